@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"proxyserver/server"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Options are the server's options, which are loaded again when Reload is
+// called. The settings are swapped all at once, so requests being handled
+// aren't interrupted.
+type Options struct {
+	loader   Loader
+	settings atomic.Pointer[Settings]
+}
+
+var _ server.Options = (*Options)(nil)
+
+// NewOptions loads the settings with the loader.
+func NewOptions(loader Loader) (*Options, error) {
+	settings, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	o := &Options{loader: loader}
+	o.settings.Store(&settings)
+	return o, nil
+}
+
+// Reload loads the settings again. They're left unchanged if they can't be
+// loaded, or if the new access control settings aren't valid.
+func (o *Options) Reload() error {
+	settings, err := o.loader.Load()
+	if err != nil {
+		return err
+	}
+	reloaded := &Options{loader: o.loader}
+	reloaded.settings.Store(&settings)
+	if err := server.ValidateAuthOptions(reloaded); err != nil {
+		return err
+	}
+	if restartNeeded(*o.settings.Load(), settings) {
+		log.Printf("Some of the changed settings only take effect after a restart")
+	}
+	o.settings.Store(&settings)
+	return nil
+}
+
+// ReloadOnSignal reloads the settings in the background whenever one of the
+// signals is received, e.g. SIGHUP.
+func (o *Options) ReloadOnSignal(signals ...os.Signal) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	go func() {
+		for range received {
+			if err := o.Reload(); err != nil {
+				log.Printf("Unable to reload settings, keeping the current ones: %v", err)
+				continue
+			}
+			log.Printf("Reloaded settings")
+		}
+	}()
+}
+
+// Settings returns the current settings.
+func (o *Options) Settings() Settings {
+	return *o.settings.Load()
+}
+
+// restartNeeded returns whether any of the settings which are only read when
+// the server starts have changed. The others are read on every request.
+func restartNeeded(old, new Settings) bool {
+	for _, s := range []*Settings{&old, &new} {
+		s.Verbose = false
+		s.GetTimeout = 0
+		s.SendTimeout = 0
+		s.ArticleTimeout = 0
+		s.AllowedConsumerKeys = ""
+		s.AllowedAccessTokens = ""
+		s.AllowedNetworks = ""
+		s.BasicAuthUsername = ""
+		s.BasicAuthPassword = ""
+		s.BasicAuthPasswordFile = ""
+	}
+	return old != new
+}
+
+// list splits a comma separated setting.
+func list(setting string) []string {
+	var items []string
+	for _, item := range strings.Split(setting, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (o *Options) Port() int                      { return o.settings.Load().Port }
+func (o *Options) Verbose() bool                  { return o.settings.Load().Verbose }
+func (o *Options) BackendName() string            { return o.settings.Load().Backend }
+func (o *Options) BackendEndpoint() string        { return o.settings.Load().BackendEndpoint }
+func (o *Options) BackendBearerToken() string     { return o.settings.Load().BackendBearerToken }
+func (o *Options) BackendClientID() string        { return o.settings.Load().BackendClientID }
+func (o *Options) BackendClientSecret() string    { return o.settings.Load().BackendClientSecret }
+func (o *Options) BackendUsername() string        { return o.settings.Load().BackendUsername }
+func (o *Options) BackendPassword() string        { return o.settings.Load().BackendPassword }
+func (o *Options) DataDir() string                { return o.settings.Load().DataDir }
+func (o *Options) UsersFile() string              { return o.settings.Load().UsersFile }
+func (o *Options) ImageMaxWidth() int             { return o.settings.Load().ImageMaxWidth }
+func (o *Options) GetTimeout() time.Duration      { return o.settings.Load().GetTimeout }
+func (o *Options) SendTimeout() time.Duration     { return o.settings.Load().SendTimeout }
+func (o *Options) ArticleTimeout() time.Duration  { return o.settings.Load().ArticleTimeout }
+func (o *Options) BackendRetries() int            { return o.settings.Load().BackendRetries }
+func (o *Options) BackendFailureThreshold() int   { return o.settings.Load().BackendFailureThreshold }
+func (o *Options) GetCacheTTL() time.Duration     { return o.settings.Load().GetCacheTTL }
+func (o *Options) ArticleCacheTTL() time.Duration { return o.settings.Load().ArticleCacheTTL }
+func (o *Options) PrefetchWorkers() int           { return o.settings.Load().PrefetchWorkers }
+func (o *Options) DigestSchedule() string         { return o.settings.Load().DigestSchedule }
+func (o *Options) DigestDir() string              { return o.settings.Load().DigestDir }
+func (o *Options) DigestFormat() string           { return o.settings.Load().DigestFormat }
+func (o *Options) AllowedConsumerKeys() []string  { return list(o.settings.Load().AllowedConsumerKeys) }
+func (o *Options) AllowedAccessTokens() []string  { return list(o.settings.Load().AllowedAccessTokens) }
+func (o *Options) AllowedNetworks() []string      { return list(o.settings.Load().AllowedNetworks) }
+func (o *Options) BasicAuthUsername() string      { return o.settings.Load().BasicAuthUsername }
+func (o *Options) BasicAuthPassword() string      { return o.settings.Load().BasicAuthPassword }
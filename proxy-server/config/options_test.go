@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOptions_Reload(t *testing.T) {
+	path := writeFile(t, "config.toml", `get_timeout = "10s"`)
+	options, err := NewOptions(Loader{Path: path, LookupEnv: fakeEnv(nil)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := options.GetTimeout(); got != 10*time.Second {
+		t.Errorf("Wanted a 10s timeout, got %v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`get_timeout = "20s"`), 0o600); err != nil {
+		t.Fatalf("Unable to write config file: %v", err)
+	}
+	if err := options.Reload(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := options.GetTimeout(); got != 20*time.Second {
+		t.Errorf("Wanted the reloaded 20s timeout, got %v", got)
+	}
+
+	// Broken config files don't replace working settings.
+	if err := os.WriteFile(path, []byte(`get_timeout = "later"`), 0o600); err != nil {
+		t.Fatalf("Unable to write config file: %v", err)
+	}
+	if err := options.Reload(); err == nil {
+		t.Error("Wanted an error reloading an invalid config file")
+	}
+	if got := options.GetTimeout(); got != 20*time.Second {
+		t.Errorf("Wanted the 20s timeout kept, got %v", got)
+	}
+}
+
+func TestOptions_ReloadInvalidAccessControl(t *testing.T) {
+	path := writeFile(t, "config.toml", `allowed_networks = "10.0.0.0/8"
+basic_auth_username = "kobo"
+basic_auth_password = "secret"`)
+	options, err := NewOptions(Loader{Path: path, LookupEnv: fakeEnv(nil)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, contents := range []string{
+		`allowed_networks = "10.0.0.0/33"
+basic_auth_username = "kobo"
+basic_auth_password = "secret"`,
+		`allowed_networks = "192.168.0.0/16"
+basic_auth_username = "kobo"`,
+	} {
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatalf("Unable to write config file: %v", err)
+		}
+		if err := options.Reload(); err == nil {
+			t.Errorf("Wanted an error reloading %q", contents)
+		}
+		if got := options.AllowedNetworks(); len(got) != 1 || got[0] != "10.0.0.0/8" {
+			t.Errorf("Wanted the allowed networks kept, got %v", got)
+		}
+		if options.BasicAuthUsername() != "kobo" || options.BasicAuthPassword() != "secret" {
+			t.Errorf("Wanted the basic auth credentials kept, got %q and %q", options.BasicAuthUsername(), options.BasicAuthPassword())
+		}
+	}
+}
+
+func TestOptions_ReloadOnSignal(t *testing.T) {
+	path := writeFile(t, "config.toml", `send_timeout = "10s"`)
+	options, err := NewOptions(Loader{Path: path, LookupEnv: fakeEnv(nil)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	options.ReloadOnSignal(syscall.SIGHUP)
+
+	if err := os.WriteFile(path, []byte(`send_timeout = "20s"`), 0o600); err != nil {
+		t.Fatalf("Unable to write config file: %v", err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Unable to send signal: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for options.SendTimeout() != 20*time.Second {
+		if time.Now().After(deadline) {
+			t.Fatalf("Wanted the settings reloaded on SIGHUP, got %v", options.SendTimeout())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRestartNeeded(t *testing.T) {
+	old := Defaults()
+	live := old
+	live.GetTimeout = time.Minute
+	live.Verbose = false
+	if restartNeeded(old, live) {
+		t.Error("Wanted timeouts and logging to change without a restart")
+	}
+	changed := old
+	changed.BackendEndpoint = "http://other"
+	if !restartNeeded(old, changed) {
+		t.Error("Wanted a restart for a new backend endpoint")
+	}
+}
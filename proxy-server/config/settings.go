@@ -0,0 +1,235 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config loads the proxy's settings from command line flags, a TOML
+// config file and environment variables, and reloads them while it's running.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	toml "github.com/pelletier/go-toml"
+)
+
+// EnvPrefix starts the environment variable for each setting, followed by its
+// name in upper case, e.g. POCKET_PROXY_BACKEND_ENDPOINT.
+const EnvPrefix = "POCKET_PROXY_"
+
+// Settings are everything the proxy can be configured with. Each setting has
+// the same name as a command line flag, a key in the config file and an
+// environment variable.
+type Settings struct {
+	Port                    int
+	Verbose                 bool
+	Backend                 string
+	BackendEndpoint         string
+	BackendBearerToken      string
+	BackendClientID         string
+	BackendClientSecret     string
+	BackendUsername         string
+	BackendPassword         string
+	DataDir                 string
+	UsersFile               string
+	ImageMaxWidth           int
+	GetTimeout              time.Duration
+	SendTimeout             time.Duration
+	ArticleTimeout          time.Duration
+	BackendRetries          int
+	BackendFailureThreshold int
+	GetCacheTTL             time.Duration
+	ArticleCacheTTL         time.Duration
+	PrefetchWorkers         int
+	DigestSchedule          string
+	DigestDir               string
+	DigestFormat            string
+	// Comma separated lists.
+	AllowedConsumerKeys string
+	AllowedAccessTokens string
+	AllowedNetworks     string
+	BasicAuthUsername   string
+	BasicAuthPassword   string
+
+	// Files the secrets are read from instead, so they don't have to be passed
+	// on the command line or in the environment.
+	BackendBearerTokenFile  string
+	BackendClientSecretFile string
+	BackendPasswordFile     string
+	BasicAuthPasswordFile   string
+}
+
+// Defaults returns the settings used when nothing else is given.
+func Defaults() Settings {
+	return Settings{
+		Port:                    8080,
+		Verbose:                 true,
+		Backend:                 "readeck",
+		DataDir:                 "data",
+		ImageMaxWidth:           1264,
+		GetTimeout:              60 * time.Second,
+		SendTimeout:             30 * time.Second,
+		ArticleTimeout:          60 * time.Second,
+		BackendRetries:          3,
+		BackendFailureThreshold: 5,
+		ArticleCacheTTL:         24 * time.Hour,
+		PrefetchWorkers:         4,
+		DigestFormat:            "epub",
+	}
+}
+
+// RegisterFlags adds a flag for each setting to fs, which sets it in s. The
+// current settings are the flags' defaults.
+func (s *Settings) RegisterFlags(fs *flag.FlagSet) {
+	fs.IntVar(&s.Port, "port", s.Port, "HTTP port to listen on")
+	fs.BoolVar(&s.Verbose, "verbose", s.Verbose, "If true, dumps all request fields to stdout")
+	// Kept for existing command lines.
+	fs.BoolVar(&s.Verbose, "verbost", s.Verbose, "Same as --verbose")
+	fs.StringVar(&s.Backend, "backend", s.Backend, "The name of the backend to forward API calls to")
+	fs.StringVar(&s.BackendEndpoint, "backend_endpoint", s.BackendEndpoint, "The backend API endpoint")
+	fs.StringVar(&s.BackendBearerToken, "backend_bearer_token", s.BackendBearerToken, "The backend API bearer token used for authentication. Optional for Readeck, where devices can log in instead")
+	fs.StringVar(&s.BackendBearerTokenFile, "backend_bearer_token_file", s.BackendBearerTokenFile, "A file to read --backend_bearer_token from")
+	fs.StringVar(&s.BackendClientID, "backend_client_id", s.BackendClientID, "The OAuth client ID used to authenticate with the backend (Wallabag)")
+	fs.StringVar(&s.BackendClientSecret, "backend_client_secret", s.BackendClientSecret, "The OAuth client secret used to authenticate with the backend (Wallabag)")
+	fs.StringVar(&s.BackendClientSecretFile, "backend_client_secret_file", s.BackendClientSecretFile, "A file to read --backend_client_secret from")
+	fs.StringVar(&s.BackendUsername, "backend_username", s.BackendUsername, "The username used to authenticate with the backend (Wallabag)")
+	fs.StringVar(&s.BackendPassword, "backend_password", s.BackendPassword, "The password used to authenticate with the backend (Wallabag)")
+	fs.StringVar(&s.BackendPasswordFile, "backend_password_file", s.BackendPasswordFile, "A file to read --backend_password from")
+	fs.StringVar(&s.DataDir, "data_dir", s.DataDir, "The directory to store the server's state in")
+	fs.IntVar(&s.ImageMaxWidth, "image_max_width", s.ImageMaxWidth, "The width in pixels article images are shrunk to for the device, or 0 to link to the original images")
+	fs.StringVar(&s.UsersFile, "users_file", s.UsersFile, "A JSON file mapping Pocket access tokens to each user's backend settings")
+	fs.DurationVar(&s.GetTimeout, "get_timeout", s.GetTimeout, "How long listing articles can wait on the backend, or 0 for no limit")
+	fs.DurationVar(&s.SendTimeout, "send_timeout", s.SendTimeout, "How long saving changes can wait on the backend, or 0 for no limit")
+	fs.DurationVar(&s.ArticleTimeout, "article_timeout", s.ArticleTimeout, "How long getting an article's text or an image can wait on the backend, or 0 for no limit")
+	fs.IntVar(&s.BackendRetries, "backend_retries", s.BackendRetries, "How many times backend requests and page or image downloads which failed temporarily are retried")
+	fs.IntVar(&s.BackendFailureThreshold, "backend_failure_threshold", s.BackendFailureThreshold, "How many backend requests in a row have to fail before requests are paused for a while, or 0 to never pause")
+	fs.DurationVar(&s.GetCacheTTL, "get_cache_ttl", s.GetCacheTTL, "How long article lists from the backend are reused before asking it again. Lists are always kept to fall back on when the backend fails")
+	fs.DurationVar(&s.ArticleCacheTTL, "article_cache_ttl", s.ArticleCacheTTL, "How long articles from the backend are used before they're refreshed in the background")
+	fs.IntVar(&s.PrefetchWorkers, "prefetch_workers", s.PrefetchWorkers, "How many articles of listed items to fetch at once in the background, before the device asks for them, or 0 to disable")
+	fs.StringVar(&s.DigestSchedule, "digest_schedule", s.DigestSchedule, "When to make a book of the unread items added since the last one, as a cron schedule like \"0 7 * * *\" for 7am every day, or empty to not make any")
+	fs.StringVar(&s.DigestDir, "digest_dir", s.DigestDir, "The directory digest books are saved in and served from, by default \"digests\" in --data_dir")
+	fs.StringVar(&s.DigestFormat, "digest_format", s.DigestFormat, "The format of digest books, epub, or kepub for Kobo e-readers")
+	fs.StringVar(&s.AllowedConsumerKeys, "allowed_consumer_keys", s.AllowedConsumerKeys, "A comma separated list of the Pocket consumer keys apps must send, or empty to accept any")
+	fs.StringVar(&s.AllowedAccessTokens, "allowed_access_tokens", s.AllowedAccessTokens, "A comma separated list of the Pocket access tokens devices must send, or empty to accept any. Tokens from --users_file or logging in are always accepted")
+	fs.StringVar(&s.AllowedNetworks, "allowed_networks", s.AllowedNetworks, "A comma separated list of the client addresses to accept, as CIDR prefixes (e.g. 192.168.0.0/16) or single addresses, or empty to accept any")
+	fs.StringVar(&s.BasicAuthUsername, "basic_auth_username", s.BasicAuthUsername, "The HTTP basic auth username browsers must send for the login page, OPDS catalog, exports and EPUBs, or empty for no basic auth. The Kobo can't send it, so the Pocket API doesn't use it")
+	fs.StringVar(&s.BasicAuthPassword, "basic_auth_password", s.BasicAuthPassword, "The HTTP basic auth password browsers must send")
+	fs.StringVar(&s.BasicAuthPasswordFile, "basic_auth_password_file", s.BasicAuthPasswordFile, "A file to read --basic_auth_password from")
+}
+
+// Loader loads the settings from, in increasing priority, the defaults, the
+// config file, environment variables and the command line flags.
+type Loader struct {
+	// The TOML config file, or "" for none.
+	Path string
+	// The command line flags, only those which were set are used.
+	Flags *flag.FlagSet
+	// Looks up environment variables, or os.LookupEnv if nil.
+	LookupEnv func(string) (string, bool)
+}
+
+// Load reads the settings again, including the config file and secret files.
+func (l Loader) Load() (Settings, error) {
+	s := Defaults()
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	s.RegisterFlags(fs)
+
+	if l.Path != "" {
+		if err := loadFile(fs, l.Path); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	lookupEnv := l.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := EnvPrefix + strings.ToUpper(f.Name)
+		if value, exists := lookupEnv(name); exists && err == nil {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value for %s: %w", name, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return Settings{}, err
+	}
+
+	if l.Flags != nil {
+		l.Flags.Visit(func(f *flag.Flag) {
+			// Flags which aren't settings, like the config file itself.
+			if fs.Lookup(f.Name) == nil || err != nil {
+				return
+			}
+			err = fs.Set(f.Name, f.Value.String())
+		})
+		if err != nil {
+			return Settings{}, err
+		}
+	}
+
+	for _, secret := range []struct {
+		value *string
+		path  string
+	}{
+		{&s.BackendBearerToken, s.BackendBearerTokenFile},
+		{&s.BackendClientSecret, s.BackendClientSecretFile},
+		{&s.BackendPassword, s.BackendPasswordFile},
+		{&s.BasicAuthPassword, s.BasicAuthPasswordFile},
+	} {
+		if secret.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(secret.path)
+		if err != nil {
+			return Settings{}, fmt.Errorf("unable to read secret: %w", err)
+		}
+		*secret.value = strings.TrimSpace(string(data))
+	}
+	return s, nil
+}
+
+// loadFile sets the settings in the config file.
+func loadFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+	for _, key := range tree.Keys() {
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("unknown setting %s in config file %s", key, path)
+		}
+		var value string
+		switch v := tree.Get(key).(type) {
+		case string:
+			value = v
+		case int64, float64, bool:
+			value = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("setting %s in config file %s must be a string, number or boolean", key, path)
+		}
+		if err := fs.Set(key, value); err != nil {
+			return fmt.Errorf("invalid value for %s in config file %s: %w", key, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Unable to write %s: %v", name, err)
+	}
+	return path
+}
+
+func fakeEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, exists := env[name]
+		return value, exists
+	}
+}
+
+func TestLoader_Priority(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+port = 9090
+backend = "wallabag"
+backend_endpoint = "http://file"
+get_timeout = "10s"
+verbose = false
+`)
+	var cmdline Settings
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	cmdline.RegisterFlags(flags)
+	flags.String("config", "", "")
+	if err := flags.Parse([]string{"--config=" + path, "--backend_endpoint=http://flag"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loader := Loader{
+		Path:      path,
+		Flags:     flags,
+		LookupEnv: fakeEnv(map[string]string{"POCKET_PROXY_BACKEND": "karakeep", "POCKET_PROXY_BACKEND_ENDPOINT": "http://env"}),
+	}
+	settings, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := Defaults()
+	want.Port = 9090
+	want.Backend = "karakeep"
+	want.BackendEndpoint = "http://flag"
+	want.GetTimeout = 10 * time.Second
+	want.Verbose = false
+	if settings != want {
+		t.Errorf("Wanted %+v, got %+v", want, settings)
+	}
+}
+
+func TestLoader_SecretFiles(t *testing.T) {
+	token := writeFile(t, "token", "secret-token\n")
+	password := writeFile(t, "password", "secret-password")
+	path := writeFile(t, "config.toml", `backend_bearer_token = "ignored"
+backend_bearer_token_file = "`+token+`"`)
+
+	loader := Loader{Path: path, LookupEnv: fakeEnv(map[string]string{"POCKET_PROXY_BACKEND_PASSWORD_FILE": password})}
+	settings, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if settings.BackendBearerToken != "secret-token" || settings.BackendPassword != "secret-password" {
+		t.Errorf("Wanted the secrets from their files, got %+v", settings)
+	}
+
+	loader.LookupEnv = fakeEnv(map[string]string{"POCKET_PROXY_BACKEND_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")})
+	if _, err := loader.Load(); err == nil {
+		t.Error("Wanted an error for a missing secret file")
+	}
+}
+
+func TestLoader_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "unknown setting", content: `backend_url = "http://test"`, wantErr: "unknown setting backend_url"},
+		{name: "table", content: "[backend]\nname = \"readeck\"", wantErr: "must be a string, number or boolean"},
+		{name: "wrong type", content: `port = "eighty"`, wantErr: "invalid value for port"},
+		{name: "invalid TOML", content: `port = `, wantErr: "unable to parse config file"},
+		{name: "invalid environment variable", env: map[string]string{"POCKET_PROXY_GET_TIMEOUT": "soon"}, wantErr: "invalid value for POCKET_PROXY_GET_TIMEOUT"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := Loader{Path: writeFile(t, "config.toml", tc.content), LookupEnv: fakeEnv(tc.env)}
+			_, err := loader.Load()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Wanted an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Tuesday.
+	start := time.Date(2025, 3, 4, 7, 30, 15, 0, time.UTC)
+	testCases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 3, 4, 7, 31, 0, 0, time.UTC)},
+		{"0 7 * * *", time.Date(2025, 3, 5, 7, 0, 0, 0, time.UTC)},
+		{"45 7 * * *", time.Date(2025, 3, 4, 7, 45, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2025, 3, 4, 7, 40, 0, 0, time.UTC)},
+		{"0 8-18/4 * * *", time.Date(2025, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{"0 7 * * 1,5", time.Date(2025, 3, 7, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * 0", time.Date(2025, 3, 9, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * 7", time.Date(2025, 3, 9, 7, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are given.
+		{"0 0 10 * 4", time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 12 *", time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			schedule, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("Parse() returned error: %v", err)
+			}
+			if got := schedule.Next(start); !got.Equal(tc.want) {
+				t.Errorf("Next() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextNever(t *testing.T) {
+	schedule, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if got := schedule.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Wanted no next time for February 31st, got %v", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) wanted an error", expr)
+		}
+	}
+}
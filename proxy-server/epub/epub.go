@@ -0,0 +1,316 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package epub writes EPUB 3 books, for reading articles on e-readers which
+// can't fetch them themselves.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The image types every EPUB reader has to support, and their file extensions.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ImageExtension returns the file extension for images of the content type,
+// or false if EPUB readers don't have to support them.
+func ImageExtension(contentType string) (string, bool) {
+	ext, supported := imageExtensions[contentType]
+	return ext, supported
+}
+
+// Book is an EPUB with a chapter per article.
+type Book struct {
+	// A unique identifier, made up from the title and time if empty.
+	ID       string
+	Title    string
+	Author   string
+	Language string
+	Modified time.Time
+	Chapters []Chapter
+	Images   []Image
+	// Whether to write a KEPUB, the kind of EPUB Kobo e-readers show reading
+	// statistics for. They need a .kepub.epub extension to be recognised.
+	Kepub bool
+}
+
+// Chapter is an article in the book.
+type Chapter struct {
+	Title string
+	// The article's original URL, linked to under the title.
+	URL string
+	// The HTML of the article. It's converted to XHTML when the book is written,
+	// and can show the book's images by linking to their names.
+	HTML string
+}
+
+// Image is a file in the book which chapters can show.
+type Image struct {
+	// The path of the image in the book, e.g. "images/1.jpg".
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Write writes the book as an EPUB file.
+func (b *Book) Write(w io.Writer) error {
+	book := *b
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	if book.Modified.IsZero() {
+		book.Modified = time.Now()
+	}
+	if book.ID == "" {
+		book.ID = bookID(book.Title, book.Modified)
+	}
+
+	z := zip.NewWriter(w)
+	// The mimetype has to come first, uncompressed, so the file can be recognised
+	// from its first few bytes.
+	mimetype := []byte("application/epub+zip")
+	header := &zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	}
+	header.Modified = book.Modified
+	raw, err := z.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	if _, err := raw.Write(mimetype); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		tmpl *template.Template
+		data any
+	}{
+		{"META-INF/container.xml", containerTemplate, nil},
+		{"OEBPS/content.opf", packageTemplate, &book},
+		{"OEBPS/nav.xhtml", navTemplate, &book},
+		{"OEBPS/toc.ncx", ncxTemplate, &book},
+	}
+	for _, file := range files {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: book.Modified})
+		if err != nil {
+			return err
+		}
+		if err := file.tmpl.Execute(f, file.data); err != nil {
+			return fmt.Errorf("unable to write %s: %w", file.name, err)
+		}
+	}
+
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "OEBPS/style.css", Method: zip.Deflate, Modified: book.Modified})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, stylesheet); err != nil {
+		return err
+	}
+
+	for i, chapter := range book.Chapters {
+		body, err := chapterBody(chapter, book.Kepub)
+		if err != nil {
+			return fmt.Errorf("unable to convert chapter %q: %w", chapter.Title, err)
+		}
+		f, err := z.CreateHeader(&zip.FileHeader{Name: "OEBPS/" + chapterFile(i), Method: zip.Deflate, Modified: book.Modified})
+		if err != nil {
+			return err
+		}
+		data := chapterData{Title: chapter.Title, Language: book.Language, Body: body}
+		if err := chapterTemplate.Execute(f, &data); err != nil {
+			return fmt.Errorf("unable to write chapter %q: %w", chapter.Title, err)
+		}
+	}
+
+	for _, img := range book.Images {
+		// Images are already compressed.
+		f, err := z.CreateHeader(&zip.FileHeader{Name: "OEBPS/" + img.Name, Method: zip.Store, Modified: book.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(img.Data); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// bookID makes up a UUID URN for a book which doesn't have an ID.
+func bookID(title string, modified time.Time) string {
+	sum := sha1.Sum([]byte(title + "\x00" + modified.UTC().Format(time.RFC3339Nano)))
+	// A version 5 (name based) UUID.
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func chapterFile(i int) string {
+	return fmt.Sprintf("chapter-%03d.xhtml", i+1)
+}
+
+// siteName returns the host of the URL without "www.", or "" if it isn't a URL.
+func siteName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// escape escapes text for XML content and attributes.
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+type chapterData struct {
+	Title    string
+	Language string
+	// The XHTML of the chapter's body.
+	Body string
+}
+
+var templateFuncs = template.FuncMap{
+	"x":           escape,
+	"chapterFile": chapterFile,
+	"inc":         func(i int) int { return i + 1 },
+	"timestamp":   func(t time.Time) string { return t.UTC().Format("2006-01-02T15:04:05Z") },
+}
+
+func parseTemplate(name string, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(templateFuncs).Parse(strings.TrimLeft(text, "\n")))
+}
+
+var containerTemplate = parseTemplate("container", `
+<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`)
+
+var packageTemplate = parseTemplate("package", `
+<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{x .Language}}">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{x .ID}}</dc:identifier>
+    <dc:title>{{x .Title}}</dc:title>
+    <dc:language>{{x .Language}}</dc:language>
+{{- if .Author}}
+    <dc:creator>{{x .Author}}</dc:creator>
+{{- end}}
+    <meta property="dcterms:modified">{{timestamp .Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+{{- range $i, $chapter := .Chapters}}
+    <item id="chapter-{{inc $i}}" href="{{chapterFile $i}}" media-type="application/xhtml+xml"/>
+{{- end}}
+{{- range $i, $image := .Images}}
+    <item id="image-{{inc $i}}" href="{{x $image.Name}}" media-type="{{x $image.ContentType}}"/>
+{{- end}}
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="nav"/>
+{{- range $i, $chapter := .Chapters}}
+    <itemref idref="chapter-{{inc $i}}"/>
+{{- end}}
+  </spine>
+</package>
+`)
+
+var navTemplate = parseTemplate("nav", `
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{x .Language}}" lang="{{x .Language}}">
+<head>
+  <meta charset="UTF-8"/>
+  <title>{{x .Title}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{x .Title}}</h1>
+    <ol>
+{{- range $i, $chapter := .Chapters}}
+      <li><a href="{{chapterFile $i}}">{{x $chapter.Title}}</a></li>
+{{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`)
+
+// The EPUB 2 table of contents, which older readers use instead of the nav document.
+var ncxTemplate = parseTemplate("ncx", `
+<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1" xml:lang="{{x .Language}}">
+  <head>
+    <meta name="dtb:uid" content="{{x .ID}}"/>
+  </head>
+  <docTitle><text>{{x .Title}}</text></docTitle>
+  <navMap>
+{{- range $i, $chapter := .Chapters}}
+    <navPoint id="chapter-{{inc $i}}" playOrder="{{inc $i}}">
+      <navLabel><text>{{x $chapter.Title}}</text></navLabel>
+      <content src="{{chapterFile $i}}"/>
+    </navPoint>
+{{- end}}
+  </navMap>
+</ncx>
+`)
+
+var chapterTemplate = parseTemplate("chapter", `
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{x .Language}}" lang="{{x .Language}}">
+<head>
+  <meta charset="UTF-8"/>
+  <title>{{x .Title}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+{{.Body}}
+</body>
+</html>
+`)
+
+const stylesheet = `img { max-width: 100%; height: auto; }
+.source { font-size: 0.8em; margin-bottom: 2em; }
+`
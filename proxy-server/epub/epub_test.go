@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToXHTML(t *testing.T) {
+	testCases := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "Void elements",
+			html: `<p>One<br>Two</p><img src="images/1.jpg" alt="A">`,
+			want: `<p>One<br/>Two</p><img src="images/1.jpg" alt="A"/>`,
+		},
+		{
+			name: "Entities",
+			html: `<p>Fish &amp; chips&nbsp;&copy; <a href="https://a.com/?a=1&b=2">link</a></p>`,
+			want: "<p>Fish &amp; chips\u00a0\u00a9 <a href=\"https://a.com/?a=1&amp;b=2\">link</a></p>",
+		},
+		{
+			name: "Unclosed tags",
+			html: `<div><p>One<p>Two<ul><li>Three</div>`,
+			want: `<div><p>One</p><p>Two</p><ul><li>Three</li></ul></div>`,
+		},
+		{
+			name: "Removed elements and comments",
+			html: `<p>Text<!-- a -- comment --><script>if (a < b) {}</script></p><iframe src="https://a.com"></iframe><xmp><b></xmp>`,
+			want: `<p>Text</p>`,
+		},
+		{
+			name: "Attributes",
+			html: `<p class="x" style="color: red" onclick="evil()" data-x="1"><a href="javascript:evil()" title="T">link</a></p>`,
+			want: `<p><a title="T">link</a></p>`,
+		},
+		{
+			name: "Unknown elements",
+			html: `<p>One<o:p>Two<custom-tag><b>Three</b></custom-tag></o:p></p>`,
+			want: `<p>OneTwo<b>Three</b></p>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := toXHTML(tc.html)
+			if err != nil {
+				t.Fatalf("toXHTML() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("toXHTML() mismatch (-want +got):\n%s", diff)
+			}
+			checkWellFormed(t, "<body>"+got+"</body>")
+		})
+	}
+}
+
+func TestChapterBody(t *testing.T) {
+	chapter := Chapter{Title: "Title", URL: "https://www.a.com/1", HTML: `<div><p>One <b>two</b></p><img src="images/1-1.jpg"> <ul><li>Three</li></ul></div>`}
+
+	got, err := chapterBody(chapter, false)
+	if err != nil {
+		t.Fatalf("chapterBody() returned error: %v", err)
+	}
+	want := `<h1>Title</h1><p class="source"><a href="https://www.a.com/1">a.com</a></p>` +
+		`<div><p>One <b>two</b></p><img src="images/1-1.jpg"/> <ul><li>Three</li></ul></div>`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("chapterBody() mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = chapterBody(chapter, true)
+	if err != nil {
+		t.Fatalf("chapterBody() returned error: %v", err)
+	}
+	want = `<div id="book-columns"><div id="book-inner">` +
+		`<h1><span class="koboSpan" id="kobo.1.1">Title</span></h1>` +
+		`<p class="source"><a href="https://www.a.com/1"><span class="koboSpan" id="kobo.2.1">a.com</span></a></p>` +
+		`<div><p><span class="koboSpan" id="kobo.4.1">One </span><b><span class="koboSpan" id="kobo.4.2">two</span></b></p>` +
+		`<span class="koboSpan" id="kobo.4.3"><img src="images/1-1.jpg"/></span> ` +
+		`<ul><li><span class="koboSpan" id="kobo.5.1">Three</span></li></ul></div>` +
+		`</div></div>`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("chapterBody() for a KEPUB mismatch (-want +got):\n%s", diff)
+	}
+	checkWellFormed(t, "<body>"+got+"</body>")
+}
+
+func TestBook_Write(t *testing.T) {
+	book := Book{
+		Title:    "Unread <& more>",
+		Modified: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Chapters: []Chapter{
+			{Title: "First & best", URL: "https://www.a.com/1", HTML: `<div><p>One</p><img src="images/1-1.jpg"></div>`},
+			{Title: "Second", HTML: `<p>Two`},
+		},
+		Images: []Image{{Name: "images/1-1.jpg", ContentType: "image/jpeg", Data: []byte("not really a JPEG")}},
+	}
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unable to read the book: %v", err)
+	}
+	first := reader.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store || len(first.Extra) != 0 {
+		t.Errorf("Wanted an uncompressed mimetype first, got %q with method %d", first.Name, first.Method)
+	}
+	if !bytes.HasPrefix(buf.Bytes()[30:], []byte("mimetypeapplication/epub+zip")) {
+		t.Errorf("Wanted the mimetype at the start of the file, got %q", buf.Bytes()[:60])
+	}
+
+	files := make(map[string]string)
+	for _, f := range reader.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Unable to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("Unable to read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".ncx") || strings.HasSuffix(f.Name, ".xhtml") {
+			checkWellFormed(t, files[f.Name])
+		}
+	}
+
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/style.css", "OEBPS/chapter-001.xhtml", "OEBPS/chapter-002.xhtml"} {
+		if _, exists := files[name]; !exists {
+			t.Errorf("Book is missing %s", name)
+		}
+	}
+	if files["OEBPS/images/1-1.jpg"] != "not really a JPEG" {
+		t.Errorf("Image wasn't stored as it was")
+	}
+	for _, want := range []string{
+		`<dc:title>Unread &lt;&amp; more&gt;</dc:title>`,
+		`<meta property="dcterms:modified">2025-01-02T03:04:05Z</meta>`,
+		`<item id="image-1" href="images/1-1.jpg" media-type="image/jpeg"/>`,
+		`<itemref idref="chapter-2"/>`,
+		`<dc:identifier id="book-id">urn:uuid:`,
+	} {
+		if !strings.Contains(files["OEBPS/content.opf"], want) {
+			t.Errorf("Package document doesn't contain %q:\n%s", want, files["OEBPS/content.opf"])
+		}
+	}
+	if want := `<li><a href="chapter-001.xhtml">First &amp; best</a></li>`; !strings.Contains(files["OEBPS/nav.xhtml"], want) {
+		t.Errorf("Table of contents doesn't contain %q:\n%s", want, files["OEBPS/nav.xhtml"])
+	}
+	if want := `<p class="source"><a href="https://www.a.com/1">a.com</a></p>`; !strings.Contains(files["OEBPS/chapter-001.xhtml"], want) {
+		t.Errorf("Chapter doesn't contain %q:\n%s", want, files["OEBPS/chapter-001.xhtml"])
+	}
+}
+
+func TestImageExtension(t *testing.T) {
+	if ext, supported := ImageExtension("image/png"); !supported || ext != ".png" {
+		t.Errorf("ImageExtension(image/png) = %q, %t", ext, supported)
+	}
+	if _, supported := ImageExtension("image/avif"); supported {
+		t.Error("Wanted AVIF images to be unsupported")
+	}
+}
+
+// checkWellFormed fails the test if the document isn't well formed XML.
+func checkWellFormed(t *testing.T, doc string) {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		_, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Not well formed XML: %v\n%s", err, doc)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package epub
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements which are dropped along with their content, since e-readers can't
+// use them, or they aren't allowed in EPUB content documents.
+var removedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Noframes: true,
+	atom.Noembed:  true,
+	// Old elements whose content isn't escaped when rendered.
+	atom.Xmp:       true,
+	atom.Plaintext: true,
+	atom.Form:      true,
+	atom.Button:    true,
+	atom.Input:     true,
+	atom.Select:    true,
+	atom.Textarea:  true,
+	atom.Link:      true,
+	atom.Meta:      true,
+	atom.Base:      true,
+	atom.Object:    true,
+	atom.Embed:     true,
+	atom.Video:     true,
+	atom.Audio:     true,
+	atom.Source:    true,
+	atom.Template:  true,
+	atom.Svg:       true,
+	atom.Math:      true,
+}
+
+// Attributes which are kept, everything else is stripped.
+var keptAttrs = map[string]bool{
+	"href":    true,
+	"src":     true,
+	"alt":     true,
+	"title":   true,
+	"width":   true,
+	"height":  true,
+	"colspan": true,
+	"rowspan": true,
+	"lang":    true,
+	"dir":     true,
+}
+
+// toXHTML converts an HTML fragment to XHTML, the only HTML EPUB readers accept.
+// Elements readers can't use and comments are dropped, and so are elements HTML
+// doesn't know, though their content is kept.
+func toXHTML(fragment string) (string, error) {
+	body, err := parseBody(fragment)
+	if err != nil {
+		return "", err
+	}
+	return renderChildren(body)
+}
+
+// chapterBody returns the XHTML of the chapter's <body>: its title, a link to
+// the original, and the article.
+func chapterBody(chapter Chapter, kepub bool) (string, error) {
+	body, err := parseBody(chapter.HTML)
+	if err != nil {
+		return "", err
+	}
+
+	heading := element(atom.H1)
+	heading.AppendChild(&html.Node{Type: html.TextNode, Data: chapter.Title})
+	header := []*html.Node{heading}
+	if chapter.URL != "" {
+		link := element(atom.A, html.Attribute{Key: "href", Val: chapter.URL})
+		site := siteName(chapter.URL)
+		if site == "" {
+			site = chapter.URL
+		}
+		link.AppendChild(&html.Node{Type: html.TextNode, Data: site})
+		source := element(atom.P, html.Attribute{Key: "class", Val: "source"})
+		source.AppendChild(link)
+		header = append(header, source)
+	}
+	for _, n := range slices.Backward(header) {
+		body.InsertBefore(n, body.FirstChild)
+	}
+
+	if kepub {
+		addKoboSpans(body)
+		// Kobo's renderer lays out pages using these.
+		columns := element(atom.Div, html.Attribute{Key: "id", Val: "book-columns"})
+		inner := element(atom.Div, html.Attribute{Key: "id", Val: "book-inner"})
+		columns.AppendChild(inner)
+		for c := body.FirstChild; c != nil; c = body.FirstChild {
+			body.RemoveChild(c)
+			inner.AppendChild(c)
+		}
+		body.AppendChild(columns)
+	}
+	return renderChildren(body)
+}
+
+// parseBody parses an HTML fragment into a cleaned <body>.
+func parseBody(fragment string) (*html.Node, error) {
+	body := element(atom.Body)
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	clean(body)
+	return body, nil
+}
+
+func renderChildren(n *html.Node) (string, error) {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		// The renderer writes void elements as <br/>, and escapes text and
+		// attributes, so the result is also well formed XML.
+		if err := html.Render(&buf, c); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+func element(a atom.Atom, attrs ...html.Attribute) *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: a.String(), DataAtom: a, Attr: attrs}
+}
+
+// Elements which start a new paragraph in a KEPUB's numbering.
+var koboBlocks = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Li:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+	atom.Blockquote: true,
+	atom.Pre:        true,
+	atom.Td:         true,
+	atom.Th:         true,
+	atom.Figcaption: true,
+	atom.Div:        true,
+}
+
+// addKoboSpans wraps each run of text, and each image, in a <span> numbered
+// by paragraph and by its place in the paragraph, which Kobo e-readers use to
+// keep track of the reading position.
+func addKoboSpans(root *html.Node) {
+	paragraph, segment := 0, 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			wrap := false
+			switch {
+			case c.Type == html.TextNode:
+				wrap = strings.TrimSpace(c.Data) != ""
+			case c.Type != html.ElementNode:
+			case c.DataAtom == atom.Img:
+				wrap = true
+			default:
+				if koboBlocks[c.DataAtom] {
+					paragraph++
+					segment = 0
+				}
+				walk(c)
+			}
+			if !wrap {
+				continue
+			}
+
+			paragraph = max(paragraph, 1)
+			segment++
+			span := element(atom.Span, html.Attribute{Key: "class", Val: "koboSpan"}, html.Attribute{Key: "id", Val: fmt.Sprintf("kobo.%d.%d", paragraph, segment)})
+			n.InsertBefore(span, c)
+			n.RemoveChild(c)
+			span.AppendChild(c)
+			c = span
+		}
+	}
+	walk(root)
+}
+
+func clean(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type == html.CommentNode, c.Type == html.DoctypeNode:
+			n.RemoveChild(c)
+		case c.Type != html.ElementNode:
+		case removedTags[c.DataAtom]:
+			n.RemoveChild(c)
+		case c.DataAtom == 0:
+			// Unwrap it, and clean its children where they end up.
+			first := c.FirstChild
+			for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+				c.RemoveChild(gc)
+				n.InsertBefore(gc, c)
+			}
+			n.RemoveChild(c)
+			if first != nil {
+				next = first
+			}
+		default:
+			attrs := c.Attr[:0]
+			for _, a := range c.Attr {
+				if a.Namespace != "" || !keptAttrs[a.Key] {
+					continue
+				}
+				if (a.Key == "href" || a.Key == "src") && strings.HasPrefix(strings.ToLower(strings.TrimSpace(a.Val)), "javascript:") {
+					continue
+				}
+				attrs = append(attrs, a)
+			}
+			c.Attr = attrs
+			clean(c)
+		}
+		c = next
+	}
+}
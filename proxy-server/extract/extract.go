@@ -0,0 +1,560 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extract downloads web pages and pulls out the readable article content,
+// for backends which don't store article text themselves.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"proxyserver/httpclient"
+	"proxyserver/pocketapi"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// Don't download pages larger than this.
+const maxPageSize = 10 << 20
+
+// Some sites refuse requests that don't look like they come from a browser.
+const userAgent = "Mozilla/5.0 (compatible; PocketProxy/1.0; +https://github.com/marklar423/kobo-pocket-proxy)"
+
+// Estimated reading speed, used for the reading time.
+const wordsPerMinute = 200
+
+// Article is the readable content and metadata extracted from a page.
+type Article struct {
+	URL           string
+	Title         string
+	Byline        string
+	Excerpt       string
+	SiteName      string
+	Lang          string
+	TopImageURL   string
+	DatePublished time.Time
+	// The cleaned up HTML of the main content, with all links made absolute.
+	Content   string
+	WordCount int
+}
+
+// TimeToRead returns the estimated reading time in minutes.
+func (a Article) TimeToRead() int {
+	return int(math.Ceil(float64(a.WordCount) / wordsPerMinute))
+}
+
+// Fetch downloads the page at pageUrl with client and extracts its article content.
+func Fetch(ctx context.Context, client *httpclient.Client, pageUrl string) (Article, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl, nil)
+	if err != nil {
+		return Article{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return Article{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return Article{}, fmt.Errorf("error downloading %s: [%d] %s", pageUrl, res.StatusCode, res.Status)
+	}
+	contentType := res.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return Article{}, fmt.Errorf("unable to extract article from %s, unsupported content type %s", pageUrl, contentType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(res.Body, maxPageSize), contentType)
+	if err != nil {
+		return Article{}, err
+	}
+	// Use the final URL after redirects to resolve relative links.
+	return Extract(res.Request.URL.String(), body)
+}
+
+// Extract parses the page HTML and extracts its article content.
+func Extract(pageUrl string, page io.Reader) (Article, error) {
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return Article{}, err
+	}
+	doc, err := html.Parse(page)
+	if err != nil {
+		return Article{}, err
+	}
+
+	article := Article{URL: pageUrl}
+	readMetadata(doc, &article)
+
+	removeUnlikely(doc)
+	content := findContent(doc)
+	if content == nil {
+		return Article{}, errors.New("unable to find any article content")
+	}
+	cleanContent(content, base)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, content); err != nil {
+		return Article{}, err
+	}
+	article.Content = buf.String()
+	article.WordCount = len(strings.Fields(textContent(content)))
+	if article.TopImageURL != "" {
+		article.TopImageURL = resolve(base, article.TopImageURL)
+	}
+	return article, nil
+}
+
+// ToArticleText fills in the article text response the same way the other backends do,
+// replacing images with <!--IMG_n--> comments.
+func (a Article) ToArticleText(itemID string, article *pocketapi.ArticleTextResponse) error {
+	article.ItemID = itemID
+	article.ResolvedID = itemID
+	article.GivenURL = a.URL
+	article.NormalURL = a.URL
+	article.ResolvedNormalURL = a.URL
+	article.ResolvedURL = a.URL
+	article.DateResolved = time.Now().Format(time.RFC3339)
+	timeToRead := a.TimeToRead()
+	article.TimeToRead = &timeToRead
+
+	article.HasVideo = "0"
+	if u, err := url.Parse(a.URL); err == nil {
+		article.Host = u.Hostname()
+	}
+	article.Title = a.Title
+	if !a.DatePublished.IsZero() {
+		article.DatePublished = a.DatePublished.Format(time.RFC3339)
+		timePublished := int(a.DatePublished.Unix())
+		article.TimePublished = &timePublished
+	}
+	article.ResponseCode = "200"
+	article.MimeType = "text/html"
+	article.Encoding = "utf-8"
+	article.Excerpt = a.Excerpt
+	article.TopImageURL = a.TopImageURL
+	if a.SiteName != "" {
+		article.DomainMetadata = &pocketapi.DomainMetadata{Name: a.SiteName}
+	}
+	if a.Byline != "" {
+		id := pocketapi.Digest(a.Byline)
+		article.Authors = map[string]pocketapi.Author{
+			id: {AuthorID: id, Name: a.Byline, ItemID: itemID},
+		}
+	}
+	wordCount := a.WordCount
+	article.WordCount = &wordCount
+	one := 1
+	article.IsArticle = &one
+	zero := 0
+	article.IsIndex = &zero
+	article.IsVideo = &zero
+	article.Lang = a.Lang
+
+	if err := pocketapi.ParseArticleText(strings.NewReader(a.Content), article); err != nil {
+		return err
+	}
+	article.HasImage = "0"
+	if len(article.Images) > 0 {
+		article.HasImage = "1"
+	}
+	if len(pocketapi.ParseVideos(article.Article, itemID)) > 0 {
+		article.HasVideo = "1"
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			sb.WriteString(d.Data)
+		}
+	}
+	return sb.String()
+}
+
+func resolve(base *url.URL, ref string) string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func readMetadata(doc *html.Node, article *Article) {
+	meta := map[string]string{}
+	var title string
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.Html:
+			article.Lang = attr(n, "lang")
+		case atom.Title:
+			if title == "" {
+				title = strings.TrimSpace(textContent(n))
+			}
+		case atom.Meta:
+			key := strings.ToLower(attr(n, "property"))
+			if key == "" {
+				key = strings.ToLower(attr(n, "name"))
+			}
+			if _, exists := meta[key]; key != "" && !exists {
+				meta[key] = strings.TrimSpace(attr(n, "content"))
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := meta[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	article.Title = first("og:title", "twitter:title")
+	if article.Title == "" {
+		article.Title = title
+	}
+	article.Byline = first("author", "article:author", "dc.creator")
+	article.Excerpt = first("og:description", "description", "twitter:description")
+	article.SiteName = first("og:site_name", "application-name")
+	article.TopImageURL = first("og:image", "twitter:image")
+	if published := first("article:published_time", "date", "dc.date"); published != "" {
+		if t, err := time.Parse(time.RFC3339, published); err == nil {
+			article.DatePublished = t
+		}
+	}
+}
+
+// Elements which never contain article content.
+var removedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Svg:      true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Object:   true,
+	atom.Embed:    true,
+}
+
+var unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|header|legends|menu|modal|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|ad-break|agegate|pagination|pager|popup|newsletter|subscribe|promo`)
+var maybeCandidates = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+var positiveClass = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+var negativeClass = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|footer|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+
+func removeUnlikely(doc *html.Node) {
+	var toRemove []*html.Node
+	for n := range doc.Descendants() {
+		if n.Type == html.CommentNode {
+			toRemove = append(toRemove, n)
+			continue
+		}
+		if n.Type != html.ElementNode {
+			continue
+		}
+		// Video embeds are kept, so they can be listed as the item's videos.
+		if n.DataAtom == atom.Iframe && pocketapi.IsVideoEmbed(attr(n, "src")) {
+			continue
+		}
+		if removedTags[n.DataAtom] {
+			toRemove = append(toRemove, n)
+			continue
+		}
+		if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+			continue
+		}
+		matchString := attr(n, "class") + " " + attr(n, "id")
+		if unlikelyCandidates.MatchString(matchString) && !maybeCandidates.MatchString(matchString) {
+			toRemove = append(toRemove, n)
+		}
+	}
+	for _, n := range toRemove {
+		// The node might have been removed along with one of its ancestors already.
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, val := range []string{attr(n, "class"), attr(n, "id")} {
+		if val == "" {
+			continue
+		}
+		if negativeClass.MatchString(val) {
+			weight -= 25
+		}
+		if positiveClass.MatchString(val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.DataAtom {
+	case atom.Article:
+		score += 10
+	case atom.Div:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score
+}
+
+func linkDensity(n *html.Node) float64 {
+	textLength := len(strings.TrimSpace(textContent(n)))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == atom.A {
+			linkLength += len(strings.TrimSpace(textContent(d)))
+		}
+	}
+	return float64(linkLength) / float64(textLength)
+}
+
+// findContent scores nodes by how much paragraph text they contain (roughly following
+// the algorithm used by Readability), and returns a <div> with the best candidate and
+// any related siblings.
+func findContent(doc *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			continue
+		}
+		text := strings.TrimSpace(textContent(n))
+		if len(text) < 25 {
+			continue
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		ancestor := n.Parent
+		for level := 0; ancestor != nil && ancestor.Type == html.ElementNode && level < 3; level++ {
+			if _, exists := scores[ancestor]; !exists {
+				scores[ancestor] = initialScore(ancestor)
+				candidates = append(candidates, ancestor)
+			}
+			divider := 1.0
+			if level == 1 {
+				divider = 2
+			} else if level > 1 {
+				divider = float64(level * 3)
+			}
+			scores[ancestor] += score / divider
+			ancestor = ancestor.Parent
+		}
+	}
+
+	var top *html.Node
+	topScore := 0.0
+	for _, c := range candidates {
+		scores[c] *= 1 - linkDensity(c)
+		if top == nil || scores[c] > topScore {
+			top = c
+			topScore = scores[c]
+		}
+	}
+
+	if top == nil {
+		// Nothing looked like a paragraph, so just fall back to the whole body.
+		for n := range doc.Descendants() {
+			if n.Type == html.ElementNode && n.DataAtom == atom.Body {
+				top = n
+				break
+			}
+		}
+		if top == nil {
+			return nil
+		}
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	if top.Parent == nil || top.DataAtom == atom.Body {
+		moveChildren(top, root)
+		return root
+	}
+
+	// Include siblings which look related to the top candidate, e.g. other
+	// parts of the article split up by ads.
+	threshold := max(10, topScore*0.2)
+	topClass := attr(top, "class")
+	var keep []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == top {
+			keep = append(keep, sibling)
+			continue
+		}
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		bonus := 0.0
+		if topClass != "" && attr(sibling, "class") == topClass {
+			bonus = topScore * 0.2
+		}
+		if score, exists := scores[sibling]; exists && score+bonus >= threshold {
+			keep = append(keep, sibling)
+			continue
+		}
+		if sibling.DataAtom == atom.P {
+			text := strings.TrimSpace(textContent(sibling))
+			density := linkDensity(sibling)
+			if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". ")) {
+				keep = append(keep, sibling)
+			}
+		}
+	}
+	for _, n := range keep {
+		n.Parent.RemoveChild(n)
+		root.AppendChild(n)
+	}
+	return root
+}
+
+func moveChildren(from, to *html.Node) {
+	for c := from.FirstChild; c != nil; c = from.FirstChild {
+		from.RemoveChild(c)
+		to.AppendChild(c)
+	}
+}
+
+// Attributes which are kept in the extracted content, everything else is stripped.
+var keptAttrs = map[string]bool{
+	"href":    true,
+	"src":     true,
+	"alt":     true,
+	"title":   true,
+	"width":   true,
+	"height":  true,
+	"colspan": true,
+	"rowspan": true,
+}
+
+// cleanContent strips presentational attributes and empty elements, makes all
+// links absolute, and handles lazily loaded images.
+func cleanContent(content *html.Node, base *url.URL) {
+	var toRemove []*html.Node
+	for n := range content.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if n.DataAtom == atom.Img {
+			// Lazy loading scripts usually keep the real URL in a data attribute.
+			for _, lazyAttr := range []string{"data-src", "data-original", "data-lazy-src"} {
+				if val := attr(n, lazyAttr); val != "" {
+					setAttr(n, "src", val)
+					break
+				}
+			}
+			if attr(n, "src") == "" {
+				toRemove = append(toRemove, n)
+				continue
+			}
+		}
+
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			if !keptAttrs[a.Key] {
+				continue
+			}
+			if a.Key == "href" || a.Key == "src" {
+				a.Val = resolve(base, a.Val)
+			}
+			attrs = append(attrs, a)
+		}
+		n.Attr = attrs
+
+		switch n.DataAtom {
+		case atom.Div, atom.Span, atom.P, atom.Section:
+			if strings.TrimSpace(textContent(n)) == "" && !hasDescendant(n, atom.Img) && !hasDescendant(n, atom.Iframe) {
+				toRemove = append(toRemove, n)
+			}
+		}
+	}
+	for _, n := range toRemove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func hasDescendant(n *html.Node, a atom.Atom) bool {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"proxyserver/httpclient"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+)
+
+const testPage = `
+<html lang="en">
+<head>
+  <title>Page Title | Site</title>
+  <meta property="og:title" content="Something Great Happened">
+  <meta property="og:site_name" content="Awesome Website">
+  <meta name="description" content="A short description">
+  <meta name="author" content="John Doe">
+  <meta property="og:image" content="/images/top.jpg">
+  <meta property="article:published_time" content="2025-06-30T15:08:09Z">
+  <script>var tracking = true;</script>
+</head>
+<body>
+  <nav><a href="/">Home</a> <a href="/news">News</a></nav>
+  <div class="sidebar"><p>Subscribe to our newsletter, it's great, really, trust us.</p></div>
+  <article class="post">
+    <h1>Something Great Happened</h1>
+    <p>This is the first paragraph of the article, and it has plenty of words, commas, and other things.</p>
+    <p><img data-src="/images/inline.png" src="data:image/gif;base64,R0lGOD" class="lazy" width="10" height="10"></p>
+    <p>This is the second paragraph, which goes on a bit, with <a href="/more" class="link">a relative link</a> in it.</p>
+    <div class="embed"><iframe src="https://www.youtube.com/embed/abc123" width="560" height="315"></iframe></div>
+    <iframe src="https://ads.example.com/banner"></iframe>
+    <div class="share-buttons"><a href="https://twitter.com">Share</a></div>
+  </article>
+  <div class="comments"><p>First! This is a comment that is long enough to look like a paragraph.</p></div>
+  <footer><p>Copyright 2025, all rights reserved by the Awesome Website company.</p></footer>
+</body>
+</html>
+`
+
+func TestExtract(t *testing.T) {
+	article, err := Extract("https://awesome.com/news/great.html", strings.NewReader(testPage))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if article.Title != "Something Great Happened" {
+		t.Errorf("Unexpected title: %s", article.Title)
+	}
+	if article.Byline != "John Doe" || article.SiteName != "Awesome Website" || article.Excerpt != "A short description" || article.Lang != "en" {
+		t.Errorf("Unexpected metadata: %+v", article)
+	}
+	if article.TopImageURL != "https://awesome.com/images/top.jpg" {
+		t.Errorf("Unexpected top image URL: %s", article.TopImageURL)
+	}
+	if article.DatePublished.Unix() != 1751296089 {
+		t.Errorf("Unexpected publish date: %v", article.DatePublished)
+	}
+
+	for _, want := range []string{
+		"This is the first paragraph",
+		"This is the second paragraph",
+		`<img src="https://awesome.com/images/inline.png" width="10" height="10"/>`,
+		`<a href="https://awesome.com/more">a relative link</a>`,
+		`<iframe src="https://www.youtube.com/embed/abc123" width="560" height="315"></iframe>`,
+	} {
+		if !strings.Contains(article.Content, want) {
+			t.Errorf("Expected content to contain %q, got %s", want, article.Content)
+		}
+	}
+	for _, unwanted := range []string{"tracking", "Home", "newsletter", "Share", "First!", "Copyright", "class=", "ads.example.com"} {
+		if strings.Contains(article.Content, unwanted) {
+			t.Errorf("Expected content not to contain %q, got %s", unwanted, article.Content)
+		}
+	}
+	if article.WordCount < 30 || article.WordCount > 45 {
+		t.Errorf("Unexpected word count: %d", article.WordCount)
+	}
+	var text pocketapi.ArticleTextResponse
+	if err := article.ToArticleText("id1", &text); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text.HasVideo != "1" {
+		t.Errorf("Expected the YouTube embed to count as a video, got has_video %q", text.HasVideo)
+	}
+}
+
+func TestExtract_NoParagraphs(t *testing.T) {
+	article, err := Extract("https://awesome.com/", strings.NewReader("<html><body><div>Short</div></body></html>"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if article.Content != "<div><div>Short</div></div>" {
+		t.Errorf("Unexpected content: %s", article.Content)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article.html":
+			w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+			// "Café" in Latin-1.
+			w.Write([]byte("<html><body><article><p>Welcome to the Caf\xe9, where the coffee is good, and the words are many.</p><img src=\"/img.png\"></article></body></html>"))
+		case "/file.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	article, err := Fetch(context.Background(), httpclient.New(httpclient.DefaultOptions()), server.URL+"/article.html")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(article.Content, "Café") {
+		t.Errorf("Expected content to be decoded to UTF-8, got %s", article.Content)
+	}
+
+	var text pocketapi.ArticleTextResponse
+	if err := article.ToArticleText("id1", &text); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text.ItemID != "id1" || text.HasImage != "1" || text.Images["1"].Src != server.URL+"/img.png" {
+		t.Errorf("Unexpected article text: %+v", text)
+	}
+	if !strings.Contains(text.Article, "<!--IMG_1-->") {
+		t.Errorf("Expected image placeholder in article, got %s", text.Article)
+	}
+
+	if _, err := Fetch(context.Background(), httpclient.New(httpclient.DefaultOptions()), server.URL+"/file.pdf"); err == nil {
+		t.Error("Wanted error for non-HTML content, got nil instead")
+	}
+	if _, err := Fetch(context.Background(), httpclient.New(httpclient.DefaultOptions()), server.URL+"/missing"); err == nil {
+		t.Error("Wanted error for missing page, got nil instead")
+	}
+}
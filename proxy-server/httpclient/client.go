@@ -0,0 +1,257 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpclient is the HTTP client backends use to talk to their APIs. It
+// retries requests which failed because of a temporary problem, and stops
+// sending requests for a while once a backend looks to be down, so devices get
+// a quick error instead of waiting for every request to time out.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the backend is
+// considered down.
+var ErrCircuitOpen = errors.New("backend is unavailable, not sending requests until it recovers")
+
+type Options struct {
+	// How many times a failed request is retried, or 0 to never retry.
+	MaxRetries int
+	// The delay before the first retry, which doubles for each retry after that.
+	BaseDelay time.Duration
+	// The longest delay between retries. Requests the backend asks to retry
+	// later than this with Retry-After are given up on.
+	MaxDelay time.Duration
+	// How many requests in a row have to fail before requests stop being sent,
+	// or 0 to always send them.
+	FailureThreshold int
+	// How long requests stop being sent for once the threshold is reached,
+	// after which one request is let through to check whether the backend is back.
+	Cooldown time.Duration
+}
+
+// DefaultOptions returns the options used unless configured otherwise.
+func DefaultOptions() Options {
+	return Options{
+		MaxRetries:       3,
+		BaseDelay:        200 * time.Millisecond,
+		MaxDelay:         5 * time.Second,
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+// The transport shared by every client, so connections to the same backend are reused.
+var sharedTransport = func() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 16
+	return transport
+}()
+
+// Client sends requests to one backend. It's safe for concurrent use.
+type Client struct {
+	client  *http.Client
+	options Options
+	breaker breaker
+	// Waits between retries, replaceable for tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func New(options Options) *Client {
+	return &Client{
+		client:  &http.Client{Transport: sharedTransport},
+		options: options,
+		sleep:   sleepContext,
+	}
+}
+
+type idempotentKey struct{}
+
+// Idempotent marks a request as safe to retry even though its method isn't,
+// e.g. a PATCH which sets fields to fixed values.
+func Idempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), idempotentKey{}, true))
+}
+
+func canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body can't be sent again.
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// isTemporary returns whether a response status means the request may succeed later.
+func isTemporary(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Do sends the request, retrying it if it's idempotent and fails temporarily.
+// Like http.Client.Do, the caller has to close the response body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if !c.breaker.allow(c.options, time.Now()) {
+		return nil, ErrCircuitOpen
+	}
+
+	retry := canRetry(req)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		res, err := c.client.Do(req)
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			// Given up on by the caller, which says nothing about the backend.
+			c.breaker.release()
+			if err == nil {
+				return res, nil
+			}
+			return nil, err
+		}
+		failed := err != nil || res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+		if !retry || attempt >= c.options.MaxRetries || (err == nil && !isTemporary(res.StatusCode)) {
+			c.breaker.record(c.options, !failed, time.Now())
+			return res, err
+		}
+
+		delay := c.backoff(attempt)
+		if err == nil {
+			if after, ok := retryAfter(res, time.Now()); ok {
+				if after > c.options.MaxDelay {
+					c.breaker.record(c.options, false, time.Now())
+					return res, nil
+				}
+				delay = after
+			}
+			// Read the rest of the body so the connection can be reused.
+			io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+			res.Body.Close()
+		}
+		if err := c.sleep(req.Context(), delay); err != nil {
+			c.breaker.release()
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the delay before the given retry, with full jitter so clients
+// which failed at the same time don't all retry at the same time.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.options.BaseDelay << attempt
+	if delay <= 0 || delay > c.options.MaxDelay {
+		delay = c.options.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or a date.
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	val := res.Header.Get("Retry-After")
+	if val == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(val); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(val); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// breaker counts failed requests in a row, and stops requests for the cooldown
+// once there are too many. After the cooldown, a single request is let through,
+// and its result decides whether to close the breaker or wait again.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow(options Options, now time.Time) bool {
+	if options.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < options.FailureThreshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) record(options Options, success bool, now time.Time) {
+	if options.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= options.FailureThreshold {
+		b.openUntil = now.Add(options.Cooldown)
+	}
+}
+
+// release lets another request check the backend, when a check was abandoned
+// without finding anything out.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
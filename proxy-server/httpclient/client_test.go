@@ -0,0 +1,240 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client which doesn't wait between retries, and
+// records how long it would have waited.
+func newTestClient(options Options) (*Client, *[]time.Duration) {
+	var delays []time.Duration
+	client := New(options)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return client, &delays
+}
+
+// failingServer fails the first failures requests with the given status.
+func failingServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClient_RetriesTemporaryFailures(t *testing.T) {
+	server, requests := failingServer(t, 2, http.StatusBadGateway, nil)
+	client, delays := newTestClient(DefaultOptions())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status: %d", res.StatusCode)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Wanted 3 requests, got %d", got)
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("Wanted 2 delays, got %v", *delays)
+	}
+	for i, d := range *delays {
+		// Jitter keeps each delay between half and all of the backoff.
+		max := DefaultOptions().BaseDelay << i
+		if d < max/2 || d > max {
+			t.Errorf("Delay %d out of range: %s", i, d)
+		}
+	}
+}
+
+func TestClient_RetriesReplayBody(t *testing.T) {
+	var bodies []string
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	client, _ := newTestClient(DefaultOptions())
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Errorf("Wanted the body to be sent twice, got %q", bodies)
+	}
+}
+
+func TestClient_DoesNotRetry(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		status int
+	}{
+		{name: "NotIdempotent", method: http.MethodPost, status: http.StatusBadGateway},
+		{name: "PermanentFailure", method: http.MethodGet, status: http.StatusNotFound},
+		{name: "ServerError", method: http.MethodGet, status: http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, requests := failingServer(t, 1, tc.status, nil)
+			client, _ := newTestClient(DefaultOptions())
+
+			req, _ := http.NewRequest(tc.method, server.URL, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tc.status {
+				t.Errorf("Unexpected status, want %d got %d", tc.status, res.StatusCode)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("Wanted 1 request, got %d", got)
+			}
+		})
+	}
+}
+
+func TestClient_RetriesMarkedIdempotent(t *testing.T) {
+	server, requests := failingServer(t, 1, http.StatusBadGateway, nil)
+	client, _ := newTestClient(DefaultOptions())
+
+	req, _ := http.NewRequest(http.MethodPatch, server.URL, strings.NewReader("{}"))
+	res, err := client.Do(Idempotent(req))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Wanted 2 requests, got %d", got)
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	server, _ := failingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"2"}})
+	client, delays := newTestClient(DefaultOptions())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if len(*delays) != 1 || (*delays)[0] != 2*time.Second {
+		t.Errorf("Wanted to wait 2s as asked, got %v", *delays)
+	}
+
+	// Asking to wait longer than the maximum delay gives up right away.
+	server, requests := failingServer(t, 1, http.StatusServiceUnavailable, http.Header{"Retry-After": {"3600"}})
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	res, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable || requests.Load() != 1 {
+		t.Errorf("Wanted one failed request, got status %d after %d requests", res.StatusCode, requests.Load())
+	}
+}
+
+func TestRetryAfter_Date(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &http.Response{Header: http.Header{"Retry-After": {now.Add(5 * time.Second).Format(http.TimeFormat)}}}
+	if got, ok := retryAfter(res, now); !ok || got != 5*time.Second {
+		t.Errorf("Unexpected delay, want 5s got %s", got)
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	server, requests := failingServer(t, 1000, http.StatusBadGateway, nil)
+	options := Options{FailureThreshold: 2, Cooldown: time.Hour}
+	client, _ := newTestClient(options)
+
+	get := func() error {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		res, err := client.Do(req)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+	for range 2 {
+		if err := get(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Wanted the circuit to be open, got %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Wanted no requests while the circuit is open, got %d", got)
+	}
+
+	// After the cooldown, one request checks whether the backend is back.
+	client.breaker.openUntil = time.Now()
+	if !client.breaker.allow(options, time.Now()) {
+		t.Fatal("Wanted a request to be let through after the cooldown")
+	}
+	if client.breaker.allow(options, time.Now()) {
+		t.Error("Wanted only one request to be let through while checking")
+	}
+	client.breaker.record(options, true, time.Now())
+	if !client.breaker.allow(options, time.Now()) {
+		t.Error("Wanted the circuit to close after a successful request")
+	}
+}
+
+func TestClient_CancelledWhileWaiting(t *testing.T) {
+	server, _ := failingServer(t, 1000, http.StatusBadGateway, nil)
+	client := New(Options{MaxRetries: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wanted the deadline to stop retries, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ErrUnavailable is wrapped by the errors for responses which mean the backend
+// is down or overloaded, rather than that something was wrong with the request.
+var ErrUnavailable = errors.New("backend is temporarily unavailable")
+
+type unavailableError struct {
+	error
+}
+
+func (e unavailableError) Unwrap() []error {
+	return []error{e.error, ErrUnavailable}
+}
+
+// ResponseError returns err, which describes the failed response, marked as
+// ErrUnavailable if the response's status means it may succeed later.
+func ResponseError(res *http.Response, err error) error {
+	if isTemporary(res.StatusCode) {
+		return unavailableError{err}
+	}
+	return err
+}
+
+// IsUnavailable returns whether the request failed because the backend couldn't
+// be reached or couldn't handle it right now, so it's worth sending again later.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// Failing to connect or losing the connection.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
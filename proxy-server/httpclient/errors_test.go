@@ -0,0 +1,54 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Nil", err: nil, want: false},
+		{name: "Rejected", err: ResponseError(&http.Response{StatusCode: http.StatusNotFound}, errors.New("not found")), want: false},
+		{name: "ServerDown", err: ResponseError(&http.Response{StatusCode: http.StatusBadGateway}, errors.New("bad gateway")), want: true},
+		{name: "CircuitOpen", err: fmt.Errorf("calling backend: %w", ErrCircuitOpen), want: true},
+		{name: "Timeout", err: &url.Error{Op: "Get", URL: "http://test", Err: context.DeadlineExceeded}, want: true},
+		{name: "ConnectionRefused", err: &url.Error{Op: "Get", URL: "http://test", Err: errors.New("connection refused")}, want: true},
+		{name: "Cancelled", err: &url.Error{Op: "Get", URL: "http://test", Err: context.Canceled}, want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsUnavailable(tc.err); got != tc.want {
+				t.Errorf("Unexpected result for %v, want %t got %t", tc.err, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestResponseError_KeepsMessage(t *testing.T) {
+	err := ResponseError(&http.Response{StatusCode: http.StatusServiceUnavailable}, errors.New("error calling API: [503]"))
+	if err.Error() != "error calling API: [503]" {
+		t.Errorf("Unexpected message: %s", err)
+	}
+}
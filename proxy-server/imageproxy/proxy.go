@@ -0,0 +1,235 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"proxyserver/httpclient"
+	"proxyserver/internal/atomicfile"
+)
+
+// Images bigger than this aren't worth downloading onto a Kobo.
+const maxImageSize = 20 << 20
+
+// The file extensions cached images are stored with, and their content types.
+var cacheExtensions = map[string]string{
+	".jpg": "image/jpeg",
+	".png": "image/png",
+}
+
+// Proxy fetches, transcodes and caches images. Image URLs are signed, so the
+// proxy can only be used for images the server linked to itself.
+type Proxy struct {
+	maxWidth int
+	// Where transcoded images are cached, or empty to not cache them.
+	cacheDir string
+	key      []byte
+	client   *httpclient.Client
+}
+
+func New(cacheDir string, maxWidth int, key []byte) *Proxy {
+	return &Proxy{
+		maxWidth: maxWidth,
+		cacheDir: cacheDir,
+		key:      key,
+		client:   httpclient.New(httpclient.DefaultOptions()),
+	}
+}
+
+// SetHTTPClient replaces the client used to download images.
+func (p *Proxy) SetHTTPClient(client *httpclient.Client) {
+	p.client = client
+}
+
+// LoadKey reads the key used to sign image URLs, creating a random one if it doesn't exist.
+// Keeping it on disk means the image URLs in articles already on devices keep working
+// after a restart.
+func LoadKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Sign returns the signature for an image URL.
+func (p *Proxy) Sign(src string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(src))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Verify checks that the signature came from Sign.
+func (p *Proxy) Verify(src string, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	want, _ := hex.DecodeString(p.Sign(src))
+	return hmac.Equal(got, want)
+}
+
+// Fetcher downloads the original image.
+type Fetcher func(ctx context.Context, src string) (io.ReadCloser, error)
+
+// Get returns the transcoded image, from the cache if possible.
+func (p *Proxy) Get(ctx context.Context, src string) (Image, error) {
+	return p.GetWith(ctx, src, p.Fetch)
+}
+
+// GetWith is like Get, but downloads the original image with the given fetcher,
+// e.g. for images which need authentication.
+func (p *Proxy) GetWith(ctx context.Context, src string, fetch Fetcher) (Image, error) {
+	if img, found := p.readCache(src); found {
+		return img, nil
+	}
+
+	body, err := readImage(ctx, src, fetch)
+	if err != nil {
+		return Image{}, err
+	}
+	img, err := Transcode(bytes.NewReader(body), p.maxWidth)
+	if err != nil {
+		return Image{}, fmt.Errorf("unable to transcode %s: %w", src, err)
+	}
+	if err := p.writeCache(src, img); err != nil {
+		// Not fatal, it'll just be fetched again next time.
+		log.Printf("Unable to cache image %s: %v", src, err)
+	}
+	return img, nil
+}
+
+// Fetch downloads an image over HTTP.
+func (p *Proxy) Fetch(ctx context.Context, src string) (io.ReadCloser, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported image URL %s", src)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, fmt.Errorf("error fetching image %s: [%d] %s", src, res.StatusCode, res.Status)
+	}
+	return res.Body, nil
+}
+
+// readImage downloads the whole image, as long as it isn't too big.
+func readImage(ctx context.Context, src string, fetch Fetcher) ([]byte, error) {
+	body, err := fetch(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("image %s is too big", src)
+	}
+	return data, nil
+}
+
+// GetOriginal downloads the image with the given fetcher without transcoding it,
+// and detects its content type.
+func GetOriginal(ctx context.Context, src string, fetch Fetcher) (Image, error) {
+	data, err := readImage(ctx, src, fetch)
+	if err != nil {
+		return Image{}, err
+	}
+	img := Image{Data: data, ContentType: http.DetectContentType(data)}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		img.Width, img.Height = config.Width, config.Height
+	}
+	return img, nil
+}
+
+// cachePath returns the path of the cached image without its extension. The
+// width is part of the key, so changing it doesn't serve stale sizes.
+func (p *Proxy) cachePath(src string) string {
+	sum := sha1.Sum([]byte(src))
+	return filepath.Join(p.cacheDir, fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), p.maxWidth))
+}
+
+func (p *Proxy) readCache(src string) (Image, bool) {
+	if p.cacheDir == "" {
+		return Image{}, false
+	}
+	for ext, contentType := range cacheExtensions {
+		data, err := os.ReadFile(p.cachePath(src) + ext)
+		if err != nil {
+			continue
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		return Image{Data: data, ContentType: contentType, Width: config.Width, Height: config.Height}, true
+	}
+	return Image{}, false
+}
+
+func (p *Proxy) writeCache(src string, img Image) error {
+	if p.cacheDir == "" {
+		return nil
+	}
+	ext := ".jpg"
+	if img.ContentType == "image/png" {
+		ext = ".png"
+	}
+	return atomicfile.WriteFile(p.cachePath(src)+ext, img.Data)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestProxy_GetCaches(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/image.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(encodePNG(t, image.NewGray(image.Rect(0, 0, 300, 100))))
+	}))
+	defer server.Close()
+
+	proxy := New(t.TempDir(), 150, []byte("key"))
+	for range 2 {
+		img, err := proxy.Get(context.Background(), server.URL+"/image.png")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if img.ContentType != "image/png" || img.Width != 150 || img.Height != 50 {
+			t.Errorf("Unexpected image: %s %dx%d", img.ContentType, img.Width, img.Height)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Wanted the image to be fetched once, got %d requests", requests)
+	}
+
+	if _, err := proxy.Get(context.Background(), server.URL+"/missing.png"); err == nil {
+		t.Error("Wanted error for a missing image, got nil instead")
+	}
+	if _, err := proxy.Get(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Error("Wanted error for a file URL, got nil instead")
+	}
+}
+
+func TestProxy_Sign(t *testing.T) {
+	proxy := New("", 100, []byte("key"))
+	signature := proxy.Sign("https://test.com/a.png")
+	if !proxy.Verify("https://test.com/a.png", signature) {
+		t.Error("Wanted signature to verify")
+	}
+	if proxy.Verify("https://test.com/b.png", signature) {
+		t.Error("Wanted signature for another URL to fail")
+	}
+	if New("", 100, []byte("other")).Verify("https://test.com/a.png", signature) {
+		t.Error("Wanted signature from another key to fail")
+	}
+	if proxy.Verify("https://test.com/a.png", "not hex") {
+		t.Error("Wanted malformed signature to fail")
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "key")
+	key, err := LoadKey(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reloaded, err := LoadKey(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reloading: %v", err)
+	}
+	if len(key) != 32 || !bytes.Equal(key, reloaded) {
+		t.Errorf("Wanted the same 32 byte key after reloading, got %x and %x", key, reloaded)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imageproxy fetches article images and converts them into something
+// e-ink readers handle well: small, grayscale JPEGs or PNGs.
+package imageproxy
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Registers the formats image.Decode understands, on top of JPEG and PNG.
+	_ "image/gif"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Good enough for grayscale on e-ink, and much smaller than the default.
+const jpegQuality = 75
+
+// Image is a transcoded image, ready to send to the device.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Transcode decodes the image, shrinks it to at most maxWidth pixels wide, and
+// re-encodes it in grayscale. Line art formats (PNG and GIF) stay as PNGs so
+// text in them stays sharp, everything else becomes a JPEG.
+//
+// Formats Go can't decode, like AVIF and SVG, return an error.
+func Transcode(r io.Reader, maxWidth int) (Image, error) {
+	src, format, err := image.Decode(r)
+	if err != nil {
+		return Image{}, err
+	}
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return Image{}, errors.New("image is empty")
+	}
+
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxWidth > 0 && width > maxWidth {
+		height = max(1, height*maxWidth/width)
+		width = maxWidth
+	}
+
+	// Transparent areas are drawn onto white, since that's the colour of the page.
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(canvas, canvas.Bounds(), src, bounds, draw.Over, nil)
+
+	gray := image.NewGray(canvas.Bounds())
+	draw.Draw(gray, gray.Bounds(), canvas, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	res := Image{Width: width, Height: height}
+	switch format {
+	case "png", "gif":
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := enc.Encode(&buf, gray); err != nil {
+			return Image{}, err
+		}
+		res.ContentType = "image/png"
+	default:
+		if err := jpeg.Encode(&buf, gray, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Image{}, err
+		}
+		res.ContentType = "image/jpeg"
+	}
+	res.Data = buf.Bytes()
+	return res, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageproxy
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTranscode(t *testing.T) {
+	colourful := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := range 400 {
+		for y := range 200 {
+			colourful.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var jpegBuf bytes.Buffer
+	if err := jpeg.Encode(&jpegBuf, colourful, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name            string
+		data            []byte
+		maxWidth        int
+		wantContentType string
+		wantWidth       int
+		wantHeight      int
+	}{
+		{name: "Downscale JPEG", data: jpegBuf.Bytes(), maxWidth: 100, wantContentType: "image/jpeg", wantWidth: 100, wantHeight: 50},
+		{name: "PNG Stays PNG", data: encodePNG(t, colourful), maxWidth: 1000, wantContentType: "image/png", wantWidth: 400, wantHeight: 200},
+		{name: "No Limit", data: jpegBuf.Bytes(), maxWidth: 0, wantContentType: "image/jpeg", wantWidth: 400, wantHeight: 200},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := Transcode(bytes.NewReader(tc.data), tc.maxWidth)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if img.ContentType != tc.wantContentType || img.Width != tc.wantWidth || img.Height != tc.wantHeight {
+				t.Errorf("Unexpected image, want %s %dx%d got %s %dx%d", tc.wantContentType, tc.wantWidth, tc.wantHeight, img.ContentType, img.Width, img.Height)
+			}
+
+			decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+			if err != nil {
+				t.Fatalf("Unable to decode transcoded image: %v", err)
+			}
+			if decoded.Bounds().Dx() != tc.wantWidth || decoded.Bounds().Dy() != tc.wantHeight {
+				t.Errorf("Encoded size doesn't match, got %v", decoded.Bounds())
+			}
+			if _, isGray := decoded.ColorModel().Convert(color.RGBA{R: 255, A: 255}).(color.Gray); !isGray {
+				t.Errorf("Wanted a grayscale image, got %T", decoded.ColorModel())
+			}
+		})
+	}
+}
+
+func TestTranscode_TransparentOnWhite(t *testing.T) {
+	img, err := Transcode(bytes.NewReader(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 10, 10)))), 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.GrayModel.Convert(decoded.At(5, 5)).(color.Gray); got.Y != 255 {
+		t.Errorf("Wanted transparent pixels to be white, got %v", got)
+	}
+}
+
+func TestTranscode_Unsupported(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"></svg>`
+	if _, err := Transcode(strings.NewReader(svg), 100); err == nil {
+		t.Error("Wanted error for an SVG, got nil instead")
+	}
+}
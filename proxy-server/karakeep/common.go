@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"proxyserver/httpclient"
+	"strings"
+	"sync"
+)
+
+type KarakeepConn struct {
+	endpoint string
+	apiKey   string
+	client   *httpclient.Client
+
+	// A mapping of bookmark URLs to Karakeep IDs, since Pocket only gives us a URL
+	// when requesting article text. This works the same way as the Readeck backend,
+	// except that misses fall back to searching Karakeep.
+	urlIDCacheMu sync.RWMutex
+	urlIDCache   map[string]string
+}
+
+func NewKarakeepConn(endpoint string, apiKey string) *KarakeepConn {
+	return &KarakeepConn{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		apiKey:     apiKey,
+		client:     httpclient.New(httpclient.DefaultOptions()),
+		urlIDCache: make(map[string]string),
+	}
+}
+
+// SetHTTPClient replaces the client used to call the Karakeep API.
+func (conn *KarakeepConn) SetHTTPClient(client *httpclient.Client) {
+	conn.client = client
+}
+
+func (conn *KarakeepConn) createRequest(ctx context.Context, method, action string, body io.Reader) (*http.Request, error) {
+	apiUrl := fmt.Sprintf("%s/api/v1/%s", conn.endpoint, action)
+	keepReq, err := http.NewRequestWithContext(ctx, method, apiUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	keepReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conn.apiKey))
+	keepReq.Header.Set("Accept", "application/json")
+	return keepReq, nil
+}
+
+func (conn *KarakeepConn) do(keepReq *http.Request) (*http.Response, error) {
+	keepRes, err := conn.client.Do(keepReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponseCode(keepRes); err != nil {
+		keepRes.Body.Close()
+		return nil, err
+	}
+	return keepRes, nil
+}
+
+func (conn *KarakeepConn) cacheID(url, id string) {
+	conn.urlIDCacheMu.Lock()
+	defer conn.urlIDCacheMu.Unlock()
+	conn.urlIDCache[url] = id
+}
+
+func (conn *KarakeepConn) cachedID(url string) (string, bool) {
+	conn.urlIDCacheMu.RLock()
+	defer conn.urlIDCacheMu.RUnlock()
+	id, exists := conn.urlIDCache[url]
+	return id, exists
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func checkResponseCode(keepRes *http.Response) error {
+	if keepRes.StatusCode >= 200 && keepRes.StatusCode <= 299 {
+		return nil
+	}
+	var body errorBody
+	if err := json.NewDecoder(keepRes.Body).Decode(&body); err != nil || body.Message == "" {
+		return httpclient.ResponseError(keepRes, fmt.Errorf("error calling Karakeep API: [%d] %s", keepRes.StatusCode, keepRes.Status))
+	}
+	return httpclient.ResponseError(keepRes, fmt.Errorf("error calling Karakeep API: [%d] %s, More details: %s %s", keepRes.StatusCode, keepRes.Status, body.Code, body.Message))
+}
@@ -0,0 +1,389 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The largest page Karakeep will return in one call.
+const maxPageSize = 100
+
+// Used when the request doesn't specify a count.
+const defaultCount = 30
+
+// Karakeep can only sort by creation date, so a bookmark edited since the last
+// sync can be on any page, and an incremental sync has to walk the whole
+// library to find every change. To keep those syncs cheap, only this many pages
+// of the newest bookmarks are checked; edits to older bookmarks show up on the
+// next full sync.
+const maxSincePages = 10
+
+type tag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bookmarkContent struct {
+	Type          string     `json:"type"`
+	URL           string     `json:"url"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	ImageURL      string     `json:"imageUrl"`
+	Favicon       string     `json:"favicon"`
+	HTMLContent   string     `json:"htmlContent"`
+	Author        string     `json:"author"`
+	Publisher     string     `json:"publisher"`
+	DatePublished *time.Time `json:"datePublished"`
+}
+
+type bookmark struct {
+	ID         string          `json:"id"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	ModifiedAt *time.Time      `json:"modifiedAt"`
+	Title      string          `json:"title"`
+	Archived   bool            `json:"archived"`
+	Favourited bool            `json:"favourited"`
+	Summary    string          `json:"summary"`
+	Tags       []tag           `json:"tags"`
+	Content    bookmarkContent `json:"content"`
+}
+
+type bookmarksResponse struct {
+	Bookmarks  []bookmark `json:"bookmarks"`
+	NextCursor *string    `json:"nextCursor"`
+}
+
+// The bookmark title can be overridden by the user, otherwise it's the crawled title.
+func (b bookmark) title() string {
+	if b.Title != "" {
+		return b.Title
+	}
+	return b.Content.Title
+}
+
+func (b bookmark) updated() time.Time {
+	if b.ModifiedAt != nil {
+		return *b.ModifiedAt
+	}
+	return b.CreatedAt
+}
+
+func (b bookmark) excerpt() string {
+	if b.Content.Description != "" {
+		return b.Content.Description
+	}
+	return b.Summary
+}
+
+// buildGetQuerystring translates the filters that Karakeep supports natively.
+// Pagination is handled by Get, and the other filters by includeBookmark.
+func buildGetQuerystring(req pocketapi.GetRequest) url.Values {
+	query := url.Values{}
+
+	switch strings.ToLower(req.State) {
+	case "unread":
+		query.Set("archived", "false")
+	case "archive":
+		query.Set("archived", "true")
+	case "all":
+		fallthrough
+	default:
+		// Leave it unset.
+	}
+
+	switch strings.ToLower(req.Favorite) {
+	case "0":
+		query.Set("favourited", "false")
+	case "1":
+		query.Set("favourited", "true")
+	default:
+		// Leave it unset.
+	}
+
+	switch strings.ToLower(req.Sort) {
+	case "oldest":
+		query.Set("sortOrder", "asc")
+	case "newest":
+		fallthrough
+	default:
+		// Karakeep can't sort by title or site, so fall back to the newest first.
+		query.Set("sortOrder", "desc")
+	}
+
+	return query
+}
+
+// includeBookmark returns whether the bookmark matches the filters which Karakeep can't apply itself.
+func includeBookmark(req pocketapi.GetRequest, b bookmark) bool {
+	if req.Since != nil && b.updated().Before(time.Unix(*req.Since, 0)) {
+		return false
+	}
+
+	// Karakeep's search could apply these, but it orders results by relevance
+	// rather than date, so they're filtered here instead.
+	switch req.Tag {
+	case "":
+		// No filter.
+	case pocketapi.UntaggedTag:
+		if len(b.Tags) > 0 {
+			return false
+		}
+	default:
+		if !slices.ContainsFunc(b.Tags, func(t tag) bool { return t.Name == req.Tag }) {
+			return false
+		}
+	}
+
+	if req.Search != "" {
+		search := strings.ToLower(req.Search)
+		if !strings.Contains(strings.ToLower(b.title()), search) && !strings.Contains(strings.ToLower(b.Content.URL), search) {
+			return false
+		}
+	}
+
+	if req.Domain != "" {
+		// Subdomains match too, e.g. "example.com" matches "www.example.com".
+		h, domain := strings.ToLower(host(b.Content.URL)), strings.ToLower(req.Domain)
+		if h != domain && !strings.HasSuffix(h, "."+domain) {
+			return false
+		}
+	}
+
+	// Karakeep doesn't distinguish between articles, videos and images, but
+	// notes and uploaded assets aren't something the Kobo can display.
+	return b.Content.Type == "link"
+}
+
+func host(rawUrl string) string {
+	if u, err := url.Parse(rawUrl); err == nil {
+		return u.Hostname()
+	}
+	return rawUrl
+}
+
+func (b bookmark) toPocketItem() pocketapi.GetResponseItem {
+
+	timeFavorited := "0"
+	if b.Favourited {
+		timeFavorited = strconv.FormatInt(b.updated().Unix(), 10)
+	}
+
+	var domainMeta *pocketapi.DomainMetadata
+	if b.Content.Publisher != "" {
+		domainMeta = &pocketapi.DomainMetadata{Name: b.Content.Publisher, Logo: b.Content.Favicon}
+	}
+
+	var authors map[string]pocketapi.Author
+	if b.Content.Author != "" {
+		id := pocketapi.Digest(b.Content.Author)
+		authors = map[string]pocketapi.Author{
+			id: {AuthorID: id, Name: b.Content.Author, ItemID: b.ID},
+		}
+	}
+
+	hasImage := "0"
+	var image *pocketapi.Image
+	if b.Content.ImageURL != "" {
+		hasImage = "1"
+		image = &pocketapi.Image{
+			ItemID:  b.ID,
+			ImageID: pocketapi.Digest(b.Content.ImageURL),
+			Src:     b.Content.ImageURL,
+		}
+	}
+
+	var tags []string
+	for _, t := range b.Tags {
+		tags = append(tags, t.Name)
+	}
+
+	status := "0"
+	if b.Archived {
+		status = "1"
+	}
+
+	return pocketapi.GetResponseItem{
+		ItemID:         b.ID,
+		Favorite:       pocketapi.OneIfTrue(b.Favourited),
+		Status:         status,
+		TimeAdded:      strconv.FormatInt(b.CreatedAt.Unix(), 10),
+		TimeUpdated:    strconv.FormatInt(b.updated().Unix(), 10),
+		TimeFavorited:  timeFavorited,
+		Tags:           pocketapi.NewTags(b.ID, tags),
+		ResolvedID:     b.ID,
+		GivenURL:       b.Content.URL,
+		GivenTitle:     b.title(),
+		ResolvedTitle:  b.title(),
+		ResolvedURL:    b.Content.URL,
+		Excerpt:        b.excerpt(),
+		IsArticle:      "1",
+		IsIndex:        "0",
+		HasVideo:       "0",
+		HasImage:       hasImage,
+		WordCount:      "0",
+		DomainMetadata: domainMeta,
+		Authors:        authors,
+		Image:          image,
+		TopImageURL:    b.Content.ImageURL,
+	}
+}
+
+func (conn *KarakeepConn) listBookmarks(ctx context.Context, query url.Values) (bookmarksResponse, error) {
+	keepReq, err := conn.createRequest(ctx, http.MethodGet, "bookmarks", nil)
+	if err != nil {
+		return bookmarksResponse{}, err
+	}
+	keepReq.URL.RawQuery = query.Encode()
+
+	keepRes, err := conn.do(keepReq)
+	if err != nil {
+		return bookmarksResponse{}, err
+	}
+	defer keepRes.Body.Close()
+
+	var res bookmarksResponse
+	if err := json.NewDecoder(keepRes.Body).Decode(&res); err != nil {
+		return bookmarksResponse{}, err
+	}
+	return res, nil
+}
+
+func (conn *KarakeepConn) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	offset := 0
+	if req.Offset != nil {
+		offset = max(0, *req.Offset)
+	}
+	count := defaultCount
+	if req.Count != nil && *req.Count > 0 {
+		count = *req.Count
+	}
+
+	// Karakeep paginates with cursors rather than offsets, so walk the pages
+	// until we've skipped past the offset and collected enough bookmarks.
+	query := buildGetQuerystring(req)
+	query.Set("includeContent", "false")
+	query.Set("limit", strconv.Itoa(maxPageSize))
+
+	// The page cap only works when walking from the newest bookmarks, otherwise
+	// it'd skip the recently added ones.
+	capped := req.Since != nil && query.Get("sortOrder") == "desc"
+
+	var matching []bookmark
+	hasMore := false
+	for pages := 1; ; pages++ {
+		res, err := conn.listBookmarks(ctx, query)
+		if err != nil {
+			return pocketapi.GetResponse{}, err
+		}
+		for _, b := range res.Bookmarks {
+			// Cache every URL seen, even if it's filtered out.
+			conn.cacheID(b.Content.URL, b.ID)
+			if includeBookmark(req, b) {
+				matching = append(matching, b)
+			}
+		}
+		hasMore = res.NextCursor != nil && *res.NextCursor != ""
+		if capped && pages >= maxSincePages {
+			hasMore = false
+		}
+		if !hasMore || len(matching) > offset+count {
+			break
+		}
+		query.Set("cursor", *res.NextCursor)
+	}
+
+	var pocketRes pocketapi.GetResponse
+	pocketRes.Status = 1
+	pocketRes.List = map[string]pocketapi.GetResponseItem{}
+
+	page := matching[min(offset, len(matching)):min(offset+count, len(matching))]
+	for _, b := range page {
+		pocketRes.List[b.ID] = b.toPocketItem()
+	}
+
+	// Karakeep doesn't report a total, so this is only exact once we reach the
+	// last page. Otherwise, signal that there's at least one more item.
+	pocketRes.Total = len(matching)
+	if hasMore {
+		pocketRes.Total++
+	}
+	return pocketRes, nil
+}
+
+func (conn *KarakeepConn) getBookmark(ctx context.Context, itemID string, includeContent bool) (bookmark, error) {
+	keepReq, err := conn.createRequest(ctx, http.MethodGet, fmt.Sprintf("bookmarks/%s", itemID), nil)
+	if err != nil {
+		return bookmark{}, err
+	}
+	keepReq.URL.RawQuery = url.Values{"includeContent": {strconv.FormatBool(includeContent)}}.Encode()
+
+	keepRes, err := conn.do(keepReq)
+	if err != nil {
+		return bookmark{}, err
+	}
+	defer keepRes.Body.Close()
+
+	var b bookmark
+	if err := json.NewDecoder(keepRes.Body).Decode(&b); err != nil {
+		return bookmark{}, err
+	}
+	return b, nil
+}
+
+// findBookmarkID returns the ID of the bookmark with the given URL, first checking
+// the cache and then falling back to Karakeep's search.
+func (conn *KarakeepConn) findBookmarkID(ctx context.Context, bookmarkUrl string) (string, error) {
+	if id, cached := conn.cachedID(bookmarkUrl); cached {
+		return id, nil
+	}
+
+	keepReq, err := conn.createRequest(ctx, http.MethodGet, "bookmarks/search", nil)
+	if err != nil {
+		return "", err
+	}
+	keepReq.URL.RawQuery = url.Values{
+		"q":              {fmt.Sprintf("url:%s", bookmarkUrl)},
+		"includeContent": {"false"},
+	}.Encode()
+
+	keepRes, err := conn.do(keepReq)
+	if err != nil {
+		return "", err
+	}
+	defer keepRes.Body.Close()
+
+	var res bookmarksResponse
+	if err := json.NewDecoder(keepRes.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	// The search matches substrings, so look for the exact URL.
+	for _, b := range res.Bookmarks {
+		conn.cacheID(b.Content.URL, b.ID)
+		if b.Content.URL == bookmarkUrl {
+			return b.ID, nil
+		}
+	}
+	return "", fmt.Errorf("unable to find a bookmark for URL %s in Karakeep", bookmarkUrl)
+}
@@ -0,0 +1,369 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"proxyserver/pocketapi"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var cmpSortStrings = cmpopts.SortSlices(func(a, b string) bool { return a < b })
+
+func numPointer[T int | int64](value T) *T {
+	return &value
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantToken := "Bearer key123"
+		if r.Header.Get("Authorization") != wantToken {
+			t.Errorf("Unexpected authorization header: want %s got %s", wantToken, r.Header.Get("Authorization"))
+		}
+		handler(w, r)
+	}))
+}
+
+func TestKarakeep_GetRequest(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request pocketapi.GetRequest
+		want    url.Values
+	}{
+		{
+			name:    "Defaults",
+			request: pocketapi.GetRequest{},
+			want: url.Values{
+				"sortOrder":      {"desc"},
+				"includeContent": {"false"},
+				"limit":          {"100"},
+			},
+		},
+		{
+			name: "Archived Favorites Oldest",
+			request: pocketapi.GetRequest{
+				State:    "archive",
+				Favorite: "1",
+				Sort:     "oldest",
+			},
+			want: url.Values{
+				"sortOrder":      {"asc"},
+				"includeContent": {"false"},
+				"limit":          {"100"},
+				"archived":       {"true"},
+				"favourited":     {"true"},
+			},
+		},
+		{
+			name: "Unread Not Favorite",
+			request: pocketapi.GetRequest{
+				State:    "unread",
+				Favorite: "0",
+			},
+			want: url.Values{
+				"sortOrder":      {"desc"},
+				"includeContent": {"false"},
+				"limit":          {"100"},
+				"archived":       {"false"},
+				"favourited":     {"false"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("Unexpected HTTP method, want GET got %s", r.Method)
+				}
+				if r.URL.Path != "/api/v1/bookmarks" {
+					t.Errorf("Unexpected path, want /api/v1/bookmarks got %s", r.URL.Path)
+				}
+				if diff := cmp.Diff(tc.want, r.URL.Query()); diff != "" {
+					t.Errorf("GET query mismatch (-want +got):\n%s", diff)
+				}
+				w.Write([]byte(`{"bookmarks": [], "nextCursor": null}`))
+			})
+			defer server.Close()
+
+			if _, err := NewKarakeepConn(server.URL, "key123").Get(context.Background(), tc.request); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestKarakeep_GetResponse(t *testing.T) {
+	const jsonResponse = `{
+		"bookmarks": [{
+			"id": "bm1",
+			"createdAt": "2025-06-30T15:08:09Z",
+			"modifiedAt": "2025-06-30T15:08:12Z",
+			"title": null,
+			"archived": false,
+			"favourited": true,
+			"summary": "A summary",
+			"tags": [],
+			"content": {
+				"type": "link",
+				"url": "https://some-news-website.org/something-great-happened/",
+				"title": "Something Great & Awesome Happened",
+				"description": "Lorem ipsum",
+				"imageUrl": "https://some-news-website.org/image.jpeg",
+				"author": "John Doe",
+				"publisher": "Awesome Website"
+			}
+		}, {
+			"id": "bm2",
+			"createdAt": "2025-06-30T15:08:09Z",
+			"modifiedAt": null,
+			"title": "My Own Title",
+			"archived": true,
+			"favourited": false,
+			"tags": [],
+			"content": {
+				"type": "link",
+				"url": "https://some-news-website.org/something-else-happened/",
+				"title": "Something Else Happened"
+			}
+		}, {
+			"id": "note1",
+			"createdAt": "2025-06-30T15:08:09Z",
+			"archived": false,
+			"favourited": false,
+			"tags": [],
+			"content": {"type": "text", "text": "Just a note"}
+		}],
+		"nextCursor": null
+	}`
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(jsonResponse))
+	})
+	defer server.Close()
+
+	conn := NewKarakeepConn(server.URL, "key123")
+	res, err := conn.Get(context.Background(), pocketapi.GetRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := pocketapi.GetResponse{
+		Status: 1,
+		Total:  2,
+		List: map[string]pocketapi.GetResponseItem{
+			"bm1": {
+				ItemID:         "bm1",
+				Favorite:       "1",
+				Status:         "0",
+				TimeAdded:      "1751296089",
+				TimeUpdated:    "1751296092",
+				TimeFavorited:  "1751296092",
+				TopImageURL:    "https://some-news-website.org/image.jpeg",
+				ResolvedID:     "bm1",
+				GivenURL:       "https://some-news-website.org/something-great-happened/",
+				GivenTitle:     "Something Great & Awesome Happened",
+				ResolvedTitle:  "Something Great & Awesome Happened",
+				ResolvedURL:    "https://some-news-website.org/something-great-happened/",
+				Excerpt:        "Lorem ipsum",
+				IsArticle:      "1",
+				IsIndex:        "0",
+				HasVideo:       "0",
+				HasImage:       "1",
+				WordCount:      "0",
+				DomainMetadata: &pocketapi.DomainMetadata{Name: "Awesome Website"},
+				Authors: map[string]pocketapi.Author{
+					pocketapi.Digest("John Doe"): {AuthorID: pocketapi.Digest("John Doe"), Name: "John Doe", ItemID: "bm1"},
+				},
+				Image: &pocketapi.Image{
+					ItemID:  "bm1",
+					ImageID: pocketapi.Digest("https://some-news-website.org/image.jpeg"),
+					Src:     "https://some-news-website.org/image.jpeg",
+				},
+			},
+			"bm2": {
+				ItemID:        "bm2",
+				Favorite:      "0",
+				Status:        "1",
+				TimeAdded:     "1751296089",
+				TimeUpdated:   "1751296089",
+				TimeFavorited: "0",
+				ResolvedID:    "bm2",
+				GivenURL:      "https://some-news-website.org/something-else-happened/",
+				GivenTitle:    "My Own Title",
+				ResolvedTitle: "My Own Title",
+				ResolvedURL:   "https://some-news-website.org/something-else-happened/",
+				IsArticle:     "1",
+				IsIndex:       "0",
+				HasVideo:      "0",
+				HasImage:      "0",
+				WordCount:     "0",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, res); diff != "" {
+		t.Errorf("GET response mismatch (-want +got):\n%s", diff)
+	}
+
+	if id, cached := conn.cachedID("https://some-news-website.org/something-else-happened/"); !cached || id != "bm2" {
+		t.Errorf("Unexpected cached ID, want bm2 got %s", id)
+	}
+}
+
+func TestKarakeep_GetPagination(t *testing.T) {
+	// Serve 5 pages of 2 bookmarks each, b0 to b9.
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		page := 0
+		fmt.Sscanf(r.URL.Query().Get("cursor"), "page%d", &page)
+		nextCursor := "null"
+		if page < 4 {
+			nextCursor = fmt.Sprintf(`"page%d"`, page+1)
+		}
+		fmt.Fprintf(w, `{"bookmarks": [
+			{"id": "b%d", "createdAt": "2025-06-30T15:08:09Z", "content": {"type": "link", "url": "https://test.com/%d"}},
+			{"id": "b%d", "createdAt": "2025-06-30T15:08:09Z", "content": {"type": "link", "url": "https://test.com/%d"}}
+		], "nextCursor": %s}`, page*2, page*2, page*2+1, page*2+1, nextCursor)
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		name      string
+		count     int
+		offset    int
+		wantIDs   []string
+		wantTotal int
+	}{
+		{name: "First Page", count: 3, offset: 0, wantIDs: []string{"b0", "b1", "b2"}, wantTotal: 5},
+		{name: "Middle Page", count: 3, offset: 3, wantIDs: []string{"b3", "b4", "b5"}, wantTotal: 9},
+		{name: "Last Page", count: 3, offset: 9, wantIDs: []string{"b9"}, wantTotal: 10},
+		{name: "Past The End", count: 3, offset: 12, wantIDs: nil, wantTotal: 10},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewKarakeepConn(server.URL, "key123").Get(context.Background(), pocketapi.GetRequest{
+				Count:  numPointer(tc.count),
+				Offset: numPointer(tc.offset),
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var gotIDs []string
+			for id := range res.List {
+				gotIDs = append(gotIDs, id)
+			}
+			if diff := cmp.Diff(tc.wantIDs, gotIDs, cmpSortStrings); diff != "" {
+				t.Errorf("Item IDs mismatch (-want +got):\n%s", diff)
+			}
+			if res.Total != tc.wantTotal {
+				t.Errorf("Unexpected total, want %d got %d", tc.wantTotal, res.Total)
+			}
+		})
+	}
+}
+
+func TestKarakeep_GetSince(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bookmarks": [
+			{"id": "old", "createdAt": "2025-06-30T15:08:09Z", "modifiedAt": "2025-06-30T15:08:09Z", "content": {"type": "link", "url": "https://test.com/old"}},
+			{"id": "new", "createdAt": "2025-06-30T15:08:09Z", "modifiedAt": "2025-07-30T15:08:09Z", "content": {"type": "link", "url": "https://test.com/new"}}
+		], "nextCursor": null}`))
+	})
+	defer server.Close()
+
+	res, err := NewKarakeepConn(server.URL, "key123").Get(context.Background(), pocketapi.GetRequest{Since: numPointer(int64(1752000000))})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, exists := res.List["new"]; !exists || len(res.List) != 1 {
+		t.Errorf("Unexpected items, want only \"new\" got %v", res.List)
+	}
+}
+
+func TestKarakeep_GetSinceStopsPaging(t *testing.T) {
+	pages := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		w.Write([]byte(fmt.Sprintf(`{"bookmarks": [
+			{"id": "bm%d", "createdAt": "2025-06-30T15:08:09Z", "modifiedAt": "2025-07-30T15:08:09Z", "content": {"type": "link", "url": "https://test.com/%d"}}
+		], "nextCursor": "cursor%d"}`, pages, pages, pages)))
+	})
+	defer server.Close()
+
+	conn := NewKarakeepConn(server.URL, "key123")
+	res, err := conn.Get(context.Background(), pocketapi.GetRequest{Since: numPointer(int64(1752000000)), Count: numPointer(1000)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pages != maxSincePages {
+		t.Errorf("Unexpected number of pages fetched, want %d got %d", maxSincePages, pages)
+	}
+	if len(res.List) != maxSincePages || res.Total != maxSincePages {
+		t.Errorf("Unexpected items, want %d got %d with total %d", maxSincePages, len(res.List), res.Total)
+	}
+
+	// Oldest first walks from the other end, so it can't stop early.
+	pages = 0
+	_, err = conn.Get(context.Background(), pocketapi.GetRequest{Since: numPointer(int64(1752000000)), Count: numPointer(maxSincePages + 5), Sort: "oldest"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pages != maxSincePages+6 {
+		t.Errorf("Unexpected number of pages fetched, want %d got %d", maxSincePages+6, pages)
+	}
+}
+
+func TestKarakeep_GetFilters(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bookmarks": [
+			{"id": "tagged", "createdAt": "2025-06-30T15:08:09Z", "title": "Something Great", "tags": [{"id": "t1", "name": "news"}], "content": {"type": "link", "url": "https://www.test.com/great"}},
+			{"id": "untagged", "createdAt": "2025-06-30T15:08:09Z", "content": {"type": "link", "url": "https://other.org/page", "title": "Another Thing"}}
+		], "nextCursor": null}`))
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		request pocketapi.GetRequest
+		want    []string
+	}{
+		{name: "Tag", request: pocketapi.GetRequest{Tag: "news"}, want: []string{"tagged"}},
+		{name: "Untagged", request: pocketapi.GetRequest{Tag: pocketapi.UntaggedTag}, want: []string{"untagged"}},
+		{name: "Missing tag", request: pocketapi.GetRequest{Tag: "sports"}, want: nil},
+		{name: "Search title", request: pocketapi.GetRequest{Search: "great"}, want: []string{"tagged"}},
+		{name: "Search URL", request: pocketapi.GetRequest{Search: "other.org"}, want: []string{"untagged"}},
+		{name: "Domain", request: pocketapi.GetRequest{Domain: "test.com"}, want: []string{"tagged"}},
+		{name: "No filters", request: pocketapi.GetRequest{}, want: []string{"tagged", "untagged"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewKarakeepConn(server.URL, "key123").Get(context.Background(), tc.request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := slices.Sorted(maps.Keys(res.List))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Listed items mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"proxyserver/httpclient"
+	"time"
+)
+
+var pointerTrue bool = true
+var pointerFalse bool = false
+
+type updateRequest struct {
+	Archived   *bool `json:"archived,omitempty"`
+	Favourited *bool `json:"favourited,omitempty"`
+}
+
+func sendUpdate(ctx context.Context, conn *KarakeepConn, itemID string, params updateRequest) error {
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(params); err != nil {
+		return err
+	}
+
+	keepReq, err := conn.createRequest(ctx, http.MethodPatch, fmt.Sprintf("bookmarks/%s", itemID), &buffer)
+	if err != nil {
+		return err
+	}
+	keepReq.Header.Set("Content-Type", "application/json")
+
+	// Updates set fields to fixed values, so they're safe to retry.
+	keepRes, err := conn.do(httpclient.Idempotent(keepReq))
+	if err != nil {
+		return err
+	}
+	return keepRes.Body.Close()
+}
+
+type insertRequest struct {
+	Type  string `json:"type"`
+	Url   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+func (conn *KarakeepConn) Add(ctx context.Context, url string, title string, time time.Time) (string, error) {
+	body := insertRequest{Type: "link", Url: url, Title: title}
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(body); err != nil {
+		return "", err
+	}
+
+	keepReq, err := conn.createRequest(ctx, http.MethodPost, "bookmarks", &buffer)
+	if err != nil {
+		return "", err
+	}
+	keepReq.Header.Set("Content-Type", "application/json")
+
+	keepRes, err := conn.do(keepReq)
+	if err != nil {
+		return "", err
+	}
+	defer keepRes.Body.Close()
+
+	// Cache the returned ID.
+	var created bookmark
+	if err := json.NewDecoder(keepRes.Body).Decode(&created); err == nil && created.ID != "" {
+		conn.cacheID(url, created.ID)
+	}
+	return created.ID, nil
+}
+
+func (conn *KarakeepConn) Archive(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Archived: &pointerTrue})
+}
+
+func (conn *KarakeepConn) Unarchive(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Archived: &pointerFalse})
+}
+
+func (conn *KarakeepConn) Delete(ctx context.Context, itemID string, time time.Time) error {
+	keepReq, err := conn.createRequest(ctx, http.MethodDelete, fmt.Sprintf("bookmarks/%s", itemID), nil)
+	if err != nil {
+		return err
+	}
+
+	keepRes, err := conn.do(keepReq)
+	if err != nil {
+		return err
+	}
+	return keepRes.Body.Close()
+}
+
+func (conn *KarakeepConn) Favorite(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Favourited: &pointerTrue})
+}
+
+func (conn *KarakeepConn) Unfavorite(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Favourited: &pointerFalse})
+}
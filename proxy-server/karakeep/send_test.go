@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func boolPointer(value bool) *bool {
+	return &value
+}
+
+func TestKarakeep_SendUpdate(t *testing.T) {
+	const itemID = "bm1"
+
+	testCases := []struct {
+		name       string
+		update     func(*KarakeepConn) error
+		statusCode int
+		wantError  bool
+		wantMethod string
+		wantBody   *updateRequest
+	}{
+		{
+			name:       "Archive",
+			statusCode: http.StatusOK,
+			update: func(conn *KarakeepConn) error {
+				return conn.Archive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Archived: boolPointer(true)},
+		},
+		{
+			name:       "Unarchive",
+			statusCode: http.StatusOK,
+			update: func(conn *KarakeepConn) error {
+				return conn.Unarchive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Archived: boolPointer(false)},
+		},
+		{
+			name:       "Favorite",
+			statusCode: http.StatusOK,
+			update: func(conn *KarakeepConn) error {
+				return conn.Favorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Favourited: boolPointer(true)},
+		},
+		{
+			name:       "Unfavorite",
+			statusCode: http.StatusOK,
+			update: func(conn *KarakeepConn) error {
+				return conn.Unfavorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Favourited: boolPointer(false)},
+		},
+		{
+			name:       "Delete",
+			statusCode: http.StatusNoContent,
+			update: func(conn *KarakeepConn) error {
+				return conn.Delete(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodDelete,
+		},
+		{
+			name:       "Error",
+			statusCode: http.StatusNotFound,
+			update: func(conn *KarakeepConn) error {
+				return conn.Delete(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodDelete,
+			wantError:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tc.wantMethod {
+					t.Errorf("Unexpected HTTP method, want %s got %s", tc.wantMethod, r.Method)
+				}
+				if r.URL.Path != "/api/v1/bookmarks/bm1" {
+					t.Errorf("Unexpected path, want /api/v1/bookmarks/bm1 got %s", r.URL.Path)
+				}
+				if tc.wantBody != nil {
+					var got updateRequest
+					if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+						t.Errorf("Unable to decode request body: %v", err)
+					}
+					if diff := cmp.Diff(*tc.wantBody, got); diff != "" {
+						t.Errorf("Request body mismatch (-want +got):\n%s", diff)
+					}
+				}
+				w.WriteHeader(tc.statusCode)
+			})
+			defer server.Close()
+
+			err := tc.update(NewKarakeepConn(server.URL, "key123"))
+			if tc.wantError && err == nil {
+				t.Error("Wanted error, got nil instead")
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("Wanted nil error, got %v instead", err)
+			}
+		})
+	}
+}
+
+func TestKarakeep_Add(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/bookmarks" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var got insertRequest
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Unable to decode request body: %v", err)
+		}
+		want := insertRequest{Type: "link", Url: "https://test.com/article"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Request body mismatch (-want +got):\n%s", diff)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "new1", "content": {"type": "link", "url": "https://test.com/article"}}`))
+	})
+	defer server.Close()
+
+	conn := NewKarakeepConn(server.URL, "key123")
+	if _, err := conn.Add(context.Background(), "https://test.com/article", "", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id, cached := conn.cachedID("https://test.com/article"); !cached || id != "new1" {
+		t.Errorf("Unexpected cached ID, want new1 got %s", id)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"proxyserver/pocketapi"
+	"strings"
+	"time"
+)
+
+func copyFromBookmark(b bookmark, article *pocketapi.ArticleTextResponse) {
+	pocketItem := b.toPocketItem()
+
+	article.ItemID = b.ID
+	article.ResolvedID = b.ID
+	article.GivenURL = b.Content.URL
+	article.NormalURL = b.Content.URL
+	article.ResolvedNormalURL = b.Content.URL
+	article.ResolvedURL = b.Content.URL
+	article.DateResolved = b.CreatedAt.Format(time.RFC3339)
+
+	article.HasVideo = "0"
+	if u, err := url.Parse(b.Content.URL); err == nil {
+		article.Host = u.Hostname()
+	}
+	article.Title = b.title()
+	if b.Content.DatePublished != nil {
+		article.DatePublished = b.Content.DatePublished.Format(time.RFC3339)
+	}
+	article.ResponseCode = "200"
+	article.Excerpt = b.excerpt()
+	article.TopImageURL = b.Content.ImageURL
+	article.DomainMetadata = pocketItem.DomainMetadata
+
+	article.HasImage = pocketItem.HasImage
+	article.Authors = pocketItem.Authors
+	one := 1
+	article.IsArticle = &one
+	zero := 0
+	article.IsIndex = &zero
+	article.IsVideo = &zero
+}
+
+func (conn *KarakeepConn) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	id, err := conn.findBookmarkID(ctx, url)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+
+	b, err := conn.getBookmark(ctx, id, true)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+	if b.Content.HTMLContent == "" {
+		return pocketapi.ArticleTextResponse{}, errors.New("Karakeep hasn't cached any content for this bookmark yet")
+	}
+
+	article := pocketapi.ArticleTextResponse{}
+	copyFromBookmark(b, &article)
+
+	article.Encoding = "utf-8"
+	if err := pocketapi.ParseArticleText(strings.NewReader(b.Content.HTMLContent), &article); err != nil {
+		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error parsing article HTML: %v", err)
+	}
+
+	return article, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package karakeep
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestKarakeep_ArticleText(t *testing.T) {
+	const articleUrl = "https://some-news-website.org/article"
+
+	testCases := []struct {
+		name        string
+		htmlContent string
+		wantArticle string
+		wantError   bool
+	}{
+		{
+			name:        "Cached Content",
+			htmlContent: `<p>Hello</p><img src=\"https://some-news-website.org/img.png\">`,
+			wantArticle: "<div><p>Hello</p><!--IMG_1--></div>",
+		},
+		{
+			name:      "Not Crawled Yet",
+			wantError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/bookmarks/search":
+					if got, want := r.URL.Query().Get("q"), "url:"+articleUrl; got != want {
+						t.Errorf("Unexpected search query, want %s got %s", want, got)
+					}
+					// The search matches substrings, so this should skip the first result.
+					w.Write([]byte(`{"bookmarks": [
+						{"id": "other", "content": {"type": "link", "url": "https://some-news-website.org/article/2"}},
+						{"id": "bm1", "content": {"type": "link", "url": "https://some-news-website.org/article"}}
+					]}`))
+				case "/api/v1/bookmarks/bm1":
+					if r.URL.Query().Get("includeContent") != "true" {
+						t.Error("Expected content to be requested")
+					}
+					w.Write([]byte(`{
+						"id": "bm1",
+						"createdAt": "2025-06-30T15:08:09Z",
+						"content": {
+							"type": "link",
+							"url": "https://some-news-website.org/article",
+							"title": "An Article",
+							"htmlContent": "` + tc.htmlContent + `"
+						}
+					}`))
+				default:
+					t.Errorf("Unexpected request to %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+			defer server.Close()
+
+			article, err := NewKarakeepConn(server.URL, "key123").ArticleText(context.Background(), articleUrl)
+			if tc.wantError {
+				if err == nil {
+					t.Error("Wanted error, got nil instead")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if article.ItemID != "bm1" || article.Title != "An Article" || article.Host != "some-news-website.org" {
+				t.Errorf("Unexpected article metadata: %+v", article)
+			}
+			if article.Article != tc.wantArticle {
+				t.Errorf("Unexpected article HTML, want %s got %s", tc.wantArticle, article.Article)
+			}
+			if len(article.Images) != 1 {
+				t.Errorf("Unexpected number of images, want 1 got %d", len(article.Images))
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"proxyserver/httpclient"
+	"strings"
+)
+
+// Linkding doesn't have favourites, so they're stored as a tag instead.
+const favoriteTag = "favorite"
+
+type LinkdingConn struct {
+	endpoint string
+	apiToken string
+	client   *httpclient.Client
+	// The client used to download articles, which Linkding doesn't store.
+	pageClient *httpclient.Client
+}
+
+func NewLinkdingConn(endpoint string, apiToken string) *LinkdingConn {
+	return &LinkdingConn{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		apiToken:   apiToken,
+		client:     httpclient.New(httpclient.DefaultOptions()),
+		pageClient: httpclient.New(httpclient.DefaultOptions()),
+	}
+}
+
+// SetHTTPClient replaces the client used to call the Linkding API.
+func (conn *LinkdingConn) SetHTTPClient(client *httpclient.Client) {
+	conn.client = client
+}
+
+// SetPageClient replaces the client used to download articles.
+func (conn *LinkdingConn) SetPageClient(client *httpclient.Client) {
+	conn.pageClient = client
+}
+
+func (conn *LinkdingConn) createRequest(ctx context.Context, method, action string, body io.Reader) (*http.Request, error) {
+	apiUrl := fmt.Sprintf("%s/api/%s", conn.endpoint, action)
+	dingReq, err := http.NewRequestWithContext(ctx, method, apiUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	dingReq.Header.Set("Authorization", fmt.Sprintf("Token %s", conn.apiToken))
+	dingReq.Header.Set("Accept", "application/json")
+	return dingReq, nil
+}
+
+func (conn *LinkdingConn) do(dingReq *http.Request) (*http.Response, error) {
+	dingRes, err := conn.client.Do(dingReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponseCode(dingRes); err != nil {
+		dingRes.Body.Close()
+		return nil, err
+	}
+	return dingRes, nil
+}
+
+type errorBody struct {
+	Detail string `json:"detail"`
+}
+
+func checkResponseCode(dingRes *http.Response) error {
+	if dingRes.StatusCode >= 200 && dingRes.StatusCode <= 299 {
+		return nil
+	}
+	var body errorBody
+	if err := json.NewDecoder(dingRes.Body).Decode(&body); err != nil || body.Detail == "" {
+		return httpclient.ResponseError(dingRes, fmt.Errorf("error calling Linkding API: [%d] %s", dingRes.StatusCode, dingRes.Status))
+	}
+	return httpclient.ResponseError(dingRes, fmt.Errorf("error calling Linkding API: [%d] %s, More details: %s", dingRes.StatusCode, dingRes.Status, body.Detail))
+}
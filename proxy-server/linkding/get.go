@@ -0,0 +1,305 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Used when the request doesn't specify a count.
+const defaultCount = 30
+
+// How many bookmarks to fetch per request when walking every page.
+const tagPageSize = 100
+
+type bookmark struct {
+	ID                 int       `json:"id"`
+	URL                string    `json:"url"`
+	Title              string    `json:"title"`
+	Description        string    `json:"description"`
+	WebsiteTitle       string    `json:"website_title"`
+	WebsiteDescription string    `json:"website_description"`
+	FaviconURL         string    `json:"favicon_url"`
+	PreviewImageURL    string    `json:"preview_image_url"`
+	IsArchived         bool      `json:"is_archived"`
+	Unread             bool      `json:"unread"`
+	TagNames           []string  `json:"tag_names"`
+	DateAdded          time.Time `json:"date_added"`
+	DateModified       time.Time `json:"date_modified"`
+}
+
+type bookmarksResponse struct {
+	Count   int        `json:"count"`
+	Next    *string    `json:"next"`
+	Results []bookmark `json:"results"`
+}
+
+func (b bookmark) itemID() string {
+	return strconv.Itoa(b.ID)
+}
+
+// The title and description can be overridden by the user, otherwise they're scraped from the site.
+func (b bookmark) title() string {
+	if b.Title != "" {
+		return b.Title
+	}
+	return b.WebsiteTitle
+}
+
+func (b bookmark) excerpt() string {
+	if b.Description != "" {
+		return b.Description
+	}
+	return b.WebsiteDescription
+}
+
+func (b bookmark) isFavorite() bool {
+	return slices.Contains(b.TagNames, favoriteTag)
+}
+
+// pocketTags returns the bookmark's tags, minus the one used for favourites.
+func (b bookmark) pocketTags() []string {
+	return slices.DeleteFunc(slices.Clone(b.TagNames), func(t string) bool { return t == favoriteTag })
+}
+
+func (b bookmark) toPocketItem() pocketapi.GetResponseItem {
+	id := b.itemID()
+
+	// Linkding doesn't record when a tag was added.
+	timeFavorited := "0"
+	if b.isFavorite() {
+		timeFavorited = strconv.FormatInt(b.DateModified.Unix(), 10)
+	}
+
+	hasImage := "0"
+	var image *pocketapi.Image
+	if b.PreviewImageURL != "" {
+		hasImage = "1"
+		image = &pocketapi.Image{
+			ItemID:  id,
+			ImageID: pocketapi.Digest(b.PreviewImageURL),
+			Src:     b.PreviewImageURL,
+		}
+	}
+
+	status := "0"
+	if b.IsArchived {
+		status = "1"
+	}
+
+	return pocketapi.GetResponseItem{
+		ItemID:        id,
+		Favorite:      pocketapi.OneIfTrue(b.isFavorite()),
+		Status:        status,
+		TimeAdded:     strconv.FormatInt(b.DateAdded.Unix(), 10),
+		TimeUpdated:   strconv.FormatInt(b.DateModified.Unix(), 10),
+		TimeFavorited: timeFavorited,
+		Tags:          pocketapi.NewTags(id, b.pocketTags()),
+		ResolvedID:    id,
+		GivenURL:      b.URL,
+		GivenTitle:    b.title(),
+		ResolvedTitle: b.title(),
+		ResolvedURL:   b.URL,
+		Excerpt:       b.excerpt(),
+		IsArticle:     "1",
+		IsIndex:       "0",
+		HasVideo:      "0",
+		HasImage:      hasImage,
+		WordCount:     "0",
+		Image:         image,
+		TopImageURL:   b.PreviewImageURL,
+	}
+}
+
+// buildGetQuery translates everything except pagination, which depends on
+// whether archived and unarchived bookmarks are both being listed.
+func buildGetQuery(req pocketapi.GetRequest) url.Values {
+	query := url.Values{}
+
+	if req.Since != nil {
+		query.Set("modified_since", time.Unix(*req.Since, 0).UTC().Format(time.RFC3339))
+	}
+
+	// Everything else is filtered through Linkding's search syntax.
+	var terms []string
+	switch strings.ToLower(req.Favorite) {
+	case "0":
+		terms = append(terms, fmt.Sprintf("not #%s", favoriteTag))
+	case "1":
+		terms = append(terms, fmt.Sprintf("#%s", favoriteTag))
+	default:
+		// Leave it unset.
+	}
+
+	switch req.Tag {
+	case "":
+		// Leave it unset.
+	case pocketapi.UntaggedTag:
+		// The favourite tag is hidden from Pocket, so favourites without any other tags are missed here.
+		terms = append(terms, "!untagged")
+	default:
+		terms = append(terms, "#"+req.Tag)
+	}
+
+	if req.Search != "" {
+		terms = append(terms, req.Search)
+	}
+	// Linkding can't filter by domain, but searches match the URL too.
+	if req.Domain != "" {
+		terms = append(terms, req.Domain)
+	}
+
+	if len(terms) > 0 {
+		query.Set("q", strings.Join(terms, " "))
+	}
+
+	// Linkding only sorts by newest first through the API, so req.Sort is ignored.
+	return query
+}
+
+func (conn *LinkdingConn) listBookmarks(ctx context.Context, action string, query url.Values, offset, limit int) (bookmarksResponse, error) {
+	dingReq, err := conn.createRequest(ctx, http.MethodGet, action, nil)
+	if err != nil {
+		return bookmarksResponse{}, err
+	}
+	pageQuery := url.Values{}
+	for k, v := range query {
+		pageQuery[k] = v
+	}
+	pageQuery.Set("offset", strconv.Itoa(offset))
+	pageQuery.Set("limit", strconv.Itoa(limit))
+	dingReq.URL.RawQuery = pageQuery.Encode()
+
+	dingRes, err := conn.do(dingReq)
+	if err != nil {
+		return bookmarksResponse{}, err
+	}
+	defer dingRes.Body.Close()
+
+	var res bookmarksResponse
+	if err := json.NewDecoder(dingRes.Body).Decode(&res); err != nil {
+		return bookmarksResponse{}, err
+	}
+	return res, nil
+}
+
+func (conn *LinkdingConn) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	offset := 0
+	if req.Offset != nil {
+		offset = max(0, *req.Offset)
+	}
+	count := defaultCount
+	if req.Count != nil && *req.Count > 0 {
+		count = *req.Count
+	}
+	query := buildGetQuery(req)
+
+	var bookmarks []bookmark
+	total := 0
+	switch strings.ToLower(req.State) {
+	case "unread":
+		res, err := conn.listBookmarks(ctx, "bookmarks/", query, offset, count)
+		if err != nil {
+			return pocketapi.GetResponse{}, err
+		}
+		bookmarks, total = res.Results, res.Count
+	case "archive":
+		res, err := conn.listBookmarks(ctx, "bookmarks/archived/", query, offset, count)
+		if err != nil {
+			return pocketapi.GetResponse{}, err
+		}
+		bookmarks, total = res.Results, res.Count
+	default:
+		// Linkding lists archived bookmarks separately, so treat them as
+		// coming after all the unarchived ones.
+		unread, err := conn.listBookmarks(ctx, "bookmarks/", query, offset, count)
+		if err != nil {
+			return pocketapi.GetResponse{}, err
+		}
+		bookmarks = unread.Results
+		archivedOffset := max(0, offset-unread.Count)
+		archived, err := conn.listBookmarks(ctx, "bookmarks/archived/", query, archivedOffset, max(1, count-len(bookmarks)))
+		if err != nil {
+			return pocketapi.GetResponse{}, err
+		}
+		if len(bookmarks) < count {
+			bookmarks = append(bookmarks, archived.Results[:min(len(archived.Results), count-len(bookmarks))]...)
+		}
+		total = unread.Count + archived.Count
+	}
+
+	var pocketRes pocketapi.GetResponse
+	pocketRes.Status = 1
+	pocketRes.Total = total
+	pocketRes.List = map[string]pocketapi.GetResponseItem{}
+	for _, b := range bookmarks {
+		pocketRes.List[b.itemID()] = b.toPocketItem()
+	}
+	return pocketRes, nil
+}
+
+func (conn *LinkdingConn) getBookmark(ctx context.Context, itemID string) (bookmark, error) {
+	dingReq, err := conn.createRequest(ctx, http.MethodGet, fmt.Sprintf("bookmarks/%s/", itemID), nil)
+	if err != nil {
+		return bookmark{}, err
+	}
+
+	dingRes, err := conn.do(dingReq)
+	if err != nil {
+		return bookmark{}, err
+	}
+	defer dingRes.Body.Close()
+
+	var b bookmark
+	if err := json.NewDecoder(dingRes.Body).Decode(&b); err != nil {
+		return bookmark{}, err
+	}
+	return b, nil
+}
+
+// findBookmark looks up the bookmark saved with the given URL.
+func (conn *LinkdingConn) findBookmark(ctx context.Context, bookmarkUrl string) (bookmark, error) {
+	dingReq, err := conn.createRequest(ctx, http.MethodGet, "bookmarks/check/", nil)
+	if err != nil {
+		return bookmark{}, err
+	}
+	dingReq.URL.RawQuery = url.Values{"url": {bookmarkUrl}}.Encode()
+
+	dingRes, err := conn.do(dingReq)
+	if err != nil {
+		return bookmark{}, err
+	}
+	defer dingRes.Body.Close()
+
+	var body struct {
+		Bookmark *bookmark `json:"bookmark"`
+	}
+	if err := json.NewDecoder(dingRes.Body).Decode(&body); err != nil {
+		return bookmark{}, err
+	}
+	if body.Bookmark == nil {
+		return bookmark{}, fmt.Errorf("unable to find a bookmark for URL %s in Linkding", bookmarkUrl)
+	}
+	return *body.Bookmark, nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"proxyserver/pocketapi"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var cmpSortStrings = cmpopts.SortSlices(func(a, b string) bool { return a < b })
+
+func numPointer[T int | int64](value T) *T {
+	return &value
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wantToken := "Token token123"
+		if r.Header.Get("Authorization") != wantToken {
+			t.Errorf("Unexpected authorization header: want %s got %s", wantToken, r.Header.Get("Authorization"))
+		}
+		handler(w, r)
+	}))
+}
+
+func TestLinkding_GetRequest(t *testing.T) {
+	testCases := []struct {
+		name     string
+		request  pocketapi.GetRequest
+		wantPath string
+		want     url.Values
+	}{
+		{
+			name:     "Unread",
+			request:  pocketapi.GetRequest{State: "unread"},
+			wantPath: "/api/bookmarks/",
+			want: url.Values{
+				"offset": {"0"},
+				"limit":  {"30"},
+			},
+		},
+		{
+			name: "Archived Favorites",
+			request: pocketapi.GetRequest{
+				State:    "archive",
+				Favorite: "1",
+				Count:    numPointer(10),
+				Offset:   numPointer(20),
+			},
+			wantPath: "/api/bookmarks/archived/",
+			want: url.Values{
+				"offset": {"20"},
+				"limit":  {"10"},
+				"q":      {"#favorite"},
+			},
+		},
+		{
+			name: "Unread Not Favorite Since",
+			request: pocketapi.GetRequest{
+				State:    "unread",
+				Favorite: "0",
+				Since:    numPointer(int64(0)),
+			},
+			wantPath: "/api/bookmarks/",
+			want: url.Values{
+				"offset":         {"0"},
+				"limit":          {"30"},
+				"q":              {"not #favorite"},
+				"modified_since": {time.Unix(0, 0).UTC().Format(time.RFC3339)},
+			},
+		},
+		{
+			name: "Favorite Tag Search Domain",
+			request: pocketapi.GetRequest{
+				State:    "unread",
+				Favorite: "1",
+				Tag:      "news",
+				Search:   "kobo",
+				Domain:   "example.com",
+			},
+			wantPath: "/api/bookmarks/",
+			want: url.Values{
+				"offset": {"0"},
+				"limit":  {"30"},
+				"q":      {"#favorite #news kobo example.com"},
+			},
+		},
+		{
+			name:     "Untagged",
+			request:  pocketapi.GetRequest{State: "unread", Tag: "_untagged_"},
+			wantPath: "/api/bookmarks/",
+			want: url.Values{
+				"offset": {"0"},
+				"limit":  {"30"},
+				"q":      {"!untagged"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("Unexpected HTTP method, want GET got %s", r.Method)
+				}
+				if r.URL.Path != tc.wantPath {
+					t.Errorf("Unexpected path, want %s got %s", tc.wantPath, r.URL.Path)
+				}
+				if diff := cmp.Diff(tc.want, r.URL.Query()); diff != "" {
+					t.Errorf("GET query mismatch (-want +got):\n%s", diff)
+				}
+				w.Write([]byte(`{"count": 0, "results": []}`))
+			})
+			defer server.Close()
+
+			if _, err := NewLinkdingConn(server.URL, "token123").Get(context.Background(), tc.request); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLinkding_GetResponse(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count": 1, "next": null, "results": [{
+			"id": 7,
+			"url": "https://some-news-website.org/something-great-happened/",
+			"title": "",
+			"description": "",
+			"website_title": "Something Great & Awesome Happened",
+			"website_description": "Lorem ipsum",
+			"preview_image_url": "https://some-news-website.org/image.jpeg",
+			"is_archived": false,
+			"unread": true,
+			"tag_names": ["news", "favorite"],
+			"date_added": "2025-06-30T15:08:09Z",
+			"date_modified": "2025-06-30T15:08:12Z"
+		}]}`))
+	})
+	defer server.Close()
+
+	res, err := NewLinkdingConn(server.URL, "token123").Get(context.Background(), pocketapi.GetRequest{State: "unread"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := pocketapi.GetResponse{
+		Status: 1,
+		Total:  1,
+		List: map[string]pocketapi.GetResponseItem{
+			"7": {
+				ItemID:        "7",
+				Favorite:      "1",
+				Status:        "0",
+				TimeAdded:     "1751296089",
+				TimeUpdated:   "1751296092",
+				TimeFavorited: "1751296092",
+				Tags:          pocketapi.Tags{"news": {ItemID: "7", Tag: "news"}},
+				TopImageURL:   "https://some-news-website.org/image.jpeg",
+				ResolvedID:    "7",
+				GivenURL:      "https://some-news-website.org/something-great-happened/",
+				GivenTitle:    "Something Great & Awesome Happened",
+				ResolvedTitle: "Something Great & Awesome Happened",
+				ResolvedURL:   "https://some-news-website.org/something-great-happened/",
+				Excerpt:       "Lorem ipsum",
+				IsArticle:     "1",
+				IsIndex:       "0",
+				HasVideo:      "0",
+				HasImage:      "1",
+				WordCount:     "0",
+				Image: &pocketapi.Image{
+					ItemID:  "7",
+					ImageID: pocketapi.Digest("https://some-news-website.org/image.jpeg"),
+					Src:     "https://some-news-website.org/image.jpeg",
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, res); diff != "" {
+		t.Errorf("GET response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkding_GetAllPagination(t *testing.T) {
+	// 5 unread bookmarks (u0-u4) followed by 5 archived ones (a0-a4).
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		prefix := "u"
+		if r.URL.Path == "/api/bookmarks/archived/" {
+			prefix = "a"
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var results string
+		for i := offset; i < min(5, offset+limit); i++ {
+			if results != "" {
+				results += ","
+			}
+			// Use the ID to encode the prefix, since IDs are numbers.
+			id := i
+			if prefix == "a" {
+				id += 100
+			}
+			results += fmt.Sprintf(`{"id": %d, "url": "https://test.com/%s%d"}`, id, prefix, i)
+		}
+		fmt.Fprintf(w, `{"count": 5, "results": [%s]}`, results)
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		offset  int
+		wantIDs []string
+	}{
+		{name: "Only Unread", offset: 0, wantIDs: []string{"0", "1", "2"}},
+		{name: "Unread And Archived", offset: 3, wantIDs: []string{"3", "4", "100"}},
+		{name: "Only Archived", offset: 6, wantIDs: []string{"101", "102", "103"}},
+		{name: "Past The End", offset: 12, wantIDs: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewLinkdingConn(server.URL, "token123").Get(context.Background(), pocketapi.GetRequest{
+				Count:  numPointer(3),
+				Offset: numPointer(tc.offset),
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var gotIDs []string
+			for id := range res.List {
+				gotIDs = append(gotIDs, id)
+			}
+			if diff := cmp.Diff(tc.wantIDs, gotIDs, cmpSortStrings); diff != "" {
+				t.Errorf("Item IDs mismatch (-want +got):\n%s", diff)
+			}
+			if res.Total != 10 {
+				t.Errorf("Unexpected total, want 10 got %d", res.Total)
+			}
+		})
+	}
+}
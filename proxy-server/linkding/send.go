@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"proxyserver/httpclient"
+	"slices"
+	"time"
+)
+
+// post sends the body to the action, decoding the response into result unless it's nil.
+func (conn *LinkdingConn) post(ctx context.Context, action string, body any, result any) error {
+	var buffer bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buffer).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	dingReq, err := conn.createRequest(ctx, http.MethodPost, action, &buffer)
+	if err != nil {
+		return err
+	}
+	dingReq.Header.Set("Content-Type", "application/json")
+
+	dingRes, err := conn.do(dingReq)
+	if err != nil {
+		return err
+	}
+	defer dingRes.Body.Close()
+	if result != nil {
+		return json.NewDecoder(dingRes.Body).Decode(result)
+	}
+	return nil
+}
+
+type updateRequest struct {
+	TagNames []string `json:"tag_names"`
+}
+
+// updateTags applies the update function to the bookmark's current tags and saves the result.
+func (conn *LinkdingConn) updateTags(ctx context.Context, itemID string, update func([]string) []string) error {
+	b, err := conn.getBookmark(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(updateRequest{TagNames: update(b.TagNames)}); err != nil {
+		return err
+	}
+
+	dingReq, err := conn.createRequest(ctx, http.MethodPatch, fmt.Sprintf("bookmarks/%s/", itemID), &buffer)
+	if err != nil {
+		return err
+	}
+	dingReq.Header.Set("Content-Type", "application/json")
+
+	// Updates set fields to fixed values, so they're safe to retry.
+	dingRes, err := conn.do(httpclient.Idempotent(dingReq))
+	if err != nil {
+		return err
+	}
+	return dingRes.Body.Close()
+}
+
+type insertRequest struct {
+	Url   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+func (conn *LinkdingConn) Add(ctx context.Context, url string, title string, time time.Time) (string, error) {
+	var created bookmark
+	if err := conn.post(ctx, "bookmarks/", insertRequest{Url: url, Title: title}, &created); err != nil {
+		return "", err
+	}
+	return created.itemID(), nil
+}
+
+func (conn *LinkdingConn) Archive(ctx context.Context, itemID string, time time.Time) error {
+	return conn.post(ctx, fmt.Sprintf("bookmarks/%s/archive/", itemID), nil, nil)
+}
+
+func (conn *LinkdingConn) Unarchive(ctx context.Context, itemID string, time time.Time) error {
+	return conn.post(ctx, fmt.Sprintf("bookmarks/%s/unarchive/", itemID), nil, nil)
+}
+
+func (conn *LinkdingConn) Delete(ctx context.Context, itemID string, time time.Time) error {
+	dingReq, err := conn.createRequest(ctx, http.MethodDelete, fmt.Sprintf("bookmarks/%s/", itemID), nil)
+	if err != nil {
+		return err
+	}
+
+	dingRes, err := conn.do(dingReq)
+	if err != nil {
+		return err
+	}
+	return dingRes.Body.Close()
+}
+
+func (conn *LinkdingConn) Favorite(ctx context.Context, itemID string, time time.Time) error {
+	return conn.updateTags(ctx, itemID, func(tags []string) []string {
+		if slices.Contains(tags, favoriteTag) {
+			return tags
+		}
+		return append(tags, favoriteTag)
+	})
+}
+
+func (conn *LinkdingConn) Unfavorite(ctx context.Context, itemID string, time time.Time) error {
+	return conn.updateTags(ctx, itemID, func(tags []string) []string {
+		return slices.DeleteFunc(tags, func(t string) bool { return t == favoriteTag })
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkding_Send(t *testing.T) {
+	const itemID = "7"
+
+	testCases := []struct {
+		name        string
+		update      func(*LinkdingConn) error
+		statusCode  int
+		wantError   bool
+		wantMethod  string
+		wantPath    string
+		currentTags []string
+		wantTags    []string
+	}{
+		{
+			name:       "Archive",
+			statusCode: http.StatusNoContent,
+			update: func(conn *LinkdingConn) error {
+				return conn.Archive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPost,
+			wantPath:   "/api/bookmarks/7/archive/",
+		},
+		{
+			name:       "Unarchive",
+			statusCode: http.StatusNoContent,
+			update: func(conn *LinkdingConn) error {
+				return conn.Unarchive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPost,
+			wantPath:   "/api/bookmarks/7/unarchive/",
+		},
+		{
+			name:       "Delete",
+			statusCode: http.StatusNoContent,
+			update: func(conn *LinkdingConn) error {
+				return conn.Delete(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodDelete,
+			wantPath:   "/api/bookmarks/7/",
+		},
+		{
+			name:       "Error",
+			statusCode: http.StatusNotFound,
+			update: func(conn *LinkdingConn) error {
+				return conn.Archive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPost,
+			wantPath:   "/api/bookmarks/7/archive/",
+			wantError:  true,
+		},
+		{
+			name:       "Favorite",
+			statusCode: http.StatusOK,
+			update: func(conn *LinkdingConn) error {
+				return conn.Favorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod:  http.MethodPatch,
+			wantPath:    "/api/bookmarks/7/",
+			currentTags: []string{"news"},
+			wantTags:    []string{"news", "favorite"},
+		},
+		{
+			name:       "Already Favorite",
+			statusCode: http.StatusOK,
+			update: func(conn *LinkdingConn) error {
+				return conn.Favorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod:  http.MethodPatch,
+			wantPath:    "/api/bookmarks/7/",
+			currentTags: []string{"favorite", "news"},
+			wantTags:    []string{"favorite", "news"},
+		},
+		{
+			name:       "Unfavorite",
+			statusCode: http.StatusOK,
+			update: func(conn *LinkdingConn) error {
+				return conn.Unfavorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod:  http.MethodPatch,
+			wantPath:    "/api/bookmarks/7/",
+			currentTags: []string{"favorite", "news"},
+			wantTags:    []string{"news"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/api/bookmarks/7/" {
+					json.NewEncoder(w).Encode(bookmark{ID: 7, TagNames: tc.currentTags})
+					return
+				}
+				if r.Method != tc.wantMethod {
+					t.Errorf("Unexpected HTTP method, want %s got %s", tc.wantMethod, r.Method)
+				}
+				if r.URL.Path != tc.wantPath {
+					t.Errorf("Unexpected path, want %s got %s", tc.wantPath, r.URL.Path)
+				}
+				if tc.wantTags != nil {
+					var got updateRequest
+					if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+						t.Errorf("Unable to decode request body: %v", err)
+					}
+					if diff := cmp.Diff(tc.wantTags, got.TagNames); diff != "" {
+						t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+					}
+				}
+				w.WriteHeader(tc.statusCode)
+			})
+			defer server.Close()
+
+			err := tc.update(NewLinkdingConn(server.URL, "token123"))
+			if tc.wantError && err == nil {
+				t.Error("Wanted error, got nil instead")
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("Wanted nil error, got %v instead", err)
+			}
+		})
+	}
+}
+
+func TestLinkding_Add(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/bookmarks/" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var got insertRequest
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Unable to decode request body: %v", err)
+		}
+		want := insertRequest{Url: "https://test.com/article", Title: "Title"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Request body mismatch (-want +got):\n%s", diff)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 7, "url": "https://test.com/article"}`))
+	})
+	defer server.Close()
+
+	itemID, err := NewLinkdingConn(server.URL, "token123").Add(context.Background(), "https://test.com/article", "Title", time.Time{})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if itemID != "7" {
+		t.Errorf("Wanted item ID 7, got %q", itemID)
+	}
+}
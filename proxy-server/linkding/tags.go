@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// The favourite tag is managed by Favorite and Unfavorite, so the tag actions
+// below leave it alone.
+
+func (conn *LinkdingConn) AddTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return conn.updateTags(ctx, itemID, func(current []string) []string {
+		for _, t := range tags {
+			if !slices.Contains(current, t) {
+				current = append(current, t)
+			}
+		}
+		return current
+	})
+}
+
+func (conn *LinkdingConn) RemoveTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return conn.updateTags(ctx, itemID, func(current []string) []string {
+		return slices.DeleteFunc(current, func(t string) bool { return t != favoriteTag && slices.Contains(tags, t) })
+	})
+}
+
+func (conn *LinkdingConn) ReplaceTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return conn.updateTags(ctx, itemID, func(current []string) []string {
+		replaced := []string{}
+		if slices.Contains(current, favoriteTag) {
+			replaced = append(replaced, favoriteTag)
+		}
+		for _, t := range tags {
+			if !slices.Contains(replaced, t) {
+				replaced = append(replaced, t)
+			}
+		}
+		return replaced
+	})
+}
+
+func (conn *LinkdingConn) ClearTags(ctx context.Context, itemID string, time time.Time) error {
+	return conn.ReplaceTags(ctx, itemID, nil, time)
+}
+
+// Linkding has no API for managing tags themselves, so renaming or deleting
+// one means updating every bookmark that has it.
+
+func (conn *LinkdingConn) RenameTag(ctx context.Context, oldTag string, newTag string, time time.Time) error {
+	return conn.updateTagged(ctx, oldTag, func(current []string) []string {
+		current = slices.DeleteFunc(current, func(t string) bool { return t == oldTag })
+		if !slices.Contains(current, newTag) {
+			current = append(current, newTag)
+		}
+		return current
+	})
+}
+
+func (conn *LinkdingConn) DeleteTag(ctx context.Context, tag string, time time.Time) error {
+	return conn.updateTagged(ctx, tag, func(current []string) []string {
+		return slices.DeleteFunc(current, func(t string) bool { return t == tag })
+	})
+}
+
+// updateTagged applies the update function to every bookmark, archived or not, with the given tag.
+func (conn *LinkdingConn) updateTagged(ctx context.Context, tag string, update func([]string) []string) error {
+	if tag == favoriteTag {
+		return fmt.Errorf("the %q tag is reserved for favourites", favoriteTag)
+	}
+
+	// Collect the IDs first, since updating bookmarks changes the search results.
+	query := url.Values{}
+	query.Set("q", "#"+tag)
+	var ids []string
+	for _, action := range []string{"bookmarks/", "bookmarks/archived/"} {
+		for offset := 0; ; {
+			res, err := conn.listBookmarks(ctx, action, query, offset, tagPageSize)
+			if err != nil {
+				return err
+			}
+			for _, b := range res.Results {
+				// Linkding's tag search isn't case sensitive.
+				if slices.Contains(b.TagNames, tag) {
+					ids = append(ids, b.itemID())
+				}
+			}
+			offset += len(res.Results)
+			if res.Next == nil || len(res.Results) == 0 {
+				break
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if err := conn.updateTags(ctx, id, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkding_ItemTags(t *testing.T) {
+	const itemID = "7"
+
+	testCases := []struct {
+		name        string
+		update      func(*LinkdingConn) error
+		currentTags []string
+		wantTags    []string
+	}{
+		{
+			name: "Add",
+			update: func(conn *LinkdingConn) error {
+				return conn.AddTags(context.Background(), itemID, []string{"news", "go"}, time.Time{})
+			},
+			currentTags: []string{"news"},
+			wantTags:    []string{"news", "go"},
+		},
+		{
+			name: "Remove",
+			update: func(conn *LinkdingConn) error {
+				return conn.RemoveTags(context.Background(), itemID, []string{"news", "favorite"}, time.Time{})
+			},
+			currentTags: []string{"favorite", "news", "go"},
+			wantTags:    []string{"favorite", "go"},
+		},
+		{
+			name: "Replace",
+			update: func(conn *LinkdingConn) error {
+				return conn.ReplaceTags(context.Background(), itemID, []string{"go"}, time.Time{})
+			},
+			currentTags: []string{"news", "favorite"},
+			wantTags:    []string{"favorite", "go"},
+		},
+		{
+			name: "Clear",
+			update: func(conn *LinkdingConn) error {
+				return conn.ClearTags(context.Background(), itemID, time.Time{})
+			},
+			currentTags: []string{"news"},
+			wantTags:    []string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/bookmarks/7/" {
+					t.Errorf("Unexpected path %s", r.URL.Path)
+				}
+				switch r.Method {
+				case http.MethodGet:
+					json.NewEncoder(w).Encode(bookmark{ID: 7, TagNames: tc.currentTags})
+				case http.MethodPatch:
+					var got updateRequest
+					if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+						t.Errorf("Unable to decode request body: %v", err)
+					}
+					if diff := cmp.Diff(tc.wantTags, got.TagNames); diff != "" {
+						t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+					}
+				default:
+					t.Errorf("Unexpected HTTP method %s", r.Method)
+				}
+			})
+			defer server.Close()
+
+			if err := tc.update(NewLinkdingConn(server.URL, "token123")); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLinkding_RenameTag(t *testing.T) {
+	bookmarks := map[string]bookmark{
+		"1": {ID: 1, TagNames: []string{"news", "go"}},
+		"2": {ID: 2, TagNames: []string{"News"}},
+		"3": {ID: 3, TagNames: []string{"news", "favorite"}, IsArchived: true},
+	}
+	var mu sync.Mutex
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && (r.URL.Path == "/api/bookmarks/" || r.URL.Path == "/api/bookmarks/archived/"):
+			if got := r.URL.Query().Get("q"); got != "#news" {
+				t.Errorf("Unexpected search query %q", got)
+			}
+			archived := r.URL.Path == "/api/bookmarks/archived/"
+			var res bookmarksResponse
+			for _, id := range []string{"1", "2", "3"} {
+				if b := bookmarks[id]; b.IsArchived == archived {
+					res.Results = append(res.Results, b)
+				}
+			}
+			res.Count = len(res.Results)
+			json.NewEncoder(w).Encode(res)
+		case r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(bookmarks[r.URL.Path[len("/api/bookmarks/"):len(r.URL.Path)-1]])
+		case r.Method == http.MethodPatch:
+			id := r.URL.Path[len("/api/bookmarks/") : len(r.URL.Path)-1]
+			var got updateRequest
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("Unable to decode request body: %v", err)
+			}
+			b := bookmarks[id]
+			b.TagNames = got.TagNames
+			bookmarks[id] = b
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	if err := NewLinkdingConn(server.URL, "token123").RenameTag(context.Background(), "news", "world", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"1": {"go", "world"},
+		"2": {"News"},
+		"3": {"favorite", "world"},
+	}
+	got := map[string][]string{}
+	for id, b := range bookmarks {
+		got[id] = b.TagNames
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLinkding_DeleteFavoriteTag(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+	})
+	defer server.Close()
+
+	if err := NewLinkdingConn(server.URL, "token123").DeleteTag(context.Background(), favoriteTag, time.Time{}); err == nil {
+		t.Error("Wanted error, got nil instead")
+	}
+}
+
+func TestLinkding_PocketTags(t *testing.T) {
+	b := bookmark{TagNames: []string{"news", "favorite", "go"}}
+	if diff := cmp.Diff([]string{"news", "go"}, b.pocketTags()); diff != "" {
+		t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+	}
+	if !slices.Contains(b.TagNames, favoriteTag) {
+		t.Error("pocketTags modified the bookmark's tags")
+	}
+}
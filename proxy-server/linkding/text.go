@@ -0,0 +1,51 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"fmt"
+	"proxyserver/extract"
+	"proxyserver/pocketapi"
+	"time"
+)
+
+func (conn *LinkdingConn) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	b, err := conn.findBookmark(ctx, url)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+
+	// Linkding doesn't store the article content, so download and extract it here instead.
+	extracted, err := extract.Fetch(ctx, conn.pageClient, url)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error extracting article: %v", err)
+	}
+	// The user's own title and description take precedence.
+	if b.Title != "" {
+		extracted.Title = b.Title
+	}
+	if b.Description != "" {
+		extracted.Excerpt = b.Description
+	}
+
+	article := pocketapi.ArticleTextResponse{}
+	if err := extracted.ToArticleText(b.itemID(), &article); err != nil {
+		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error parsing article HTML: %v", err)
+	}
+	article.DateResolved = b.DateAdded.Format(time.RFC3339)
+
+	return article, nil
+}
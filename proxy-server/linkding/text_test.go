@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linkding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLinkding_ArticleText(t *testing.T) {
+	website := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Scraped Title</title></head><body>
+			<article><p>This is the body of the article, it's long enough to be a paragraph.</p>
+			<img src="/img.png" width="10" height="10"></article>
+		</body></html>`))
+	}))
+	defer website.Close()
+	articleUrl := website.URL + "/article.html"
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/bookmarks/check/" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("url"); got != articleUrl {
+			t.Errorf("Unexpected URL check, want %s got %s", articleUrl, got)
+		}
+		fmt.Fprintf(w, `{"bookmark": {"id": 7, "url": %q, "title": "My Title", "date_added": "2025-06-30T15:08:09Z"}}`, articleUrl)
+	})
+	defer server.Close()
+
+	article, err := NewLinkdingConn(server.URL, "token123").ArticleText(context.Background(), articleUrl)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if article.ItemID != "7" || article.Title != "My Title" || article.DateResolved != "2025-06-30T15:08:09Z" {
+		t.Errorf("Unexpected article metadata: %+v", article)
+	}
+	if !strings.Contains(article.Article, "This is the body of the article") || !strings.Contains(article.Article, "<!--IMG_1-->") {
+		t.Errorf("Unexpected article HTML: %s", article.Article)
+	}
+	if img := article.Images["1"]; img.Src != website.URL+"/img.png" || img.Width != "10" {
+		t.Errorf("Unexpected image: %+v", img)
+	}
+}
+
+func TestLinkding_ArticleTextNotFound(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bookmark": null}`))
+	})
+	defer server.Close()
+
+	if _, err := NewLinkdingConn(server.URL, "token123").ArticleText(context.Background(), "https://test.com/missing"); err == nil {
+		t.Error("Wanted error, got nil instead")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func (i item) toPocketItem() pocketapi.GetResponseItem {
+
+	timeFavorited := "0"
+	if i.Favorite {
+		timeFavorited = strconv.FormatInt(i.Favorited.Unix(), 10)
+	}
+
+	var domainMeta *pocketapi.DomainMetadata
+	if i.SiteName != "" {
+		domainMeta = &pocketapi.DomainMetadata{Name: i.SiteName}
+	}
+
+	var authors map[string]pocketapi.Author
+	if i.Byline != "" {
+		id := pocketapi.Digest(i.Byline)
+		authors = map[string]pocketapi.Author{
+			id: {AuthorID: id, Name: i.Byline, ItemID: i.ID},
+		}
+	}
+
+	hasImage := "0"
+	var image *pocketapi.Image
+	if i.TopImageURL != "" {
+		hasImage = "1"
+		image = &pocketapi.Image{
+			ItemID:  i.ID,
+			ImageID: pocketapi.Digest(i.TopImageURL),
+			Src:     i.TopImageURL,
+		}
+	}
+
+	status := "0"
+	if i.Archived {
+		status = "1"
+	}
+
+	return pocketapi.GetResponseItem{
+		ItemID:         i.ID,
+		Favorite:       pocketapi.OneIfTrue(i.Favorite),
+		Status:         status,
+		TimeAdded:      strconv.FormatInt(i.Added.Unix(), 10),
+		TimeUpdated:    strconv.FormatInt(i.Updated.Unix(), 10),
+		TimeFavorited:  timeFavorited,
+		Tags:           pocketapi.NewTags(i.ID, i.Tags),
+		ResolvedID:     i.ID,
+		GivenURL:       i.URL,
+		GivenTitle:     i.title(),
+		ResolvedTitle:  i.title(),
+		ResolvedURL:    i.URL,
+		Excerpt:        i.Excerpt,
+		IsArticle:      "1",
+		IsIndex:        "0",
+		HasVideo:       "0",
+		HasImage:       hasImage,
+		WordCount:      strconv.Itoa(i.WordCount),
+		Lang:           i.Lang,
+		TimeToRead:     (i.WordCount + 199) / 200,
+		DomainMetadata: domainMeta,
+		Authors:        authors,
+		Image:          image,
+		TopImageURL:    i.TopImageURL,
+	}
+}
+
+func matchesRequest(req pocketapi.GetRequest, i item) bool {
+	switch strings.ToLower(req.State) {
+	case "unread":
+		if i.Archived {
+			return false
+		}
+	case "archive":
+		if !i.Archived {
+			return false
+		}
+	}
+
+	switch req.Favorite {
+	case "0":
+		if i.Favorite {
+			return false
+		}
+	case "1":
+		if !i.Favorite {
+			return false
+		}
+	}
+
+	if req.Since != nil && i.Updated.Before(time.Unix(*req.Since, 0)) {
+		return false
+	}
+
+	switch req.Tag {
+	case "":
+		// No filter.
+	case pocketapi.UntaggedTag:
+		if len(i.Tags) > 0 {
+			return false
+		}
+	default:
+		if !slices.Contains(i.Tags, req.Tag) {
+			return false
+		}
+	}
+
+	if req.Search != "" {
+		search := strings.ToLower(req.Search)
+		if !strings.Contains(strings.ToLower(i.title()), search) && !strings.Contains(strings.ToLower(i.URL), search) {
+			return false
+		}
+	}
+
+	if req.Domain != "" {
+		// Subdomains match too, e.g. "example.com" matches "www.example.com".
+		h, domain := strings.ToLower(host(i.URL)), strings.ToLower(req.Domain)
+		if h != domain && !strings.HasSuffix(h, "."+domain) {
+			return false
+		}
+	}
+
+	// Everything saved locally is an article, so there aren't any videos or images.
+	switch strings.ToLower(req.ContentType) {
+	case "video", "image":
+		return false
+	}
+	return true
+}
+
+func host(rawUrl string) string {
+	if u, err := url.Parse(rawUrl); err == nil {
+		return u.Hostname()
+	}
+	return rawUrl
+}
+
+func sortItems(sortOrder string, items []item) {
+	slices.SortStableFunc(items, func(a, b item) int {
+		switch strings.ToLower(sortOrder) {
+		case "oldest":
+			return a.Added.Compare(b.Added)
+		case "title":
+			return strings.Compare(strings.ToLower(a.title()), strings.ToLower(b.title()))
+		case "site":
+			return strings.Compare(host(a.URL), host(b.URL))
+		default:
+			return b.Added.Compare(a.Added)
+		}
+	})
+}
+
+func (conn *LocalConn) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	var items []item
+	err := conn.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(itemsBucket).ForEach(func(k, v []byte) error {
+			var i item
+			if err := json.Unmarshal(v, &i); err != nil {
+				return err
+			}
+			if matchesRequest(req, i) {
+				items = append(items, i)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return pocketapi.GetResponse{}, err
+	}
+	sortItems(req.Sort, items)
+
+	var pocketRes pocketapi.GetResponse
+	pocketRes.Status = 1
+	pocketRes.Total = len(items)
+	pocketRes.List = map[string]pocketapi.GetResponseItem{}
+
+	offset := 0
+	if req.Offset != nil {
+		offset = min(max(0, *req.Offset), len(items))
+	}
+	end := len(items)
+	if req.Count != nil && *req.Count > 0 {
+		end = min(offset+*req.Count, len(items))
+	}
+	for _, i := range items[offset:end] {
+		pocketRes.List[i.ID] = i.toPocketItem()
+	}
+	return pocketRes, nil
+}
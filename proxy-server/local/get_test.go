@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"proxyserver/extract"
+	"proxyserver/pocketapi"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var cmpEmptySlices = cmpopts.EquateEmpty()
+
+func numPointer[T int | int64](value T) *T {
+	return &value
+}
+
+// newTestConn opens a database in a temporary directory, with a fake extractor
+// that returns an article titled after the URL.
+func newTestConn(t *testing.T) *LocalConn {
+	conn, err := NewLocalConn(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Unable to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		if url == "https://fail.com" {
+			return extract.Article{}, errors.New("fetch failed")
+		}
+		return extract.Article{
+			URL:       url,
+			Title:     "Title of " + url,
+			SiteName:  "Site",
+			Content:   `<p>Some text</p><img src="https://img.com/1.png">`,
+			WordCount: 400,
+		}, nil
+	}
+	return conn
+}
+
+func TestLocal_Get(t *testing.T) {
+	conn := newTestConn(t)
+	base := time.Unix(1751296089, 0)
+	for i, url := range []string{"https://c.com", "https://a.com", "https://b.com"} {
+		if _, err := conn.Add(context.Background(), url, "", base.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Unexpected error adding %s: %v", url, err)
+		}
+	}
+	// IDs are assigned in order, so c.com is 1, a.com is 2 and b.com is 3.
+	if err := conn.Archive(context.Background(), "2", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error archiving: %v", err)
+	}
+	if err := conn.Favorite(context.Background(), "3", base); err != nil {
+		t.Fatalf("Unexpected error favoriting: %v", err)
+	}
+	if err := conn.AddTags(context.Background(), "1", []string{"news"}, time.Time{}); err != nil {
+		t.Fatalf("Unexpected error tagging: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		request   pocketapi.GetRequest
+		wantIDs   []string
+		wantTotal int
+	}{
+		{name: "All Newest", request: pocketapi.GetRequest{}, wantIDs: []string{"3", "2", "1"}, wantTotal: 3},
+		{name: "Oldest", request: pocketapi.GetRequest{Sort: "oldest"}, wantIDs: []string{"1", "2", "3"}, wantTotal: 3},
+		{name: "Site", request: pocketapi.GetRequest{Sort: "site"}, wantIDs: []string{"2", "3", "1"}, wantTotal: 3},
+		{name: "Unread", request: pocketapi.GetRequest{State: "unread"}, wantIDs: []string{"3", "1"}, wantTotal: 2},
+		{name: "Archived", request: pocketapi.GetRequest{State: "archive"}, wantIDs: []string{"2"}, wantTotal: 1},
+		{name: "Favorites", request: pocketapi.GetRequest{Favorite: "1"}, wantIDs: []string{"3"}, wantTotal: 1},
+		{name: "Not Favorites", request: pocketapi.GetRequest{Favorite: "0"}, wantIDs: []string{"2", "1"}, wantTotal: 2},
+		{name: "Videos", request: pocketapi.GetRequest{ContentType: "video"}, wantTotal: 0},
+		{name: "Paginated", request: pocketapi.GetRequest{Count: numPointer(2), Offset: numPointer(1)}, wantIDs: []string{"2", "1"}, wantTotal: 3},
+		{name: "Past The End", request: pocketapi.GetRequest{Count: numPointer(2), Offset: numPointer(5)}, wantTotal: 3},
+		{name: "Tag", request: pocketapi.GetRequest{Tag: "news"}, wantIDs: []string{"1"}, wantTotal: 1},
+		{name: "Untagged", request: pocketapi.GetRequest{Tag: "_untagged_"}, wantIDs: []string{"3", "2"}, wantTotal: 2},
+		{name: "Search", request: pocketapi.GetRequest{Search: "B.COM"}, wantIDs: []string{"3"}, wantTotal: 1},
+		{name: "Domain", request: pocketapi.GetRequest{Domain: "a.com"}, wantIDs: []string{"2"}, wantTotal: 1},
+		{name: "Since Future", request: pocketapi.GetRequest{Since: numPointer(time.Now().Add(time.Hour).Unix())}, wantTotal: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := conn.Get(context.Background(), tc.request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Total != tc.wantTotal {
+				t.Errorf("Unexpected total, want %d got %d", tc.wantTotal, res.Total)
+			}
+			// Check the sort order by sorting the IDs by the order they were returned in.
+			got := make([]string, 0, len(res.List))
+			for _, id := range tc.wantIDs {
+				if _, exists := res.List[id]; exists {
+					got = append(got, id)
+				}
+			}
+			if len(res.List) != len(tc.wantIDs) {
+				t.Errorf("Unexpected number of items, want %d got %d", len(tc.wantIDs), len(res.List))
+			}
+			if diff := cmp.Diff(tc.wantIDs, got, cmpEmptySlices); diff != "" {
+				t.Errorf("Item IDs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLocal_GetResponseItem(t *testing.T) {
+	conn := newTestConn(t)
+	added := time.Unix(1751296089, 0)
+	if _, err := conn.Add(context.Background(), "https://a.com/article", "My Title", added); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := conn.Favorite(context.Background(), "1", added); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	res, err := conn.Get(context.Background(), pocketapi.GetRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := res.List["1"]
+	got.TimeUpdated = ""
+	want := pocketapi.GetResponseItem{
+		ItemID:         "1",
+		Favorite:       "1",
+		Status:         "0",
+		TimeAdded:      "1751296089",
+		TimeFavorited:  "1751296089",
+		ResolvedID:     "1",
+		GivenURL:       "https://a.com/article",
+		GivenTitle:     "My Title",
+		ResolvedTitle:  "My Title",
+		ResolvedURL:    "https://a.com/article",
+		IsArticle:      "1",
+		IsIndex:        "0",
+		HasVideo:       "0",
+		HasImage:       "0",
+		WordCount:      "400",
+		TimeToRead:     2,
+		DomainMetadata: &pocketapi.DomainMetadata{Name: "Site"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Item mismatch (-want +got):\n%s", diff)
+	}
+}
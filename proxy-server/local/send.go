@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func (conn *LocalConn) Add(ctx context.Context, url string, title string, addTime time.Time) (string, error) {
+	if addTime.IsZero() {
+		addTime = time.Now()
+	}
+
+	// Download the article outside of the transaction, since it could take a while.
+	article, extractErr := conn.fetch(ctx, url)
+	if extractErr != nil {
+		// Still save the bookmark, extraction will be retried when the article is requested.
+		log.Printf("Unable to extract article %s: %v", url, extractErr)
+	}
+
+	var itemID string
+	err := conn.db.Update(func(tx *bolt.Tx) error {
+		i := item{URL: url, Added: addTime}
+		if id, exists := findID(tx, url); exists {
+			// Re-adding an existing item moves it back to the unread list, same as Pocket.
+			existing, err := getItem(tx, id)
+			if err != nil {
+				return err
+			}
+			i = existing
+			i.Archived = false
+		} else {
+			id, err := nextID(tx)
+			if err != nil {
+				return err
+			}
+			i.ID = id
+		}
+
+		if title != "" {
+			i.GivenTitle = title
+		}
+		i.Updated = time.Now()
+		if extractErr != nil {
+			i.ExtractError = extractErr.Error()
+		} else {
+			i.setArticle(article)
+			if err := putArticle(tx, i.ID, article); err != nil {
+				return err
+			}
+		}
+		itemID = i.ID
+		return putItem(tx, i)
+	})
+	return itemID, err
+}
+
+func (conn *LocalConn) Archive(ctx context.Context, itemID string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) { i.Archived = true })
+}
+
+func (conn *LocalConn) Unarchive(ctx context.Context, itemID string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) { i.Archived = false })
+}
+
+func (conn *LocalConn) Delete(ctx context.Context, itemID string, time time.Time) error {
+	return conn.db.Update(func(tx *bolt.Tx) error {
+		i, err := getItem(tx, itemID)
+		if errors.Is(err, errNotFound) {
+			// Already deleted.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(urlsBucket).Delete([]byte(i.URL)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(articlesBucket).Delete([]byte(itemID)); err != nil {
+			return err
+		}
+		return tx.Bucket(itemsBucket).Delete([]byte(itemID))
+	})
+}
+
+func (conn *LocalConn) Favorite(ctx context.Context, itemID string, favoriteTime time.Time) error {
+	if favoriteTime.IsZero() {
+		favoriteTime = time.Now()
+	}
+	return conn.updateItem(itemID, func(i *item) {
+		i.Favorite = true
+		i.Favorited = favoriteTime
+	})
+}
+
+func (conn *LocalConn) Unfavorite(ctx context.Context, itemID string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) { i.Favorite = false })
+}
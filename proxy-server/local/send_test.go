@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"proxyserver/pocketapi"
+	"testing"
+	"time"
+)
+
+func TestLocal_Send(t *testing.T) {
+	conn := newTestConn(t)
+	if _, err := conn.Add(context.Background(), "https://a.com", "", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	getItem := func() pocketapi.GetResponseItem {
+		res, err := conn.Get(context.Background(), pocketapi.GetRequest{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return res.List["1"]
+	}
+
+	steps := []struct {
+		name         string
+		action       func() error
+		wantStatus   string
+		wantFavorite string
+	}{
+		{name: "Archive", action: func() error { return conn.Archive(context.Background(), "1", time.Time{}) }, wantStatus: "1", wantFavorite: "0"},
+		{name: "Favorite", action: func() error { return conn.Favorite(context.Background(), "1", time.Time{}) }, wantStatus: "1", wantFavorite: "1"},
+		{name: "Unarchive", action: func() error { return conn.Unarchive(context.Background(), "1", time.Time{}) }, wantStatus: "0", wantFavorite: "1"},
+		{name: "Unfavorite", action: func() error { return conn.Unfavorite(context.Background(), "1", time.Time{}) }, wantStatus: "0", wantFavorite: "0"},
+		{name: "Archive Again", action: func() error { return conn.Archive(context.Background(), "1", time.Time{}) }, wantStatus: "1", wantFavorite: "0"},
+		// Re-adding moves it back to the unread list.
+		{name: "Readd", action: func() error {
+			_, err := conn.Add(context.Background(), "https://a.com", "", time.Time{})
+			return err
+		}, wantStatus: "0", wantFavorite: "0"},
+	}
+	for _, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		item := getItem()
+		if item.Status != step.wantStatus || item.Favorite != step.wantFavorite {
+			t.Errorf("%s: unexpected status/favorite, want %s/%s got %s/%s", step.name, step.wantStatus, step.wantFavorite, item.Status, item.Favorite)
+		}
+	}
+
+	if err := conn.Delete(context.Background(), "1", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error deleting: %v", err)
+	}
+	res, err := conn.Get(context.Background(), pocketapi.GetRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(res.List) != 0 {
+		t.Errorf("Expected no items after deleting, got %v", res.List)
+	}
+	if _, err := conn.ArticleText(context.Background(), "https://a.com"); err == nil {
+		t.Error("Expected the URL to be gone after deleting")
+	}
+
+	// Deleting twice and updating missing items.
+	if err := conn.Delete(context.Background(), "1", time.Time{}); err != nil {
+		t.Errorf("Unexpected error deleting twice: %v", err)
+	}
+	if err := conn.Archive(context.Background(), "1", time.Time{}); err == nil {
+		t.Error("Wanted error archiving a missing item, got nil instead")
+	}
+}
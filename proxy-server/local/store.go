@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package local implements a self-contained backend, which stores bookmarks and their
+// extracted article text in an embedded database instead of an external service.
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"proxyserver/extract"
+	"proxyserver/httpclient"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// Item ID -> item, JSON encoded.
+	itemsBucket = []byte("items")
+	// Item URL -> item ID.
+	urlsBucket = []byte("urls")
+	// Item ID -> extracted article, JSON encoded.
+	articlesBucket = []byte("articles")
+)
+
+var errNotFound = errors.New("item not found")
+
+type item struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	GivenTitle    string    `json:"given_title,omitempty"`
+	Title         string    `json:"title,omitempty"`
+	Excerpt       string    `json:"excerpt,omitempty"`
+	SiteName      string    `json:"site_name,omitempty"`
+	Byline        string    `json:"byline,omitempty"`
+	Lang          string    `json:"lang,omitempty"`
+	TopImageURL   string    `json:"top_image_url,omitempty"`
+	WordCount     int       `json:"word_count,omitempty"`
+	Archived      bool      `json:"archived,omitempty"`
+	Favorite      bool      `json:"favorite,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	Added         time.Time `json:"added"`
+	Updated       time.Time `json:"updated"`
+	Favorited     time.Time `json:"favorited,omitempty"`
+	DatePublished time.Time `json:"date_published,omitempty"`
+	// The last error hit while extracting the article, if any.
+	ExtractError string `json:"extract_error,omitempty"`
+}
+
+// The title the user gave takes precedence over the extracted one.
+func (i item) title() string {
+	if i.GivenTitle != "" {
+		return i.GivenTitle
+	}
+	if i.Title != "" {
+		return i.Title
+	}
+	return i.URL
+}
+
+func (i *item) setArticle(a extract.Article) {
+	i.Title = a.Title
+	i.Excerpt = a.Excerpt
+	i.SiteName = a.SiteName
+	i.Byline = a.Byline
+	i.Lang = a.Lang
+	i.TopImageURL = a.TopImageURL
+	i.WordCount = a.WordCount
+	i.DatePublished = a.DatePublished
+	i.ExtractError = ""
+}
+
+type LocalConn struct {
+	db *bolt.DB
+	// Downloads and extracts articles, replaceable for tests.
+	fetch func(ctx context.Context, url string) (extract.Article, error)
+}
+
+// NewLocalConn opens (or creates) the database at dbPath.
+func NewLocalConn(dbPath string) (*LocalConn, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %s: %w", dbPath, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{itemsBucket, urlsBucket, articlesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	conn := &LocalConn{db: db}
+	conn.SetHTTPClient(httpclient.New(httpclient.DefaultOptions()))
+	return conn, nil
+}
+
+// SetHTTPClient replaces the client used to download articles.
+func (conn *LocalConn) SetHTTPClient(client *httpclient.Client) {
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		return extract.Fetch(ctx, client, url)
+	}
+}
+
+func (conn *LocalConn) Close() error {
+	return conn.db.Close()
+}
+
+func getItem(tx *bolt.Tx, itemID string) (item, error) {
+	data := tx.Bucket(itemsBucket).Get([]byte(itemID))
+	if data == nil {
+		return item{}, errNotFound
+	}
+	var i item
+	if err := json.Unmarshal(data, &i); err != nil {
+		return item{}, err
+	}
+	return i, nil
+}
+
+func putItem(tx *bolt.Tx, i item) error {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(itemsBucket).Put([]byte(i.ID), data); err != nil {
+		return err
+	}
+	return tx.Bucket(urlsBucket).Put([]byte(i.URL), []byte(i.ID))
+}
+
+func putArticle(tx *bolt.Tx, itemID string, a extract.Article) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(articlesBucket).Put([]byte(itemID), data)
+}
+
+func getArticle(tx *bolt.Tx, itemID string) (extract.Article, bool, error) {
+	data := tx.Bucket(articlesBucket).Get([]byte(itemID))
+	if data == nil {
+		return extract.Article{}, false, nil
+	}
+	var a extract.Article
+	if err := json.Unmarshal(data, &a); err != nil {
+		return extract.Article{}, false, err
+	}
+	return a, true, nil
+}
+
+func findID(tx *bolt.Tx, url string) (string, bool) {
+	id := tx.Bucket(urlsBucket).Get([]byte(url))
+	return string(id), id != nil
+}
+
+func nextID(tx *bolt.Tx) (string, error) {
+	seq, err := tx.Bucket(itemsBucket).NextSequence()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(seq, 10), nil
+}
+
+// updateItem loads the item, applies the update function and saves it again,
+// all within one transaction.
+func (conn *LocalConn) updateItem(itemID string, update func(*item)) error {
+	return conn.db.Update(func(tx *bolt.Tx) error {
+		i, err := getItem(tx, itemID)
+		if err != nil {
+			return fmt.Errorf("unable to update item %s: %w", itemID, err)
+		}
+		update(&i)
+		i.Updated = time.Now()
+		return putItem(tx, i)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func (conn *LocalConn) AddTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) {
+		for _, t := range tags {
+			if !slices.Contains(i.Tags, t) {
+				i.Tags = append(i.Tags, t)
+			}
+		}
+	})
+}
+
+func (conn *LocalConn) RemoveTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) {
+		i.Tags = slices.DeleteFunc(i.Tags, func(t string) bool { return slices.Contains(tags, t) })
+	})
+}
+
+func (conn *LocalConn) ReplaceTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) {
+		i.Tags = nil
+		for _, t := range tags {
+			if !slices.Contains(i.Tags, t) {
+				i.Tags = append(i.Tags, t)
+			}
+		}
+	})
+}
+
+func (conn *LocalConn) ClearTags(ctx context.Context, itemID string, time time.Time) error {
+	return conn.updateItem(itemID, func(i *item) { i.Tags = nil })
+}
+
+func (conn *LocalConn) RenameTag(ctx context.Context, oldTag string, newTag string, time time.Time) error {
+	return conn.updateTagged(oldTag, func(i *item) {
+		i.Tags = slices.DeleteFunc(i.Tags, func(t string) bool { return t == oldTag })
+		if !slices.Contains(i.Tags, newTag) {
+			i.Tags = append(i.Tags, newTag)
+		}
+	})
+}
+
+func (conn *LocalConn) DeleteTag(ctx context.Context, tag string, time time.Time) error {
+	return conn.updateTagged(tag, func(i *item) {
+		i.Tags = slices.DeleteFunc(i.Tags, func(t string) bool { return t == tag })
+	})
+}
+
+// updateTagged applies the update function to every item with the given tag, all within one transaction.
+func (conn *LocalConn) updateTagged(tag string, update func(*item)) error {
+	return conn.db.Update(func(tx *bolt.Tx) error {
+		var tagged []item
+		err := tx.Bucket(itemsBucket).ForEach(func(k, v []byte) error {
+			var i item
+			if err := json.Unmarshal(v, &i); err != nil {
+				return err
+			}
+			if slices.Contains(i.Tags, tag) {
+				tagged = append(tagged, i)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Buckets can't be modified while iterating over them.
+		now := time.Now()
+		for _, i := range tagged {
+			update(&i)
+			i.Updated = now
+			if err := putItem(tx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
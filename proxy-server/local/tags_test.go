@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLocal_Tags(t *testing.T) {
+	conn := newTestConn(t)
+	for _, url := range []string{"https://a.com", "https://b.com"} {
+		if _, err := conn.Add(context.Background(), url, "", time.Time{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	steps := []struct {
+		name   string
+		action func() error
+		want   map[string][]string
+	}{
+		{
+			name: "Add",
+			action: func() error {
+				return conn.AddTags(context.Background(), "1", []string{"news", "go", "news"}, time.Time{})
+			},
+			want: map[string][]string{"1": {"news", "go"}},
+		},
+		{
+			name:   "Add Other",
+			action: func() error { return conn.AddTags(context.Background(), "2", []string{"news"}, time.Time{}) },
+			want:   map[string][]string{"1": {"news", "go"}, "2": {"news"}},
+		},
+		{
+			name:   "Remove",
+			action: func() error { return conn.RemoveTags(context.Background(), "1", []string{"go"}, time.Time{}) },
+			want:   map[string][]string{"1": {"news"}, "2": {"news"}},
+		},
+		{
+			name:   "Rename",
+			action: func() error { return conn.RenameTag(context.Background(), "news", "world", time.Time{}) },
+			want:   map[string][]string{"1": {"world"}, "2": {"world"}},
+		},
+		{
+			name:   "Replace",
+			action: func() error { return conn.ReplaceTags(context.Background(), "2", []string{"go", "world"}, time.Time{}) },
+			want:   map[string][]string{"1": {"world"}, "2": {"go", "world"}},
+		},
+		{
+			name:   "Delete",
+			action: func() error { return conn.DeleteTag(context.Background(), "world", time.Time{}) },
+			want:   map[string][]string{"2": {"go"}},
+		},
+		{
+			name:   "Clear",
+			action: func() error { return conn.ClearTags(context.Background(), "2", time.Time{}) },
+			want:   map[string][]string{},
+		},
+	}
+	for _, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		res, err := conn.Get(context.Background(), pocketapi.GetRequest{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got := map[string][]string{}
+		for id, item := range res.List {
+			for _, tag := range item.Tags {
+				got[id] = append(got[id], tag.Tag)
+			}
+		}
+		// Tags are a map in the response, so their order is lost.
+		if diff := cmp.Diff(step.want, got, cmpopts.SortSlices(strings.Compare)); diff != "" {
+			t.Errorf("%s: tags mismatch (-want +got):\n%s", step.name, diff)
+		}
+	}
+
+	if err := conn.AddTags(context.Background(), "3", []string{"news"}, time.Time{}); err == nil {
+		t.Error("Wanted error tagging a missing item, got nil instead")
+	}
+}
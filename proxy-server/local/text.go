@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"proxyserver/extract"
+	"proxyserver/pocketapi"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func (conn *LocalConn) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	var i item
+	var article extract.Article
+	var stored bool
+	err := conn.db.View(func(tx *bolt.Tx) error {
+		id, exists := findID(tx, url)
+		if !exists {
+			return fmt.Errorf("unable to find an item for URL %s", url)
+		}
+		var err error
+		if i, err = getItem(tx, id); err != nil {
+			return err
+		}
+		article, stored, err = getArticle(tx, id)
+		return err
+	})
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+
+	if !stored {
+		// Extraction failed when the item was added, so try again.
+		if article, err = conn.fetch(ctx, url); err != nil {
+			return pocketapi.ArticleTextResponse{}, fmt.Errorf("error extracting article: %v", err)
+		}
+		err = conn.db.Update(func(tx *bolt.Tx) error {
+			// The item may have been changed or deleted while fetching, so
+			// only update the article fields of the latest copy.
+			latest, err := getItem(tx, i.ID)
+			if err != nil {
+				return err
+			}
+			if err := putArticle(tx, latest.ID, article); err != nil {
+				return err
+			}
+			latest.setArticle(article)
+			latest.Updated = time.Now()
+			i = latest
+			return putItem(tx, i)
+		})
+		if err != nil {
+			return pocketapi.ArticleTextResponse{}, err
+		}
+	}
+
+	res := pocketapi.ArticleTextResponse{}
+	if err := article.ToArticleText(i.ID, &res); err != nil {
+		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error parsing article HTML: %v", err)
+	}
+	res.Title = i.title()
+	res.DateResolved = i.Added.Format(time.RFC3339)
+	return res, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"proxyserver/extract"
+	"proxyserver/pocketapi"
+	"testing"
+	"time"
+)
+
+func TestLocal_ArticleText(t *testing.T) {
+	conn := newTestConn(t)
+	if _, err := conn.Add(context.Background(), "https://a.com", "", time.Unix(1751296089, 0)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	article, err := conn.ArticleText(context.Background(), "https://a.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if article.ItemID != "1" || article.Title != "Title of https://a.com" {
+		t.Errorf("Unexpected article metadata: %+v", article)
+	}
+	if article.Article != "<div><p>Some text</p><!--IMG_1--></div>" {
+		t.Errorf("Unexpected article HTML: %s", article.Article)
+	}
+	if article.Images["1"].Src != "https://img.com/1.png" {
+		t.Errorf("Unexpected images: %v", article.Images)
+	}
+}
+
+func TestLocal_ArticleTextRetriesExtraction(t *testing.T) {
+	conn := newTestConn(t)
+	// The fake extractor always fails for this URL.
+	if _, err := conn.Add(context.Background(), "https://fail.com", "Given Title", time.Time{}); err != nil {
+		t.Fatalf("Adding should succeed even if extraction fails, got %v", err)
+	}
+	if _, err := conn.ArticleText(context.Background(), "https://fail.com"); err == nil {
+		t.Fatal("Wanted extraction error, got nil instead")
+	}
+
+	// Once the site is back up, the article should be extracted and stored.
+	calls := 0
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		calls++
+		return extract.Article{URL: url, Title: "Extracted", Content: "<p>Back up</p>"}, nil
+	}
+	for range 2 {
+		article, err := conn.ArticleText(context.Background(), "https://fail.com")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if article.Title != "Given Title" || article.Article != "<div><p>Back up</p></div>" {
+			t.Errorf("Unexpected article: %+v", article)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the article to be extracted once, got %d", calls)
+	}
+}
+
+func TestLocal_ArticleTextKeepsConcurrentChanges(t *testing.T) {
+	conn := newTestConn(t)
+	if _, err := conn.Add(context.Background(), "https://fail.com", "", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Archive the item while its article is being fetched.
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		if err := conn.Archive(ctx, "1", time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return extract.Article{URL: url, Content: "<p>Back up</p>"}, nil
+	}
+	if _, err := conn.ArticleText(context.Background(), "https://fail.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res, err := conn.Get(context.Background(), pocketapi.GetRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.List["1"].Status != "1" {
+		t.Errorf("Expected the item to stay archived, got %+v", res.List["1"])
+	}
+
+	// Delete another while fetching, which shouldn't bring it back.
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		return extract.Article{}, errors.New("fetch failed")
+	}
+	if _, err := conn.Add(context.Background(), "https://fail.com/2", "", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		if err := conn.Delete(ctx, "2", time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return extract.Article{URL: url, Content: "<p>Back up</p>"}, nil
+	}
+	if _, err := conn.ArticleText(context.Background(), "https://fail.com/2"); err == nil {
+		t.Error("Wanted an error for the deleted item, got nil instead")
+	}
+	res, err = conn.Get(context.Background(), pocketapi.GetRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, exists := res.List["2"]; exists || len(res.List) != 1 {
+		t.Errorf("Expected the deleted item to stay deleted, got %v", res.List)
+	}
+}
+
+func TestLocal_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	conn, err := NewLocalConn(path)
+	if err != nil {
+		t.Fatalf("Unable to open database: %v", err)
+	}
+	conn.fetch = func(ctx context.Context, url string) (extract.Article, error) {
+		return extract.Article{URL: url, Content: "<p>Saved</p>"}, nil
+	}
+	if _, err := conn.Add(context.Background(), "https://a.com", "", time.Time{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	conn.Close()
+
+	reopened, err := NewLocalConn(path)
+	if err != nil {
+		t.Fatalf("Unable to reopen database: %v", err)
+	}
+	defer reopened.Close()
+	article, err := reopened.ArticleText(context.Background(), "https://a.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if article.Article != "<div><p>Saved</p></div>" {
+		t.Errorf("Unexpected article HTML: %s", article.Article)
+	}
+}
@@ -15,25 +15,180 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"proxyserver/config"
+	"proxyserver/pocketapi"
 	"proxyserver/server"
+	"slices"
+	"strings"
+	"syscall"
 )
 
-var port = flag.Int("port", 8080, "HTTP port to listen on")
-var verbose = flag.Bool("verbost", true, "If true, dumps all request fields to stdout")
-var backendName = flag.String("backend", "readeck", "The name of the backend to forward API calls to")
-var backendEndpoint = flag.String("backend_endpoint", "", "The backend API endpoint")
-var backendBearerToken = flag.String("backend_bearer_token", "", "The backend API bearer token used for authentication")
+var configFile = flag.String("config", os.Getenv(config.EnvPrefix+"CONFIG"), "A TOML file to read settings from, with the same names as the flags. Reloaded on SIGHUP")
+var migrateTo = flag.String("to", "", "migrate: a TOML file with the settings of the backend to copy to, in the same format as --config")
+var checkpoint = flag.String("checkpoint", "", "import, migrate: the file to keep progress in, so an interrupted run carries on where it stopped. Defaults to one in --data_dir")
+var exportFormat = flag.String("format", "", "export: the format to write, csv, html (bookmarks) or json (including article text). Defaults to the file's extension, or csv")
+var bookState = flag.String("state", "unread", "epub: which items to put in the book, unread, archive or all")
+var bookTag = flag.String("tag", "", "epub: only put items with this tag in the book")
+var bookCount = flag.Int("count", 0, "epub: the most items to put in the book, oldest first, or 0 for all of them")
+var dryRun = flag.Bool("dry_run", false, "import, migrate: only report what would be copied, without changing the backend")
 
-type FlagOptions struct{}
-
-func (FlagOptions) Port() int                  { return *port }
-func (FlagOptions) Verbose() bool              { return *verbose }
-func (FlagOptions) BackendName() string        { return *backendName }
-func (FlagOptions) BackendEndpoint() string    { return *backendEndpoint }
-func (FlagOptions) BackendBearerToken() string { return *backendBearerToken }
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [command] [flags] [files]\n\n", os.Args[0])
+	fmt.Fprintf(out, "Commands:\n")
+	fmt.Fprintf(out, "  serve         Run the proxy (the default)\n")
+	fmt.Fprintf(out, "  import FILE   Import Pocket export files (CSV or HTML) into the backend\n")
+	fmt.Fprintf(out, "  migrate       Copy every item from the backend to the one in --to\n")
+	fmt.Fprintf(out, "  export [FILE] Write every item in the backend to FILE, or to stdout\n")
+	fmt.Fprintf(out, "  epub [FILE]   Make an EPUB of the unread items (or --state, --tag) in FILE, or on stdout\n")
+	fmt.Fprintf(out, "  digest        Make a digest of the unread items added since the last one now, rather than on --digest_schedule\n\n")
+	fmt.Fprintf(out, "Flags:\n")
+	flag.PrintDefaults()
+}
 
 func main() {
-	flag.Parse()
-	server.StartServing(FlagOptions{})
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	defaults := config.Defaults()
+	defaults.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.CommandLine.Parse(args)
+
+	options, err := config.NewOptions(config.Loader{Path: *configFile, Flags: flag.CommandLine})
+	if err != nil {
+		log.Fatalf("Unable to load settings: %v", err)
+	}
+
+	switch command {
+	case "serve":
+		options.ReloadOnSignal(syscall.SIGHUP)
+		server.StartServing(options)
+	case "import":
+		if err := runImport(options, flag.Args()); err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+	case "migrate":
+		if err := runMigrate(options); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "export":
+		if err := runExport(options, flag.Args()); err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+	case "epub":
+		if err := runEpub(options, flag.Args()); err != nil {
+			log.Fatalf("Unable to make the book: %v", err)
+		}
+	case "digest":
+		if err := runDigest(options); err != nil {
+			log.Fatalf("Unable to make a digest: %v", err)
+		}
+	default:
+		fmt.Fprintf(flag.CommandLine.Output(), "Unknown command %q\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+}
+
+func runImport(options server.Options, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no export files given, usage: %s import [flags] FILE...", os.Args[0])
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	return server.Import(ctx, options, files, importOptions(), os.Stdout)
+}
+
+func runMigrate(from server.Options) error {
+	if *migrateTo == "" {
+		return fmt.Errorf("no destination given, usage: %s migrate [flags] --to=FILE", os.Args[0])
+	}
+	// Only the file, so the source's environment variables don't apply to the destination.
+	to, err := config.NewOptions(config.Loader{Path: *migrateTo, LookupEnv: func(string) (string, bool) { return "", false }})
+	if err != nil {
+		return fmt.Errorf("unable to load destination settings: %w", err)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	return server.Migrate(ctx, from, to, importOptions(), os.Stdout)
+}
+
+func runExport(options server.Options, args []string) (err error) {
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments, usage: %s export [flags] [FILE]", os.Args[0])
+	}
+	format := *exportFormat
+	if format == "" && len(args) == 1 {
+		format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+	}
+	if format == "" {
+		format = "csv"
+	}
+	if !slices.Contains(pocketapi.ExportFormats, format) {
+		return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(pocketapi.ExportFormats, ", "))
+	}
+
+	out := io.Writer(os.Stdout)
+	if len(args) == 1 {
+		file, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = file
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	return server.Export(ctx, options, format, out)
+}
+
+func runEpub(options server.Options, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments, usage: %s epub [flags] [FILE]", os.Args[0])
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	// The book is only written out once it's finished, so a failure doesn't leave half a file behind.
+	var book bytes.Buffer
+	selection := server.BookSelection{State: *bookState, Tag: *bookTag, Count: *bookCount}
+	if err := server.Book(ctx, options, selection, &book); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		_, err := os.Stdout.Write(book.Bytes())
+		return err
+	}
+	return os.WriteFile(args[0], book.Bytes(), 0o644)
+}
+
+func runDigest(options server.Options) error {
+	ctx, stop := interruptContext()
+	defer stop()
+	return server.Digest(ctx, options)
+}
+
+func importOptions() server.ImportOptions {
+	return server.ImportOptions{Checkpoint: *checkpoint, DryRun: *dryRun}
+}
+
+// interruptContext is cancelled on Ctrl-C. Stopping part way through an import
+// is fine, the next run carries on.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 }
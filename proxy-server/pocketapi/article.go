@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/net/html"
+)
+
+// ParseArticleText parses the HTML body of an article into the format Pocket clients expect.
+// The <body> is replaced with a root <div>, and every <img> is replaced with an HTML comment
+// of the form <!--IMG_n-->, where n is the key of the image in article.Images.
+func ParseArticleText(articleText io.Reader, article *ArticleTextResponse) error {
+	doc, err := html.Parse(articleText)
+	if err != nil {
+		return err
+	}
+
+	// We need to separate the <img> tags and replace them with HTML comments
+	// of the form <!--IMG_n-->, since that what Pocket clients expect.
+	article.Images = make(map[string]Image)
+
+	var root *html.Node
+	for n := range doc.Descendants() {
+		if n.Type == html.ElementNode {
+			if n.Data == "body" {
+				root = n
+				// Replace the body with a root <div>, since the existing Pocket API
+				// doesn't include a <body> tag.
+				root.Data = "div"
+			}
+
+			if n.Data == "img" {
+				pImg := Image{}
+				for _, a := range n.Attr {
+					if a.Key == "src" {
+						pImg.Src = a.Val
+					}
+					if a.Key == "height" {
+						pImg.Height = a.Val
+					}
+					if a.Key == "width" {
+						pImg.Width = a.Val
+					}
+				}
+				if pImg.Src == "" {
+					// No image URL available, skip
+					continue
+				}
+				// Save the URL
+				pImg.ImageID = strconv.Itoa(len(article.Images) + 1)
+				pImg.ItemID = article.ItemID
+				article.Images[pImg.ImageID] = pImg
+
+				// Replace the tag with a comment
+				n.Type = html.CommentNode
+				n.Data = fmt.Sprintf("IMG_%s", pImg.ImageID)
+				n.Attr = nil
+			}
+		}
+	}
+
+	if root == nil {
+		return errors.New("unable to parse HTML")
+	}
+
+	var buf bytes.Buffer
+	w := io.Writer(&buf)
+	html.Render(w, root)
+	article.Article = buf.String()
+	article.ContentLength = strconv.Itoa(len(article.Article))
+
+	return nil
+}
+
+var imageCommentPattern = regexp.MustCompile(`<!--IMG_([^>]*?)-->`)
+
+// RenderArticleImages returns the HTML of the article with its <!--IMG_n--> comments
+// turned back into <img> tags, so it can be read without a Pocket client.
+func RenderArticleImages(article ArticleTextResponse) string {
+	return imageCommentPattern.ReplaceAllStringFunc(article.Article, func(comment string) string {
+		img, exists := article.Images[imageCommentPattern.FindStringSubmatch(comment)[1]]
+		if !exists {
+			return ""
+		}
+		tag := fmt.Sprintf(`<img src="%s"`, html.EscapeString(img.Src))
+		if img.Width != "" {
+			tag += fmt.Sprintf(` width="%s"`, html.EscapeString(img.Width))
+		}
+		if img.Height != "" {
+			tag += fmt.Sprintf(` height="%s"`, html.EscapeString(img.Height))
+		}
+		return tag + "/>"
+	})
+}
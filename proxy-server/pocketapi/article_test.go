@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseArticleText(t *testing.T) {
+	testCases := []struct {
+		name   string
+		text   string
+		itemID string
+		want   ArticleTextResponse
+	}{
+		{
+			name:   "Empty",
+			itemID: "item123",
+			text:   "",
+			want: ArticleTextResponse{
+				ItemID:  "item123",
+				Article: "<div></div>",
+				Images:  map[string]Image{},
+			},
+		},
+		{
+			name:   "Basic",
+			itemID: "item123",
+			text:   "<div><img src=\"http://test.com/img.png\" /></div>",
+			want: ArticleTextResponse{
+				ItemID:  "item123",
+				Article: "<div><div><!--IMG_1--></div></div>",
+				Images: map[string]Image{
+					"1": {
+						ItemID:  "item123",
+						ImageID: "1",
+						Src:     "http://test.com/img.png",
+					},
+				},
+			},
+		},
+		{
+			name:   "Malformed",
+			itemID: "item123",
+			text:   "<div><img src=\"http://test.com/img.png\" />",
+			want: ArticleTextResponse{
+				ItemID:  "item123",
+				Article: "<div><div><!--IMG_1--></div></div>",
+				Images: map[string]Image{
+					"1": {
+						ItemID:  "item123",
+						ImageID: "1",
+						Src:     "http://test.com/img.png",
+					},
+				},
+			},
+		},
+		{
+			name:   "Multiple Elements",
+			itemID: "item123",
+			text:   "<div>test</div><div><img src=\"http://test.com/img.png\" /></div>",
+			want: ArticleTextResponse{
+				ItemID:  "item123",
+				Article: "<div><div>test</div><div><!--IMG_1--></div></div>",
+				Images: map[string]Image{
+					"1": {
+						ItemID:  "item123",
+						ImageID: "1",
+						Src:     "http://test.com/img.png",
+					},
+				},
+			},
+		},
+		{
+			name:   "Multiple Images",
+			itemID: "item123",
+			text: `
+			<div>test</div>
+			<p><img src="http://test.com/img.png" /></p>
+			<div><figure><img src="http://test.com/img2.png" height="100" width="200" /></figure></div>
+			`,
+			want: ArticleTextResponse{
+				ItemID: "item123",
+				Article: `<div><div>test</div>
+			<p><!--IMG_1--></p>
+			<div><figure><!--IMG_2--></figure></div>
+			</div>`,
+				Images: map[string]Image{
+					"1": {
+						ItemID:  "item123",
+						ImageID: "1",
+						Src:     "http://test.com/img.png",
+					},
+					"2": {
+						ItemID:  "item123",
+						ImageID: "2",
+						Src:     "http://test.com/img2.png",
+						Height:  "100",
+						Width:   "200",
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ArticleTextResponse{
+				ItemID: tc.itemID,
+			}
+			if err := ParseArticleText(io.NopCloser(strings.NewReader(tc.text)), &got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tc.want.ContentLength = strconv.Itoa(len(tc.want.Article))
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseArticleText mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderArticleImages(t *testing.T) {
+	article := ArticleTextResponse{
+		Article: `<div><p>Before</p><!--IMG_1--><!--IMG_2--><!--IMG_3--></div>`,
+		Images: map[string]Image{
+			"1": {ImageID: "1", Src: "https://a.com/a.png?x=1&y=2"},
+			"2": {ImageID: "2", Src: "https://a.com/b.png", Width: "100", Height: "50"},
+		},
+	}
+	want := `<div><p>Before</p><img src="https://a.com/a.png?x=1&amp;y=2"/><img src="https://a.com/b.png" width="100" height="50"/></div>`
+	if got := RenderArticleImages(article); got != want {
+		t.Errorf("RenderArticleImages() = %q, want %q", got, want)
+	}
+}
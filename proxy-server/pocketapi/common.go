@@ -14,6 +14,11 @@
 
 package pocketapi
 
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
 type DomainMetadata struct {
 	Name          string `json:"name,omitempty"`
 	Logo          string `json:"logo,omitempty"`
@@ -30,9 +35,45 @@ type Image struct {
 	Caption string `json:"caption,omitempty"`
 }
 
+// The kinds of videos Pocket distinguishes between.
+const (
+	VideoTypeYouTube = "1"
+	VideoTypeVimeo   = "2"
+	VideoTypeHTML5   = "4"
+)
+
+type Video struct {
+	ItemID  string `json:"item_id"`
+	VideoID string `json:"video_id"`
+	Src     string `json:"src"`
+	Width   string `json:"width,omitempty"`
+	Height  string `json:"height,omitempty"`
+	// One of the VideoType constants.
+	Type string `json:"type"`
+	// The video's ID on the site hosting it, e.g. the YouTube ID.
+	Vid    string `json:"vid,omitempty"`
+	Length string `json:"length,omitempty"`
+}
+
 type Author struct {
 	AuthorID string `json:"author_id"`
 	Name     string `json:"name"`
 	URL      string `json:"url"`
 	ItemID   string `json:"item_id"`
 }
+
+// Digest returns a stable ID for val, used for the author and image IDs
+// backends derive from names and URLs.
+func Digest(val string) string {
+	h := sha1.New()
+	h.Write([]byte(val))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// OneIfTrue formats a boolean the way Pocket's API does.
+func OneIfTrue(val bool) string {
+	if val {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,381 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ExportItem is an item in a Pocket export file.
+type ExportItem struct {
+	URL       string
+	Title     string
+	TimeAdded time.Time
+	Tags      []string
+	Archived  bool
+	Favorite  bool
+	// The HTML text of the article, only kept in JSON exports.
+	Article string
+}
+
+// NewExportItem returns the parts of a listed item which are kept in an export.
+func NewExportItem(item GetResponseItem) ExportItem {
+	exported := ExportItem{
+		URL:       item.GivenURL,
+		Title:     item.GivenTitle,
+		TimeAdded: parseExportTime(item.TimeAdded),
+		Archived:  item.Status == "1",
+		Favorite:  item.Favorite == "1",
+	}
+	if exported.URL == "" {
+		exported.URL = item.ResolvedURL
+	}
+	if exported.Title == "" {
+		exported.Title = item.ResolvedTitle
+	}
+	for name := range item.Tags {
+		exported.Tags = append(exported.Tags, name)
+	}
+	slices.Sort(exported.Tags)
+	return exported
+}
+
+// ParseExport reads a Pocket export, either the CSV file Pocket exported from
+// 2024 on, or the older HTML file. Bookmark files and JSON archives written by
+// NewExportWriter are read too.
+func ParseExport(r io.Reader) ([]ExportItem, error) {
+	br := bufio.NewReader(r)
+	start, _ := br.Peek(512)
+	start = bytes.TrimSpace(start)
+	switch {
+	case bytes.HasPrefix(start, []byte("<")):
+		return parseExportHTML(br)
+	case bytes.HasPrefix(start, []byte("{")):
+		return parseExportJSON(br)
+	}
+	return parseExportCSV(br)
+}
+
+// parseExportCSV reads the CSV export, which has a header row naming the
+// columns: title, url, time_added, tags (separated by "|") and status ("unread"
+// or "archive"). A favorite column is also read if there is one.
+func parseExportCSV(r io.Reader) ([]ExportItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, exists := columns["url"]; !exists {
+		return nil, errors.New("CSV export has no url column")
+	}
+
+	var items []ExportItem
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CSV export: %w", err)
+		}
+		field := func(name string) string {
+			if i, exists := columns[name]; exists && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		item := ExportItem{
+			URL:       field("url"),
+			Title:     field("title"),
+			TimeAdded: parseExportTime(field("time_added")),
+			Archived:  isArchivedStatus(field("status")),
+		}
+		for _, tag := range strings.Split(field("tags"), "|") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				item.Tags = append(item.Tags, tag)
+			}
+		}
+		switch strings.ToLower(field("favorite")) {
+		case "1", "true", "yes":
+			item.Favorite = true
+		}
+		items = append(items, item)
+	}
+}
+
+// parseExportHTML reads the HTML export, which lists the items as links under
+// an "Unread" and a "Read Archive" heading, with their time added and comma
+// separated tags as attributes. Netscape bookmark files are laid out the same,
+// with ADD_DATE for the time added.
+func parseExportHTML(r io.Reader) ([]ExportItem, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse HTML export: %w", err)
+	}
+
+	var items []ExportItem
+	archived := false
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.Data {
+		case "h1", "h2", "h3":
+			archived = isArchivedStatus(nodeText(n))
+		case "a":
+			item := ExportItem{Title: strings.TrimSpace(nodeText(n)), Archived: archived}
+			for _, attr := range n.Attr {
+				switch attr.Key {
+				case "href":
+					item.URL = strings.TrimSpace(attr.Val)
+				case "time_added", "add_date":
+					item.TimeAdded = parseExportTime(attr.Val)
+				case "tags":
+					for _, tag := range strings.Split(attr.Val, ",") {
+						if tag = strings.TrimSpace(tag); tag != "" {
+							item.Tags = append(item.Tags, tag)
+						}
+					}
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func parseExportJSON(r io.Reader) ([]ExportItem, error) {
+	var archive exportJSON
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("unable to parse JSON export: %w", err)
+	}
+	items := make([]ExportItem, 0, len(archive.Items))
+	for _, item := range archive.Items {
+		exported := ExportItem{
+			URL:      item.URL,
+			Title:    item.Title,
+			Tags:     item.Tags,
+			Archived: item.Archived,
+			Favorite: item.Favorite,
+			Article:  item.Article,
+		}
+		if item.TimeAdded > 0 {
+			exported.TimeAdded = time.Unix(item.TimeAdded, 0)
+		}
+		items = append(items, exported)
+	}
+	return items, nil
+}
+
+func nodeText(n *html.Node) string {
+	var text strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			text.WriteString(d.Data)
+		}
+	}
+	return text.String()
+}
+
+func isArchivedStatus(status string) bool {
+	status = strings.ToLower(status)
+	return strings.Contains(status, "archive") || status == "read"
+}
+
+// parseExportTime parses a Unix time, returning the zero time if it's missing or invalid.
+func parseExportTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// ExportFormats are the formats NewExportWriter can write.
+var ExportFormats = []string{"csv", "html", "json"}
+
+// ExportWriter writes items to an export file one at a time, so the whole
+// list doesn't need to be kept in memory.
+type ExportWriter interface {
+	Write(item ExportItem) error
+	// Close finishes the file. It doesn't close the underlying writer.
+	Close() error
+}
+
+// NewExportWriter returns a writer for the format, one of ExportFormats:
+//   - csv: the columns of Pocket's CSV export, plus a favorite column.
+//   - html: a Netscape bookmark file, with unread and archived items in separate folders.
+//   - json: an archive which also keeps the text of articles.
+func NewExportWriter(w io.Writer, format string) (ExportWriter, error) {
+	switch format {
+	case "csv":
+		writer := &csvExportWriter{w: csv.NewWriter(w)}
+		writer.w.Write([]string{"title", "url", "time_added", "tags", "status", "favorite"})
+		return writer, nil
+	case "html":
+		writer := &htmlExportWriter{w: bufio.NewWriter(w)}
+		writer.w.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n" +
+			`<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">` + "\n" +
+			"<TITLE>Pocket Export</TITLE>\n<H1>Pocket Export</H1>\n<DL><p>\n")
+		return writer, nil
+	case "json":
+		writer := &jsonExportWriter{w: bufio.NewWriter(w)}
+		writer.w.WriteString(`{"items":[`)
+		return writer, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(ExportFormats, ", "))
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (writer *csvExportWriter) Write(item ExportItem) error {
+	status := "unread"
+	if item.Archived {
+		status = "archive"
+	}
+	favorite := "0"
+	if item.Favorite {
+		favorite = "1"
+	}
+	return writer.w.Write([]string{item.Title, item.URL, formatExportTime(item.TimeAdded), strings.Join(item.Tags, "|"), status, favorite})
+}
+
+func (writer *csvExportWriter) Close() error {
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+type htmlExportWriter struct {
+	w *bufio.Writer
+	// The heading of the folder being written, if one has been started.
+	folder string
+}
+
+func (writer *htmlExportWriter) Write(item ExportItem) error {
+	folder := "Unread"
+	if item.Archived {
+		folder = "Read Archive"
+	}
+	if folder != writer.folder {
+		if writer.folder != "" {
+			writer.w.WriteString("</DL><p>\n")
+		}
+		fmt.Fprintf(writer.w, "<DT><H3>%s</H3>\n<DL><p>\n", folder)
+		writer.folder = folder
+	}
+
+	writer.w.WriteString(`<DT><A HREF="` + html.EscapeString(item.URL) + `"`)
+	if !item.TimeAdded.IsZero() {
+		fmt.Fprintf(writer.w, ` ADD_DATE="%s"`, formatExportTime(item.TimeAdded))
+	}
+	if len(item.Tags) > 0 {
+		fmt.Fprintf(writer.w, ` TAGS="%s"`, html.EscapeString(strings.Join(item.Tags, ",")))
+	}
+	title := item.Title
+	if title == "" {
+		title = item.URL
+	}
+	_, err := writer.w.WriteString(">" + html.EscapeString(title) + "</A>\n")
+	return err
+}
+
+func (writer *htmlExportWriter) Close() error {
+	if writer.folder != "" {
+		writer.w.WriteString("</DL><p>\n")
+	}
+	writer.w.WriteString("</DL><p>\n")
+	return writer.w.Flush()
+}
+
+// exportJSON is the layout of a JSON export.
+type exportJSON struct {
+	Items []exportJSONItem `json:"items"`
+}
+
+type exportJSONItem struct {
+	URL       string   `json:"url"`
+	Title     string   `json:"title,omitempty"`
+	TimeAdded int64    `json:"time_added,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Archived  bool     `json:"archived,omitempty"`
+	Favorite  bool     `json:"favorite,omitempty"`
+	Article   string   `json:"article,omitempty"`
+}
+
+type jsonExportWriter struct {
+	w       *bufio.Writer
+	written int
+}
+
+func (writer *jsonExportWriter) Write(item ExportItem) error {
+	jsonItem := exportJSONItem{
+		URL:      item.URL,
+		Title:    item.Title,
+		Tags:     item.Tags,
+		Archived: item.Archived,
+		Favorite: item.Favorite,
+		Article:  item.Article,
+	}
+	if !item.TimeAdded.IsZero() {
+		jsonItem.TimeAdded = item.TimeAdded.Unix()
+	}
+	data, err := json.Marshal(&jsonItem)
+	if err != nil {
+		return err
+	}
+	if writer.written > 0 {
+		writer.w.WriteString(",")
+	}
+	writer.w.WriteString("\n")
+	writer.written++
+	_, err = writer.w.Write(data)
+	return err
+}
+
+func (writer *jsonExportWriter) Close() error {
+	writer.w.WriteString("\n]}\n")
+	return writer.w.Flush()
+}
+
+// formatExportTime formats a time as a Unix time, or "" if it's the zero time.
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
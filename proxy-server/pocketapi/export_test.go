@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseExport(t *testing.T) {
+	testCases := []struct {
+		name   string
+		export string
+		want   []ExportItem
+	}{
+		{
+			name: "CSV",
+			export: "\ufefftitle,url,time_added,tags,status\n" +
+				"First,https://a.com/1,1700000000,news|tech,unread\n" +
+				"\"Second, with a comma\",https://b.com/2,1700000100,,archive\n" +
+				"No time,https://c.com/3,,,unread\n",
+			want: []ExportItem{
+				{URL: "https://a.com/1", Title: "First", TimeAdded: time.Unix(1700000000, 0), Tags: []string{"news", "tech"}},
+				{URL: "https://b.com/2", Title: "Second, with a comma", TimeAdded: time.Unix(1700000100, 0), Archived: true},
+				{URL: "https://c.com/3", Title: "No time"},
+			},
+		},
+		{
+			name: "CSV with favorites",
+			export: "url,title,favorite,status\n" +
+				"https://a.com/1,First,1,archive\n" +
+				"https://a.com/2,Second,0,unread\n",
+			want: []ExportItem{
+				{URL: "https://a.com/1", Title: "First", Favorite: true, Archived: true},
+				{URL: "https://a.com/2", Title: "Second"},
+			},
+		},
+		{
+			name: "HTML",
+			export: `<!DOCTYPE html>
+<html><head><title>Pocket Export</title></head><body>
+<h1>Unread</h1>
+<ul>
+<li><a href="https://a.com/1" time_added="1700000000" tags="news,tech">First</a></li>
+</ul>
+<h1>Read Archive</h1>
+<ul>
+<li><a href="https://b.com/2" time_added="1700000100" tags="">Second</a></li>
+</ul>
+</body></html>`,
+			want: []ExportItem{
+				{URL: "https://a.com/1", Title: "First", TimeAdded: time.Unix(1700000000, 0), Tags: []string{"news", "tech"}},
+				{URL: "https://b.com/2", Title: "Second", TimeAdded: time.Unix(1700000100, 0), Archived: true},
+			},
+		},
+		{
+			name:   "Empty",
+			export: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseExport(strings.NewReader(tc.export))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Items mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseExport_NoURLColumn(t *testing.T) {
+	if _, err := ParseExport(strings.NewReader("title,added\nFirst,1700000000\n")); err == nil {
+		t.Error("Wanted an error for a CSV without a url column")
+	}
+}
+
+func TestNewExportItem(t *testing.T) {
+	item := GetResponseItem{
+		ItemID:        "1",
+		ResolvedURL:   "https://a.com/resolved",
+		ResolvedTitle: "Resolved",
+		TimeAdded:     "1700000000",
+		Status:        "1",
+		Favorite:      "1",
+		Tags:          NewTags("1", []string{"tech", "news"}),
+	}
+	want := ExportItem{
+		URL:       "https://a.com/resolved",
+		Title:     "Resolved",
+		TimeAdded: time.Unix(1700000000, 0),
+		Tags:      []string{"news", "tech"},
+		Archived:  true,
+		Favorite:  true,
+	}
+	if diff := cmp.Diff(want, NewExportItem(item)); diff != "" {
+		t.Errorf("Item mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportWriter_RoundTrip(t *testing.T) {
+	items := []ExportItem{
+		{URL: "https://a.com/1?a=1&b=2", Title: `First "quoted" <title>`, TimeAdded: time.Unix(1700000000, 0), Tags: []string{"news", "tech"}, Favorite: true},
+		{URL: "https://b.com/2", Title: "Second, with a comma"},
+		{URL: "https://c.com/3", Title: "Archived", TimeAdded: time.Unix(1700000100, 0), Archived: true, Article: "<div><p>Text</p></div>"},
+	}
+	for _, format := range ExportFormats {
+		t.Run(format, func(t *testing.T) {
+			var buf strings.Builder
+			writer, err := NewExportWriter(&buf, format)
+			if err != nil {
+				t.Fatalf("NewExportWriter() returned error: %v", err)
+			}
+			for _, item := range items {
+				if err := writer.Write(item); err != nil {
+					t.Fatalf("Write() returned error: %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() returned error: %v", err)
+			}
+
+			got, err := ParseExport(strings.NewReader(buf.String()))
+			if err != nil {
+				t.Fatalf("ParseExport() returned error: %v\n%s", err, buf.String())
+			}
+			want := slices.Clone(items)
+			for i := range want {
+				// Only JSON keeps articles, and bookmarks can't be favorites.
+				if format != "json" {
+					want[i].Article = ""
+				}
+				if format == "html" {
+					want[i].Favorite = false
+				}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Round trip mismatch (-want +got):\n%s\n%s", diff, buf.String())
+			}
+		})
+	}
+}
+
+func TestNewExportWriter_UnknownFormat(t *testing.T) {
+	if _, err := NewExportWriter(io.Discard, "xml"); err == nil {
+		t.Error("Wanted an error for an unknown format")
+	}
+}
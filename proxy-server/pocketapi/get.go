@@ -35,6 +35,12 @@ type GetRequest struct {
 	Offset *int `json:"offset"`
 	// Unix timestamp.
 	Since *int64 `json:"since"`
+	// Only return items with this tag, or UntaggedTag for items without any tags.
+	Tag string `json:"tag"`
+	// Only return items whose title or URL contains this.
+	Search string `json:"search"`
+	// Only return items from this domain.
+	Domain string `json:"domain"`
 }
 
 type GetResponseItem struct {
@@ -45,8 +51,7 @@ type GetResponseItem struct {
 	TimeAdded     string `json:"time_added"`
 	TimeUpdated   string `json:"time_updated"`
 	TimeFavorited string `json:"time_favorited"`
-	Tags          struct {
-	} `json:"tags"`
+	Tags          Tags   `json:"tags"`
 	TopImageURL   string `json:"top_image_url,omitempty"`
 	ResolvedID    string `json:"resolved_id"`
 	GivenURL      string `json:"given_url"`
@@ -66,6 +71,7 @@ type GetResponseItem struct {
 	DomainMetadata         *DomainMetadata   `json:"domain_metadata"`
 	Authors                map[string]Author `json:"authors"`
 	Images                 map[string]Image  `json:"images"`
+	Videos                 map[string]Video  `json:"videos,omitempty"`
 	Image                  *Image            `json:"image"`
 }
 
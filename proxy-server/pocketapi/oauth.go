@@ -0,0 +1,43 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+// Used by the OAuth flow, see https://getpocket.com/developer/docs/authentication.
+// Requests can be sent as JSON or as form data, and responses are sent as
+// JSON if the X-Accept header asks for it.
+
+type OAuthRequest struct {
+	ConsumerKey string `json:"consumer_key"`
+	RedirectURI string `json:"redirect_uri"`
+	// Passed back to the app unchanged.
+	State string `json:"state,omitempty"`
+}
+
+type OAuthRequestResponse struct {
+	// The request token, which the user authorizes by logging in.
+	Code  string `json:"code"`
+	State string `json:"state,omitempty"`
+}
+
+type OAuthAuthorizeRequest struct {
+	ConsumerKey string `json:"consumer_key"`
+	Code        string `json:"code"`
+}
+
+type OAuthAuthorizeResponse struct {
+	AccessToken string `json:"access_token"`
+	Username    string `json:"username"`
+	State       string `json:"state,omitempty"`
+}
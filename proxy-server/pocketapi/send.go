@@ -20,6 +20,13 @@ type SendAction struct {
 	ItemID string `json:"item_id"`
 	Time   int    `json:"time"`
 	URL    string `json:"url"`
+	// Used by tags_add, tags_remove and tags_replace.
+	Tags TagList `json:"tags,omitempty"`
+	// Used by tag_rename.
+	OldTag string `json:"old_tag,omitempty"`
+	NewTag string `json:"new_tag,omitempty"`
+	// Used by tag_delete.
+	Tag string `json:"tag,omitempty"`
 }
 
 type SendRequest struct {
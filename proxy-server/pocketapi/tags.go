@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// UntaggedTag is the special GetRequest tag used to request items without any tags.
+const UntaggedTag = "_untagged_"
+
+type Tag struct {
+	ItemID string `json:"item_id"`
+	Tag    string `json:"tag"`
+}
+
+// Tags maps tag names to tags. It's always serialized as an object, since
+// Pocket returns {} rather than null for untagged items.
+type Tags map[string]Tag
+
+func (t Tags) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]Tag(t))
+}
+
+// NewTags creates the tags map for the item from a list of tag names.
+func NewTags(itemID string, names []string) Tags {
+	if len(names) == 0 {
+		return nil
+	}
+	tags := make(Tags, len(names))
+	for _, n := range names {
+		tags[n] = Tag{ItemID: itemID, Tag: n}
+	}
+	return tags
+}
+
+// TagList is a list of tag names. Pocket sends these as a comma separated
+// string, but some clients send a JSON array instead, so accept both.
+type TagList []string
+
+func (t *TagList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = cleanTags(list)
+		return nil
+	}
+	var joined string
+	if err := json.Unmarshal(data, &joined); err != nil {
+		return err
+	}
+	*t = cleanTags(strings.Split(joined, ","))
+	return nil
+}
+
+func cleanTags(tags []string) TagList {
+	cleaned := TagList{}
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTagList_Unmarshal(t *testing.T) {
+	testCases := []struct {
+		name string
+		json string
+		want TagList
+	}{
+		{name: "Comma Separated", json: `{"tags": "a, b,c"}`, want: TagList{"a", "b", "c"}},
+		{name: "Array", json: `{"tags": ["a", " b "]}`, want: TagList{"a", "b"}},
+		{name: "Empty String", json: `{"tags": ""}`, want: TagList{}},
+		{name: "Missing", json: `{}`, want: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got SendAction
+			if err := json.Unmarshal([]byte(tc.json), &got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got.Tags); diff != "" {
+				t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTags_Marshal(t *testing.T) {
+	testCases := []struct {
+		name string
+		tags Tags
+		want string
+	}{
+		{name: "Nil", tags: nil, want: `{}`},
+		{name: "One Tag", tags: NewTags("id1", []string{"news"}), want: `{"news":{"item_id":"id1","tag":"news"}}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := json.Marshal(tc.tags)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Unexpected JSON, want %s got %s", tc.want, got)
+			}
+		})
+	}
+}
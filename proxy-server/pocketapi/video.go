@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParseVideos finds the videos embedded in an article's HTML, like the
+// Article field of ArticleTextResponse. Only YouTube and Vimeo embeds and HTML5
+// <video> tags count, since other iframes are usually ads or widgets.
+func ParseVideos(articleHTML string, itemID string) map[string]Video {
+	nodes, err := html.ParseFragment(strings.NewReader(articleHTML), nil)
+	if err != nil {
+		return nil
+	}
+
+	var videos map[string]Video
+	for _, root := range nodes {
+		for n := range root.Descendants() {
+			if n.Type != html.ElementNode || (n.Data != "iframe" && n.Data != "video") {
+				continue
+			}
+			video, found := parseVideoNode(n)
+			if !found {
+				continue
+			}
+			if videos == nil {
+				videos = make(map[string]Video)
+			}
+			video.VideoID = strconv.Itoa(len(videos) + 1)
+			video.ItemID = itemID
+			videos[video.VideoID] = video
+		}
+	}
+	return videos
+}
+
+func parseVideoNode(n *html.Node) (Video, bool) {
+	var video Video
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "src":
+			video.Src = a.Val
+		case "width":
+			video.Width = a.Val
+		case "height":
+			video.Height = a.Val
+		}
+	}
+
+	if n.Data == "video" {
+		// The source can also be in a child <source> tag.
+		if video.Src == "" {
+			for c := range n.ChildNodes() {
+				if c.Type == html.ElementNode && c.Data == "source" {
+					for _, a := range c.Attr {
+						if a.Key == "src" && video.Src == "" {
+							video.Src = a.Val
+						}
+					}
+				}
+			}
+		}
+		video.Type = VideoTypeHTML5
+		return video, video.Src != ""
+	}
+	return parseEmbed(video)
+}
+
+// IsVideoEmbed reports whether src is the URL of an iframe which ParseVideos
+// counts as a video.
+func IsVideoEmbed(src string) bool {
+	_, found := parseEmbed(Video{Src: src})
+	return found
+}
+
+// parseEmbed fills in the type and ID of a YouTube or Vimeo embed.
+func parseEmbed(video Video) (Video, bool) {
+	u, err := url.Parse(video.Src)
+	if err != nil {
+		return Video{}, false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	path := strings.Trim(u.Path, "/")
+	switch {
+	case host == "youtube.com" || host == "youtube-nocookie.com":
+		// e.g. https://www.youtube.com/embed/<id>
+		if id, found := strings.CutPrefix(path, "embed/"); found && id != "" {
+			video.Type = VideoTypeYouTube
+			video.Vid = id
+			return video, true
+		}
+	case host == "player.vimeo.com":
+		// e.g. https://player.vimeo.com/video/<id>
+		if id, found := strings.CutPrefix(path, "video/"); found && id != "" {
+			video.Type = VideoTypeVimeo
+			video.Vid = id
+			return video, true
+		}
+	}
+	return Video{}, false
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pocketapi
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVideos(t *testing.T) {
+	testCases := []struct {
+		name string
+		html string
+		want map[string]Video
+	}{
+		{
+			name: "None",
+			html: `<div><p>Text</p><iframe src="https://ads.com/banner"></iframe></div>`,
+		},
+		{
+			name: "YouTube",
+			html: `<div><iframe src="https://www.youtube.com/embed/abc123?rel=0" width="560" height="315"></iframe></div>`,
+			want: map[string]Video{
+				"1": {ItemID: "item1", VideoID: "1", Src: "https://www.youtube.com/embed/abc123?rel=0", Width: "560", Height: "315", Type: VideoTypeYouTube, Vid: "abc123"},
+			},
+		},
+		{
+			name: "Vimeo & HTML5",
+			html: `<div><iframe src="https://player.vimeo.com/video/42"></iframe><video><source src="https://test.com/clip.mp4"></video></div>`,
+			want: map[string]Video{
+				"1": {ItemID: "item1", VideoID: "1", Src: "https://player.vimeo.com/video/42", Type: VideoTypeVimeo, Vid: "42"},
+				"2": {ItemID: "item1", VideoID: "2", Src: "https://test.com/clip.mp4", Type: VideoTypeHTML5},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ParseVideos(tc.html, "item1")); diff != "" {
+				t.Errorf("Videos mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
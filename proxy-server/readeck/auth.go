@@ -16,21 +16,25 @@ package readeck
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 )
 
-func GetAuthToken(baseUrl string, appName, username, password string) (string, error) {
+func GetAuthToken(ctx context.Context, baseUrl string, appName, username, password string) (string, error) {
 	url := fmt.Sprintf("%s/api/auth", baseUrl)
-	payload := []byte(fmt.Sprintf(`{
-		"application": "%s",
-		"username": "%s",
-		"password": "%s"
-	}`, appName, username, password))
+	payload, err := json.Marshal(map[string]string{
+		"application": appName,
+		"username":    username,
+		"password":    password,
+	})
+	if err != nil {
+		return "", err
+	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
 	if err != nil {
 		return "", err
 	}
@@ -42,6 +46,7 @@ func GetAuthToken(baseUrl string, appName, username, password string) (string, e
 	if err != nil {
 		return "", err
 	}
+	defer res.Body.Close()
 	if err := checkResponseCode(res); err != nil {
 		return "", err
 	}
@@ -15,41 +15,76 @@
 package readeck
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"proxyserver/httpclient"
+	"sync"
+	"time"
 )
 
 type ReadeckConn struct {
 	endpoint    string
 	bearerToken string
+	client      *httpclient.Client
 
-	// A mapping article URLs to Readeck IDs.
-	// Pocket just needs a URL to get article text, but Readeck requires an item ID,
-	// so the solution here is to cache URLs and IDs in memory when Get() is called.
-	//
-	// It's not ideal, but the Kobo will usually list articles before downloading, and
-	// so long as the proxy server isn't restarted in between it'll still have the ID.
-	//
-	// If this is insufficient, it should be easy to add code to automatically refresh
-	// the cache on startup.
-	urlIDCache map[string]string
+	// A mapping of article URLs to Readeck IDs, see urlIndex.
+	index *urlIndex
+
+	// When DeletedSince last listed every bookmark, see deletionScanInterval.
+	scanMu       sync.Mutex
+	lastFullScan time.Time
 }
 
+// NewReadeckConn creates a connection which only keeps its URL index in memory.
 func NewReadeckConn(endpoint string, bearerToken string) *ReadeckConn {
+	index, _ := newURLIndex("")
+	return &ReadeckConn{
+		endpoint:    endpoint,
+		bearerToken: bearerToken,
+		client:      httpclient.New(httpclient.DefaultOptions()),
+		index:       index,
+	}
+}
+
+// NewReadeckConnWithIndex creates a connection which persists its URL index to indexPath,
+// loading any existing index from there.
+func NewReadeckConnWithIndex(endpoint string, bearerToken string, indexPath string) (*ReadeckConn, error) {
+	index, err := newURLIndex(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load URL index from %s: %w", indexPath, err)
+	}
 	return &ReadeckConn{
 		endpoint:    endpoint,
 		bearerToken: bearerToken,
-		urlIDCache:  make(map[string]string),
+		client:      httpclient.New(httpclient.DefaultOptions()),
+		index:       index,
+	}, nil
+}
+
+// SetHTTPClient replaces the client used to call the Readeck API.
+func (conn *ReadeckConn) SetHTTPClient(client *httpclient.Client) {
+	conn.client = client
+}
+
+// indexItems adds the bookmarks to the URL index. Failing to persist the index
+// isn't fatal, since it's still updated in memory.
+func (conn *ReadeckConn) indexItems(items []getResponseItem) {
+	ids := make(map[string]string, len(items))
+	for _, item := range items {
+		ids[item.URL] = item.ID
+	}
+	if err := conn.index.setAll(ids); err != nil {
+		log.Printf("Unable to save Readeck URL index: %v", err)
 	}
 }
 
-func (conn *ReadeckConn) createRequest(method, action string, body io.Reader) (*http.Request, error) {
+func (conn *ReadeckConn) createRequest(ctx context.Context, method, action string, body io.Reader) (*http.Request, error) {
 	apiUrl := fmt.Sprintf("%s/api/%s", conn.endpoint, action)
-	deckReq, err := http.NewRequest(method, apiUrl, body)
+	deckReq, err := http.NewRequestWithContext(ctx, method, apiUrl, body)
 	if err != nil {
 		return nil, err
 	}
@@ -57,24 +92,18 @@ func (conn *ReadeckConn) createRequest(method, action string, body io.Reader) (*
 	return deckReq, nil
 }
 
-func digest(val string) string {
-	h := sha1.New()
-	h.Write([]byte(val))
-	return hex.EncodeToString(h.Sum(nil))
-}
-
 type errorBody struct {
 	Message string
 	Status  int
 }
 
 func checkResponseCode(deckRes *http.Response) error {
-	if deckRes.StatusCode >= 200 || deckRes.StatusCode <= 299 {
+	if deckRes.StatusCode >= 200 && deckRes.StatusCode <= 299 {
 		return nil
 	}
 	var body errorBody
 	if err := json.NewDecoder(deckRes.Body).Decode(&body); err != nil {
-		return fmt.Errorf("error calling Readeck API: [%d] %s", deckRes.StatusCode, deckRes.Status)
+		return httpclient.ResponseError(deckRes, fmt.Errorf("error calling Readeck API: [%d] %s", deckRes.StatusCode, deckRes.Status))
 	}
-	return fmt.Errorf("error calling Readeck API: [%d] %s, More details: [%d] %s", deckRes.StatusCode, deckRes.Status, body.Status, body.Message)
+	return httpclient.ResponseError(deckRes, fmt.Errorf("error calling Readeck API: [%d] %s, More details: [%d] %s", deckRes.StatusCode, deckRes.Status, body.Status, body.Message))
 }
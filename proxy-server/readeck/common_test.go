@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckResponseCode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "OK", status: http.StatusOK},
+		{name: "Created", status: http.StatusCreated},
+		{name: "No content", status: http.StatusNoContent},
+		{name: "Not found", status: http.StatusNotFound, body: `{"status": 404, "message": "Not found"}`, wantErr: "Not found"},
+		{name: "Unauthorized", status: http.StatusUnauthorized, body: `{"status": 401, "message": "Invalid token"}`, wantErr: "Invalid token"},
+		{name: "Server error without details", status: http.StatusInternalServerError, body: "oops", wantErr: "[500]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &http.Response{
+				StatusCode: tc.status,
+				Status:     http.StatusText(tc.status),
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+			}
+			err := checkResponseCode(res)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkResponseCode() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("checkResponseCode() = %v, wanted an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
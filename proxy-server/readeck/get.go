@@ -15,8 +15,11 @@
 package readeck
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"proxyserver/pocketapi"
@@ -58,6 +61,22 @@ func buildGetQuerystring(req pocketapi.GetRequest) string {
 		// Leave it unset.
 	}
 
+	switch req.Tag {
+	case "":
+		// Leave it unset.
+	case pocketapi.UntaggedTag:
+		query.Set("has_labels", "0")
+	default:
+		query.Set("labels", req.Tag)
+	}
+
+	if req.Search != "" {
+		query.Set("search", req.Search)
+	}
+	if req.Domain != "" {
+		query.Set("site", req.Domain)
+	}
+
 	switch strings.ToLower(req.Sort) {
 	case "oldest":
 		query.Set("sort", "created")
@@ -106,7 +125,7 @@ type getResponseItem struct {
 	IsDeleted     bool      `json:"is_deleted"`
 	IsMarked      bool      `json:"is_marked"`
 	IsArchived    bool      `json:"is_archived"`
-	Labels        []any     `json:"labels"`
+	Labels        []string  `json:"labels"`
 	ReadProgress  int       `json:"read_progress"`
 	Resources     resources `json:"resources,omitempty"`
 	WordCount     int       `json:"word_count,omitempty"`
@@ -130,12 +149,6 @@ type resources struct {
 }
 
 func (m getResponseItem) toPocketItem() pocketapi.GetResponseItem {
-	oneIfTrue := func(val bool) string {
-		if val {
-			return "1"
-		}
-		return "0"
-	}
 
 	timeFavorited := "0"
 	if m.IsMarked {
@@ -151,7 +164,7 @@ func (m getResponseItem) toPocketItem() pocketapi.GetResponseItem {
 	if len(m.Authors) > 0 {
 		authors = make(map[string]pocketapi.Author)
 		for _, a := range m.Authors {
-			id := digest(a)
+			id := pocketapi.Digest(a)
 			authors[id] = pocketapi.Author{
 				AuthorID: id,
 				Name:     a,
@@ -166,7 +179,7 @@ func (m getResponseItem) toPocketItem() pocketapi.GetResponseItem {
 	if m.Resources.Image != nil {
 		hasImage = "1"
 		topImageUrl = m.Resources.Image.Src
-		id := digest(m.Resources.Image.Src)
+		id := pocketapi.Digest(m.Resources.Image.Src)
 		image = &pocketapi.Image{
 			ItemID:  m.ID,
 			ImageID: id,
@@ -186,18 +199,19 @@ func (m getResponseItem) toPocketItem() pocketapi.GetResponseItem {
 
 	return pocketapi.GetResponseItem{
 		ItemID:                 m.ID,
-		Favorite:               oneIfTrue(m.IsMarked),
+		Favorite:               pocketapi.OneIfTrue(m.IsMarked),
 		Status:                 status,
 		TimeAdded:              strconv.FormatInt(m.Created.Unix(), 10),
 		TimeUpdated:            strconv.FormatInt(m.Updated.Unix(), 10),
 		TimeFavorited:          timeFavorited,
+		Tags:                   pocketapi.NewTags(m.ID, m.Labels),
 		ResolvedID:             m.ID,
 		GivenURL:               m.URL,
 		GivenTitle:             m.Title,
 		ResolvedTitle:          m.Title,
 		ResolvedURL:            m.URL,
 		Excerpt:                m.Description,
-		IsArticle:              oneIfTrue(m.Type == "article"),
+		IsArticle:              pocketapi.OneIfTrue(m.Type == "article"),
 		IsIndex:                "0",
 		HasVideo:               "0",
 		WordCount:              strconv.Itoa(m.WordCount),
@@ -230,25 +244,24 @@ func (conn *ReadeckConn) translateGetResponse(deckRes *http.Response) (pocketapi
 	pocketRes.List = map[string]pocketapi.GetResponseItem{}
 	for _, item := range deckItems {
 		pocketRes.List[item.ID] = item.toPocketItem()
-
-		// Cache the URL and its ID.
-		conn.urlIDCache[item.URL] = item.ID
 	}
+	conn.indexItems(deckItems)
 
 	return pocketRes, nil
 }
 
-func (conn *ReadeckConn) Get(req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
-	deckReq, err := conn.createRequest(http.MethodGet, "bookmarks", nil)
+func (conn *ReadeckConn) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	deckReq, err := conn.createRequest(ctx, http.MethodGet, "bookmarks", nil)
 	if err != nil {
 		return pocketapi.GetResponse{}, err
 	}
 	deckReq.URL.RawQuery = buildGetQuerystring(req)
 
-	deckRes, err := http.DefaultClient.Do(deckReq)
+	deckRes, err := conn.client.Do(deckReq)
 	if err != nil {
 		return pocketapi.GetResponse{}, err
 	}
+	defer deckRes.Body.Close()
 	if err := checkResponseCode(deckRes); err != nil {
 		return pocketapi.GetResponse{}, err
 	}
@@ -256,16 +269,17 @@ func (conn *ReadeckConn) Get(req pocketapi.GetRequest) (pocketapi.GetResponse, e
 	return conn.translateGetResponse(deckRes)
 }
 
-func (conn *ReadeckConn) getOneItem(itemID string) (getResponseItem, error) {
-	deckReq, err := conn.createRequest(http.MethodGet, fmt.Sprintf("bookmarks/%s", itemID), nil)
+func (conn *ReadeckConn) getOneItem(ctx context.Context, itemID string) (getResponseItem, error) {
+	deckReq, err := conn.createRequest(ctx, http.MethodGet, fmt.Sprintf("bookmarks/%s", itemID), nil)
 	if err != nil {
 		return getResponseItem{}, err
 	}
 
-	deckRes, err := http.DefaultClient.Do(deckReq)
+	deckRes, err := conn.client.Do(deckReq)
 	if err != nil {
 		return getResponseItem{}, err
 	}
+	defer deckRes.Body.Close()
 	if err := checkResponseCode(deckRes); err != nil {
 		return getResponseItem{}, err
 	}
@@ -276,3 +290,142 @@ func (conn *ReadeckConn) getOneItem(itemID string) (getResponseItem, error) {
 	}
 	return item, nil
 }
+
+// The page size used when walking through all bookmarks.
+const indexPageSize = 100
+
+// listAllBookmarks walks through every page of bookmarks matching the query,
+// calling received with each page.
+func (conn *ReadeckConn) listAllBookmarks(ctx context.Context, query url.Values, received func([]getResponseItem) error) error {
+	query.Set("limit", strconv.Itoa(indexPageSize))
+	for offset := 0; ; offset += indexPageSize {
+		deckReq, err := conn.createRequest(ctx, http.MethodGet, "bookmarks", nil)
+		if err != nil {
+			return err
+		}
+		query.Set("offset", strconv.Itoa(offset))
+		deckReq.URL.RawQuery = query.Encode()
+
+		deckRes, err := conn.client.Do(deckReq)
+		if err != nil {
+			return err
+		}
+		if err := checkResponseCode(deckRes); err != nil {
+			deckRes.Body.Close()
+			return err
+		}
+		var items []getResponseItem
+		err = json.NewDecoder(deckRes.Body).Decode(&items)
+		deckRes.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := received(items); err != nil {
+			return err
+		}
+		if len(items) < indexPageSize {
+			return nil
+		}
+	}
+}
+
+// How often DeletedSince lists every bookmark. Readeck forgets bookmarks soon
+// after they're deleted, so the only way to spot them all is to compare the whole
+// library with the URL index, which is too slow to do on every sync.
+const deletionScanInterval = time.Hour
+
+// DeletedSince finds bookmarks which were deleted in Readeck. Bookmarks pending
+// deletion are still listed as updated since the given time, so those are found
+// on every call. Bookmarks which are already gone are found by listing every
+// bookmark and comparing them to the URL index, at most once per
+// deletionScanInterval. Each deletion is only reported once.
+func (conn *ReadeckConn) DeletedSince(ctx context.Context, since time.Time) ([]string, error) {
+	known := conn.index.knownIDs()
+	if len(known) == 0 {
+		return nil, nil
+	}
+
+	conn.scanMu.Lock()
+	fullScan := time.Since(conn.lastFullScan) >= deletionScanInterval
+	conn.scanMu.Unlock()
+
+	query := url.Values{}
+	if !fullScan {
+		query.Set("updated_since", since.Format(time.RFC3339))
+	}
+	present := make(map[string]bool, len(known))
+	pending := map[string]bool{}
+	err := conn.listAllBookmarks(ctx, query, func(items []getResponseItem) error {
+		for _, item := range items {
+			// Bookmarks pending deletion are still listed.
+			if item.IsDeleted {
+				pending[item.ID] = true
+			} else {
+				present[item.ID] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fullScan {
+		conn.scanMu.Lock()
+		conn.lastFullScan = time.Now()
+		conn.scanMu.Unlock()
+	}
+
+	var deleted []string
+	for _, id := range known {
+		if pending[id] || (fullScan && !present[id]) {
+			deleted = append(deleted, id)
+		}
+	}
+	if err := conn.index.removeIDs(deleted); err != nil {
+		log.Printf("Unable to save Readeck URL index: %v", err)
+	}
+	return deleted, nil
+}
+
+// WarmIndex fills the URL index with every bookmark in Readeck, so article text can
+// be requested without listing the articles first.
+func (conn *ReadeckConn) WarmIndex(ctx context.Context) error {
+	return conn.listAllBookmarks(ctx, url.Values{}, func(items []getResponseItem) error {
+		conn.indexItems(items)
+		return nil
+	})
+}
+
+// findItemID returns the Readeck ID for the URL, falling back to searching
+// Readeck if it's not in the index.
+func (conn *ReadeckConn) findItemID(ctx context.Context, articleUrl string) (string, error) {
+	if id, exists := conn.index.get(articleUrl); exists {
+		return id, nil
+	}
+
+	// Readeck can't filter by URL, but it can filter by site, which narrows the search down.
+	query := url.Values{}
+	if u, err := url.Parse(articleUrl); err == nil && u.Hostname() != "" {
+		query.Set("site", u.Hostname())
+	}
+	var id string
+	errFound := errors.New("found")
+	err := conn.listAllBookmarks(ctx, query, func(items []getResponseItem) error {
+		conn.indexItems(items)
+		for _, item := range items {
+			if item.URL == articleUrl {
+				id = item.ID
+				return errFound
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errFound {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("unable to find a bookmark for URL %s in Readeck", articleUrl)
+	}
+	return id, nil
+}
@@ -15,15 +15,18 @@
 package readeck
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"proxyserver/pocketapi"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func numPointer[T int | int64](value T) *T {
@@ -112,6 +115,41 @@ func TestReadeck_GetRequest(t *testing.T) {
 				"is_archived": {"0"},
 			},
 		},
+		{
+			name: "Tag",
+			request: pocketapi.GetRequest{
+				Tag: "news",
+			},
+			want: url.Values{
+				"sort":   {"-created"},
+				"type":   {"article"},
+				"labels": {"news"},
+			},
+		},
+		{
+			name: "Untagged",
+			request: pocketapi.GetRequest{
+				Tag: "_untagged_",
+			},
+			want: url.Values{
+				"sort":       {"-created"},
+				"type":       {"article"},
+				"has_labels": {"0"},
+			},
+		},
+		{
+			name: "Search & Domain",
+			request: pocketapi.GetRequest{
+				Search: "kobo",
+				Domain: "example.com",
+			},
+			want: url.Values{
+				"sort":   {"-created"},
+				"type":   {"article"},
+				"search": {"kobo"},
+				"site":   {"example.com"},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -134,7 +172,7 @@ func TestReadeck_GetRequest(t *testing.T) {
 			defer server.Close()
 
 			readeck := NewReadeckConn(server.URL, "token")
-			readeck.Get(tc.request)
+			readeck.Get(context.Background(), tc.request)
 		})
 	}
 }
@@ -241,12 +279,12 @@ func TestReadeck_GetResponse(t *testing.T) {
 						TimeToRead:     10,
 						DomainMetadata: &pocketapi.DomainMetadata{Name: "Awesome Website"},
 						Authors: map[string]pocketapi.Author{
-							digest("John Doe"): {AuthorID: digest("John Doe"), Name: "John Doe", ItemID: "Wyuiogb24tc7Tiob24t789yp"},
+							pocketapi.Digest("John Doe"): {AuthorID: pocketapi.Digest("John Doe"), Name: "John Doe", ItemID: "Wyuiogb24tc7Tiob24t789yp"},
 						},
 						Images: nil,
 						Image: &pocketapi.Image{
 							ItemID:  "Wyuiogb24tc7Tiob24t789yp",
-							ImageID: digest("http://readeck-instance.com:8002/bm/5C/Wyuiogb24tc7Tiob24t789yp/img/image.jpeg"),
+							ImageID: pocketapi.Digest("http://readeck-instance.com:8002/bm/5C/Wyuiogb24tc7Tiob24t789yp/img/image.jpeg"),
 							Src:     "http://readeck-instance.com:8002/bm/5C/Wyuiogb24tc7Tiob24t789yp/img/image.jpeg",
 							Width:   "800",
 							Height:  "800",
@@ -378,7 +416,7 @@ func TestReadeck_GetResponse(t *testing.T) {
 			defer server.Close()
 
 			readeck := NewReadeckConn(server.URL, "token")
-			res, err := readeck.Get(pocketapi.GetRequest{})
+			res, err := readeck.Get(context.Background(), pocketapi.GetRequest{})
 
 			if tc.wantError && err == nil {
 				t.Error("Wanted error, got nil instead")
@@ -394,3 +432,82 @@ func TestReadeck_GetResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestReadeck_DeletedSince(t *testing.T) {
+	var updatedSince []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/bookmarks" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		updatedSince = append(updatedSince, r.URL.Query().Get("updated_since"))
+		if r.URL.Query().Has("updated_since") {
+			w.Write([]byte(`[
+				{"id": "recent", "url": "https://test.com/recent", "is_deleted": true}
+			]`))
+			return
+		}
+		w.Write([]byte(`[
+			{"id": "kept", "url": "https://test.com/kept"},
+			{"id": "pending", "url": "https://test.com/pending", "is_deleted": true}
+		]`))
+	}))
+	defer server.Close()
+
+	conn := NewReadeckConn(server.URL, "token")
+	conn.index.setAll(map[string]string{
+		"https://test.com/kept":    "kept",
+		"https://test.com/pending": "pending",
+		"https://test.com/gone":    "gone",
+		"https://test.com/recent":  "recent",
+	})
+
+	// The first check lists every bookmark.
+	deleted, err := conn.DeletedSince(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"gone", "pending", "recent"}, deleted, cmpopts.SortSlices(strings.Compare)); diff != "" {
+		t.Errorf("Deleted IDs mismatch (-want +got):\n%s", diff)
+	}
+
+	// Later checks only list recently updated bookmarks, and each deletion is
+	// only reported once.
+	conn.index.setAll(map[string]string{
+		"https://test.com/kept":   "kept",
+		"https://test.com/recent": "recent",
+	})
+	since := time.Unix(1751296089, 0)
+	deleted, err = conn.DeletedSince(context.Background(), since)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"recent"}, deleted); diff != "" {
+		t.Errorf("Deleted IDs mismatch (-want +got):\n%s", diff)
+	}
+	deleted, err = conn.DeletedSince(context.Background(), since)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("Wanted no deleted IDs the second time, got %v", deleted)
+	}
+	if diff := cmp.Diff([]string{"", since.Format(time.RFC3339), since.Format(time.RFC3339)}, updatedSince); diff != "" {
+		t.Errorf("updated_since mismatch (-want +got):\n%s", diff)
+	}
+	if id, exists := conn.index.get("https://test.com/kept"); !exists || id != "kept" {
+		t.Errorf("Expected kept bookmark to stay in the index, got %s", id)
+	}
+}
+
+func TestReadeck_GetErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"status": 401, "message": "Invalid token"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewReadeckConn(server.URL, "wrong").Get(context.Background(), pocketapi.GetRequest{})
+	if err == nil || !strings.Contains(err.Error(), "Invalid token") {
+		t.Errorf("Wanted the Readeck error to be returned, got %v", err)
+	}
+}
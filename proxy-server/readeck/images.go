@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"proxyserver/pocketapi"
+)
+
+// Readeck archives each article's images, and its article HTML links to those
+// copies rather than the original site. The links can be relative to Readeck,
+// and Readeck may need the bearer token to serve them, so they're resolved
+// against the endpoint here and fetched through the proxy.
+
+// resolveImages makes the article's image URLs absolute, relative to the Readeck endpoint.
+func (conn *ReadeckConn) resolveImages(article *pocketapi.ArticleTextResponse) {
+	base, err := url.Parse(conn.endpoint + "/")
+	if err != nil {
+		return
+	}
+	for id, img := range article.Images {
+		src, err := url.Parse(img.Src)
+		if err != nil || src.IsAbs() {
+			continue
+		}
+		img.Src = base.ResolveReference(src).String()
+		article.Images[id] = img
+	}
+}
+
+// OwnsImage returns whether the image is one of Readeck's archived copies.
+func (conn *ReadeckConn) OwnsImage(src string) bool {
+	endpoint, err := url.Parse(conn.endpoint)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == endpoint.Scheme && u.Host == endpoint.Host
+}
+
+// FetchImage downloads one of Readeck's archived images with the bearer token.
+func (conn *ReadeckConn) FetchImage(ctx context.Context, src string) (io.ReadCloser, error) {
+	if !conn.OwnsImage(src) {
+		// Never send the token anywhere else.
+		return nil, fmt.Errorf("image %s isn't hosted by Readeck", src)
+	}
+	deckReq, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	deckReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conn.bearerToken))
+
+	deckRes, err := conn.client.Do(deckReq)
+	if err != nil {
+		return nil, err
+	}
+	if deckRes.StatusCode != http.StatusOK {
+		deckRes.Body.Close()
+		return nil, fmt.Errorf("error fetching Readeck image: [%d] %s", deckRes.StatusCode, deckRes.Status)
+	}
+	return deckRes.Body, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"proxyserver/pocketapi"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadeck_ResolveImages(t *testing.T) {
+	conn := NewReadeckConn("https://readeck.lan/readeck", "token")
+	article := pocketapi.ArticleTextResponse{Images: map[string]pocketapi.Image{
+		"1": {Src: "/bm/ab/abc/_resources/1.jpg"},
+		"2": {Src: "bm/ab/abc/_resources/2.jpg"},
+		"3": {Src: "https://elsewhere.com/3.jpg"},
+	}}
+	conn.resolveImages(&article)
+
+	want := map[string]string{
+		"1": "https://readeck.lan/bm/ab/abc/_resources/1.jpg",
+		"2": "https://readeck.lan/readeck/bm/ab/abc/_resources/2.jpg",
+		"3": "https://elsewhere.com/3.jpg",
+	}
+	got := map[string]string{}
+	for id, img := range article.Images {
+		got[id] = img.Src
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Image sources mismatch (-want +got):\n%s", diff)
+	}
+
+	if !conn.OwnsImage(want["1"]) || conn.OwnsImage(want["3"]) {
+		t.Error("Wanted only Readeck's images to be owned by it")
+	}
+}
+
+func TestReadeck_FetchImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("image data"))
+	}))
+	defer server.Close()
+
+	body, err := NewReadeckConn(server.URL, "token").FetchImage(context.Background(), server.URL+"/bm/ab/abc/_resources/1.jpg")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "image data" {
+		t.Errorf("Unexpected image data: %s", data)
+	}
+
+	if _, err := NewReadeckConn(server.URL, "wrong").FetchImage(context.Background(), server.URL+"/1.jpg"); err == nil {
+		t.Error("Wanted error with the wrong token, got nil instead")
+	}
+	if _, err := NewReadeckConn(server.URL, "token").FetchImage(context.Background(), "https://elsewhere.com/1.jpg"); err == nil {
+		t.Error("Wanted error fetching an image from another host, got nil instead")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"proxyserver/internal/atomicfile"
+	"slices"
+	"sync"
+)
+
+// urlIndex maps article URLs to Readeck IDs.
+//
+// Pocket just needs a URL to get article text, but Readeck requires an item ID, so
+// the index is filled in whenever we see a bookmark, and optionally persisted to
+// disk so lookups still work after a restart.
+type urlIndex struct {
+	mu  sync.RWMutex
+	ids map[string]string
+	// Where to persist the index, or empty to keep it in memory only.
+	path string
+}
+
+func newURLIndex(path string) (*urlIndex, error) {
+	idx := &urlIndex{ids: make(map[string]string), path: path}
+	if path == "" {
+		return idx, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &idx.ids); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *urlIndex) get(url string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	id, exists := idx.ids[url]
+	return id, exists
+}
+
+func (idx *urlIndex) set(url, id string) error {
+	return idx.setAll(map[string]string{url: id})
+}
+
+// setAll adds all the URLs at once, so the index is only written to disk once.
+func (idx *urlIndex) setAll(ids map[string]string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	changed := false
+	for url, id := range ids {
+		if idx.ids[url] != id {
+			idx.ids[url] = id
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return idx.save()
+}
+
+// removeIDs drops every URL that maps to one of the given IDs.
+func (idx *urlIndex) removeIDs(ids []string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	changed := false
+	for url, id := range idx.ids {
+		if slices.Contains(ids, id) {
+			delete(idx.ids, url)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return idx.save()
+}
+
+// knownIDs returns every ID in the index.
+func (idx *urlIndex) knownIDs() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	ids := make([]string, 0, len(idx.ids))
+	for _, id := range idx.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (idx *urlIndex) size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.ids)
+}
+
+// save writes the index to disk. Must be called with mu held.
+func (idx *urlIndex) save() error {
+	if idx.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(idx.ids)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(idx.path, data)
+}
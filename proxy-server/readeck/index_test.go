@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestURLIndex_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "index.json")
+
+	idx, err := newURLIndex(path)
+	if err != nil {
+		t.Fatalf("Unexpected error creating index: %v", err)
+	}
+	if err := idx.set("https://test.com/1", "id1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := idx.setAll(map[string]string{"https://test.com/2": "id2", "https://test.com/3": "id3"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reloaded, err := newURLIndex(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reloading index: %v", err)
+	}
+	for url, want := range map[string]string{"https://test.com/1": "id1", "https://test.com/2": "id2", "https://test.com/3": "id3"} {
+		if got, exists := reloaded.get(url); !exists || got != want {
+			t.Errorf("Unexpected ID for %s, want %s got %s", url, want, got)
+		}
+	}
+	if reloaded.size() != 3 {
+		t.Errorf("Unexpected index size, want 3 got %d", reloaded.size())
+	}
+}
+
+func TestURLIndex_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newURLIndex(path); err == nil {
+		t.Error("Wanted error loading a corrupt index, got nil instead")
+	}
+}
+
+func TestURLIndex_Concurrent(t *testing.T) {
+	idx, err := newURLIndex(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error creating index: %v", err)
+	}
+
+	// Run with -race to check for unsynchronised access.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			idx.set(fmt.Sprintf("https://test.com/%d", i), fmt.Sprintf("id%d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			idx.get(fmt.Sprintf("https://test.com/%d", i))
+		}()
+	}
+	wg.Wait()
+
+	if idx.size() != 10 {
+		t.Errorf("Unexpected index size, want 10 got %d", idx.size())
+	}
+}
@@ -16,9 +16,12 @@ package readeck
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"proxyserver/httpclient"
 	"time"
 )
 
@@ -26,29 +29,34 @@ var pointerTrue bool = true
 var pointerFalse bool = false
 
 type updateRequest struct {
-	IsDeleted  *bool `json:"is_deleted,omitempty"`
-	IsMarked   *bool `json:"is_marked,omitempty"`
-	IsArchived *bool `json:"is_archived,omitempty"`
+	IsDeleted    *bool     `json:"is_deleted,omitempty"`
+	IsMarked     *bool     `json:"is_marked,omitempty"`
+	IsArchived   *bool     `json:"is_archived,omitempty"`
+	Labels       *[]string `json:"labels,omitempty"`
+	AddLabels    []string  `json:"add_labels,omitempty"`
+	RemoveLabels []string  `json:"remove_labels,omitempty"`
 }
 
-func sendUpdate(conn *ReadeckConn, itemID string, params updateRequest) error {
+func sendUpdate(ctx context.Context, conn *ReadeckConn, itemID string, params updateRequest) error {
 	var buffer bytes.Buffer
 	if err := json.NewEncoder(&buffer).Encode(params); err != nil {
 		return err
 	}
 
-	deckReq, err := conn.createRequest(http.MethodPatch, fmt.Sprintf("bookmarks/%s", itemID), &buffer)
+	deckReq, err := conn.createRequest(ctx, http.MethodPatch, fmt.Sprintf("bookmarks/%s", itemID), &buffer)
 	if err != nil {
 		return err
 	}
 	deckReq.Header.Set("Content-Type", "application/json")
 
-	deckRes, err := http.DefaultClient.Do(deckReq)
+	// Updates set fields to fixed values, so they're safe to retry.
+	deckRes, err := conn.client.Do(httpclient.Idempotent(deckReq))
 	if err != nil {
 		return err
 	}
+	defer deckRes.Body.Close()
 	if deckRes.StatusCode != http.StatusOK {
-		return fmt.Errorf("error calling Readeck API: [%d] %s", deckRes.StatusCode, deckRes.Status)
+		return httpclient.ResponseError(deckRes, fmt.Errorf("error calling Readeck API: [%d] %s", deckRes.StatusCode, deckRes.Status))
 	}
 	return nil
 }
@@ -58,50 +66,62 @@ type insertRequest struct {
 	Title string `json:"title,omitempty"`
 }
 
-func (conn *ReadeckConn) Add(url string, title string, time time.Time) error {
+func (conn *ReadeckConn) Add(ctx context.Context, url string, title string, time time.Time) (string, error) {
 	body := insertRequest{Url: url, Title: title}
 	var buffer bytes.Buffer
 	if err := json.NewEncoder(&buffer).Encode(body); err != nil {
-		return err
+		return "", err
 	}
 
-	deckReq, err := conn.createRequest(http.MethodPost, "bookmarks", &buffer)
+	deckReq, err := conn.createRequest(ctx, http.MethodPost, "bookmarks", &buffer)
 	if err != nil {
-		return err
+		return "", err
 	}
 	deckReq.Header.Set("Content-Type", "application/json")
 
-	deckRes, err := http.DefaultClient.Do(deckReq)
+	deckRes, err := conn.client.Do(deckReq)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer deckRes.Body.Close()
 	if err := checkResponseCode(deckRes); err != nil {
-		return err
+		return "", err
 	}
 
-	// Cache the returned ID
+	// Index the returned ID.
 	itemID := deckRes.Header.Get("Bookmark-Id")
-	conn.urlIDCache[url] = itemID
+	if itemID != "" {
+		if err := conn.index.set(url, itemID); err != nil {
+			log.Printf("Unable to save Readeck URL index: %v", err)
+		}
+	}
 
-	return nil
+	return itemID, nil
 }
 
-func (conn *ReadeckConn) Archive(itemID string, time time.Time) error {
-	return sendUpdate(conn, itemID, updateRequest{IsArchived: &pointerTrue})
+func (conn *ReadeckConn) Archive(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{IsArchived: &pointerTrue})
 }
 
-func (conn *ReadeckConn) Unarchive(itemID string, time time.Time) error {
-	return sendUpdate(conn, itemID, updateRequest{IsArchived: &pointerFalse})
+func (conn *ReadeckConn) Unarchive(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{IsArchived: &pointerFalse})
 }
 
-func (conn *ReadeckConn) Delete(itemID string, time time.Time) error {
-	return sendUpdate(conn, itemID, updateRequest{IsDeleted: &pointerTrue})
+func (conn *ReadeckConn) Delete(ctx context.Context, itemID string, time time.Time) error {
+	if err := sendUpdate(ctx, conn, itemID, updateRequest{IsDeleted: &pointerTrue}); err != nil {
+		return err
+	}
+	// The deletion has already been recorded, so DeletedSince shouldn't report it again.
+	if err := conn.index.removeIDs([]string{itemID}); err != nil {
+		log.Printf("Unable to save Readeck URL index: %v", err)
+	}
+	return nil
 }
 
-func (conn *ReadeckConn) Favorite(itemID string, time time.Time) error {
-	return sendUpdate(conn, itemID, updateRequest{IsMarked: &pointerTrue})
+func (conn *ReadeckConn) Favorite(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{IsMarked: &pointerTrue})
 }
 
-func (conn *ReadeckConn) Unfavorite(itemID string, time time.Time) error {
-	return sendUpdate(conn, itemID, updateRequest{IsMarked: &pointerFalse})
+func (conn *ReadeckConn) Unfavorite(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{IsMarked: &pointerFalse})
 }
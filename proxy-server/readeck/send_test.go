@@ -15,6 +15,7 @@
 package readeck
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -43,7 +44,7 @@ func TestReadeck_SendUpdate(t *testing.T) {
 			name:       "Archive",
 			statusCode: http.StatusOK,
 			update: func(conn *ReadeckConn) error {
-				return conn.Archive(itemID, time.Time{})
+				return conn.Archive(context.Background(), itemID, time.Time{})
 			},
 			wantBody: updateRequest{IsDeleted: nil, IsMarked: nil, IsArchived: boolPointer(true)},
 		},
@@ -51,7 +52,7 @@ func TestReadeck_SendUpdate(t *testing.T) {
 			name:       "Unarchive",
 			statusCode: http.StatusOK,
 			update: func(conn *ReadeckConn) error {
-				return conn.Unarchive(itemID, time.Time{})
+				return conn.Unarchive(context.Background(), itemID, time.Time{})
 			},
 			wantBody: updateRequest{IsDeleted: nil, IsMarked: nil, IsArchived: boolPointer(false)},
 		},
@@ -59,7 +60,7 @@ func TestReadeck_SendUpdate(t *testing.T) {
 			name:       "Favorite",
 			statusCode: http.StatusOK,
 			update: func(conn *ReadeckConn) error {
-				return conn.Favorite(itemID, time.Time{})
+				return conn.Favorite(context.Background(), itemID, time.Time{})
 			},
 			wantBody: updateRequest{IsDeleted: nil, IsMarked: boolPointer(true), IsArchived: nil},
 		},
@@ -67,7 +68,7 @@ func TestReadeck_SendUpdate(t *testing.T) {
 			name:       "Unfavorite",
 			statusCode: http.StatusOK,
 			update: func(conn *ReadeckConn) error {
-				return conn.Unfavorite(itemID, time.Time{})
+				return conn.Unfavorite(context.Background(), itemID, time.Time{})
 			},
 			wantBody: updateRequest{IsDeleted: nil, IsMarked: boolPointer(false), IsArchived: nil},
 		},
@@ -75,7 +76,7 @@ func TestReadeck_SendUpdate(t *testing.T) {
 			name:       "Delete",
 			statusCode: http.StatusOK,
 			update: func(conn *ReadeckConn) error {
-				return conn.Delete(itemID, time.Time{})
+				return conn.Delete(context.Background(), itemID, time.Time{})
 			},
 			wantBody: updateRequest{IsDeleted: boolPointer(true), IsMarked: nil, IsArchived: nil},
 		},
@@ -153,7 +154,7 @@ func TestReadeck_Add(t *testing.T) {
 	defer server.Close()
 
 	readeck := NewReadeckConn(server.URL, "token123")
-	if err := readeck.Add("http://example.com/path-to-file?key=value", "", time.Time{}); err != nil {
+	if _, err := readeck.Add(context.Background(), "http://example.com/path-to-file?key=value", "", time.Time{}); err != nil {
 		t.Errorf("Unexpected error from Add(): want nil got %v", err)
 	}
 
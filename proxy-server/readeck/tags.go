@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"proxyserver/httpclient"
+	"time"
+)
+
+// Pocket tags are stored as Readeck labels.
+
+func (conn *ReadeckConn) AddTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{AddLabels: tags})
+}
+
+func (conn *ReadeckConn) RemoveTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{RemoveLabels: tags})
+}
+
+func (conn *ReadeckConn) ReplaceTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	if tags == nil {
+		tags = []string{}
+	}
+	return sendUpdate(ctx, conn, itemID, updateRequest{Labels: &tags})
+}
+
+func (conn *ReadeckConn) ClearTags(ctx context.Context, itemID string, time time.Time) error {
+	return conn.ReplaceTags(ctx, itemID, nil, time)
+}
+
+type labelUpdateRequest struct {
+	Name string `json:"name"`
+}
+
+func (conn *ReadeckConn) RenameTag(ctx context.Context, oldTag string, newTag string, time time.Time) error {
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(labelUpdateRequest{Name: newTag}); err != nil {
+		return err
+	}
+
+	deckReq, err := conn.createRequest(ctx, http.MethodPatch, fmt.Sprintf("bookmarks/labels/%s", url.PathEscape(oldTag)), &buffer)
+	if err != nil {
+		return err
+	}
+	deckReq.Header.Set("Content-Type", "application/json")
+
+	deckRes, err := conn.client.Do(httpclient.Idempotent(deckReq))
+	if err != nil {
+		return err
+	}
+	defer deckRes.Body.Close()
+	if deckRes.StatusCode != http.StatusOK {
+		return httpclient.ResponseError(deckRes, fmt.Errorf("error calling Readeck API: [%d] %s", deckRes.StatusCode, deckRes.Status))
+	}
+	return nil
+}
+
+func (conn *ReadeckConn) DeleteTag(ctx context.Context, tag string, time time.Time) error {
+	deckReq, err := conn.createRequest(ctx, http.MethodDelete, fmt.Sprintf("bookmarks/labels/%s", url.PathEscape(tag)), nil)
+	if err != nil {
+		return err
+	}
+
+	deckRes, err := conn.client.Do(deckReq)
+	if err != nil {
+		return err
+	}
+	defer deckRes.Body.Close()
+	if deckRes.StatusCode != http.StatusOK && deckRes.StatusCode != http.StatusNoContent {
+		return httpclient.ResponseError(deckRes, fmt.Errorf("error calling Readeck API: [%d] %s", deckRes.StatusCode, deckRes.Status))
+	}
+	return nil
+}
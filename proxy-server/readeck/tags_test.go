@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readeck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"proxyserver/pocketapi"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func stringsPointer(values ...string) *[]string {
+	if values == nil {
+		values = []string{}
+	}
+	return &values
+}
+
+func TestReadeck_ItemTags(t *testing.T) {
+	const itemID = "id123"
+
+	testCases := []struct {
+		name     string
+		update   func(*ReadeckConn) error
+		wantBody updateRequest
+	}{
+		{
+			name: "Add",
+			update: func(conn *ReadeckConn) error {
+				return conn.AddTags(context.Background(), itemID, []string{"a", "b"}, time.Time{})
+			},
+			wantBody: updateRequest{AddLabels: []string{"a", "b"}},
+		},
+		{
+			name: "Remove",
+			update: func(conn *ReadeckConn) error {
+				return conn.RemoveTags(context.Background(), itemID, []string{"a"}, time.Time{})
+			},
+			wantBody: updateRequest{RemoveLabels: []string{"a"}},
+		},
+		{
+			name: "Replace",
+			update: func(conn *ReadeckConn) error {
+				return conn.ReplaceTags(context.Background(), itemID, []string{"c"}, time.Time{})
+			},
+			wantBody: updateRequest{Labels: stringsPointer("c")},
+		},
+		{
+			name: "Clear",
+			update: func(conn *ReadeckConn) error {
+				return conn.ClearTags(context.Background(), itemID, time.Time{})
+			},
+			wantBody: updateRequest{Labels: stringsPointer()},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPatch || r.URL.Path != "/api/bookmarks/id123" {
+					t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				var got updateRequest
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("Unable to decode request body: %v", err)
+				}
+				if diff := cmp.Diff(tc.wantBody, got); diff != "" {
+					t.Errorf("Request body mismatch (-want +got):\n%s", diff)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			if err := tc.update(NewReadeckConn(server.URL, "token")); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadeck_LabelTags(t *testing.T) {
+	testCases := []struct {
+		name       string
+		update     func(*ReadeckConn) error
+		statusCode int
+		wantMethod string
+		wantPath   string
+		wantBody   string
+		wantError  bool
+	}{
+		{
+			name: "Rename",
+			update: func(conn *ReadeckConn) error {
+				return conn.RenameTag(context.Background(), "old tag", "new", time.Time{})
+			},
+			statusCode: http.StatusOK,
+			wantMethod: http.MethodPatch,
+			wantPath:   "/api/bookmarks/labels/old tag",
+			wantBody:   "new",
+		},
+		{
+			name: "Delete",
+			update: func(conn *ReadeckConn) error {
+				return conn.DeleteTag(context.Background(), "tag", time.Time{})
+			},
+			statusCode: http.StatusNoContent,
+			wantMethod: http.MethodDelete,
+			wantPath:   "/api/bookmarks/labels/tag",
+		},
+		{
+			name: "Missing",
+			update: func(conn *ReadeckConn) error {
+				return conn.DeleteTag(context.Background(), "tag", time.Time{})
+			},
+			statusCode: http.StatusNotFound,
+			wantMethod: http.MethodDelete,
+			wantPath:   "/api/bookmarks/labels/tag",
+			wantError:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tc.wantMethod || r.URL.Path != tc.wantPath {
+					t.Errorf("Unexpected request, want %s %s got %s %s", tc.wantMethod, tc.wantPath, r.Method, r.URL.Path)
+				}
+				if tc.wantBody != "" {
+					var got labelUpdateRequest
+					if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+						t.Errorf("Unable to decode request body: %v", err)
+					}
+					if got.Name != tc.wantBody {
+						t.Errorf("Unexpected label name, want %s got %s", tc.wantBody, got.Name)
+					}
+				}
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			err := tc.update(NewReadeckConn(server.URL, "token"))
+			if tc.wantError && err == nil {
+				t.Error("Wanted error, got nil instead")
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("Wanted nil error, got %v instead", err)
+			}
+		})
+	}
+}
+
+func TestReadeck_GetLabels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": "id123", "url": "https://test.com", "labels": ["news", "tech"]}]`))
+	}))
+	defer server.Close()
+
+	res, err := NewReadeckConn(server.URL, "token").Get(context.Background(), pocketapi.GetRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := pocketapi.Tags{
+		"news": {ItemID: "id123", Tag: "news"},
+		"tech": {ItemID: "id123", Tag: "tech"},
+	}
+	if diff := cmp.Diff(want, res.List["id123"].Tags); diff != "" {
+		t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+	}
+}
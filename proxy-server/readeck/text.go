@@ -15,16 +15,12 @@
 package readeck
 
 import (
-	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"proxyserver/pocketapi"
-	"strconv"
 	"time"
-
-	"golang.org/x/net/html"
 )
 
 func copyFromGetItem(item getResponseItem, article *pocketapi.ArticleTextResponse) {
@@ -55,17 +51,18 @@ func copyFromGetItem(item getResponseItem, article *pocketapi.ArticleTextRespons
 	article.Lang = item.Lang
 }
 
-func (conn *ReadeckConn) getArticleHTML(itemID string, received func(io.ReadCloser) error) error {
-	deckReq, err := conn.createRequest(http.MethodGet, fmt.Sprintf("bookmarks/%s/article", itemID), nil)
+func (conn *ReadeckConn) getArticleHTML(ctx context.Context, itemID string, received func(io.ReadCloser) error) error {
+	deckReq, err := conn.createRequest(ctx, http.MethodGet, fmt.Sprintf("bookmarks/%s/article", itemID), nil)
 	if err != nil {
 		return err
 	}
 
 	deckReq.Header.Set("Accept", "text/html")
-	deckRes, err := http.DefaultClient.Do(deckReq)
+	deckRes, err := conn.client.Do(deckReq)
 	if err != nil {
 		return err
 	}
+	defer deckRes.Body.Close()
 	if err := checkResponseCode(deckRes); err != nil {
 		return err
 	}
@@ -73,76 +70,13 @@ func (conn *ReadeckConn) getArticleHTML(itemID string, received func(io.ReadClos
 	return received(deckRes.Body)
 }
 
-func parseArticleText(articleText io.ReadCloser, article *pocketapi.ArticleTextResponse) error {
-	doc, err := html.Parse(articleText)
+func (conn *ReadeckConn) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	id, err := conn.findItemID(ctx, url)
 	if err != nil {
-		return err
-	}
-
-	// We need to separate the <img> tags and replace them with HTML comments
-	// of the form <!--IMG_n-->, since that what Pocket clients expect.
-	article.Images = make(map[string]pocketapi.Image)
-
-	var root *html.Node
-	for n := range doc.Descendants() {
-		if n.Type == html.ElementNode {
-			if n.Data == "body" {
-				root = n
-				// Replace the body with a root <div>, since the existing Pocket API
-				// doesn't include a <body> tag.
-				root.Data = "div"
-			}
-
-			if n.Data == "img" {
-				pImg := pocketapi.Image{}
-				for _, a := range n.Attr {
-					if a.Key == "src" {
-						pImg.Src = a.Val
-					}
-					if a.Key == "height" {
-						pImg.Height = a.Val
-					}
-					if a.Key == "width" {
-						pImg.Width = a.Val
-					}
-				}
-				if pImg.Src == "" {
-					// No image URL available, skip
-					continue
-				}
-				// Save the URL
-				pImg.ImageID = strconv.Itoa(len(article.Images) + 1)
-				pImg.ItemID = article.ItemID
-				article.Images[pImg.ImageID] = pImg
-
-				// Replace the tag with a comment
-				n.Type = html.CommentNode
-				n.Data = fmt.Sprintf("IMG_%s", pImg.ImageID)
-				n.Attr = nil
-			}
-		}
-	}
-
-	if root == nil {
-		return errors.New("unable to parse HTML")
-	}
-
-	var buf bytes.Buffer
-	w := io.Writer(&buf)
-	html.Render(w, root)
-	article.Article = buf.String()
-	article.ContentLength = strconv.Itoa(len(article.Article))
-
-	return nil
-}
-
-func (conn *ReadeckConn) ArticleText(url string) (pocketapi.ArticleTextResponse, error) {
-	id, cached := conn.urlIDCache[url]
-	if !cached {
-		return pocketapi.ArticleTextResponse{}, fmt.Errorf("unable to find URL %s in cache. Try calling /get first (and paginate all items) to refresh the cache", url)
+		return pocketapi.ArticleTextResponse{}, err
 	}
 
-	item, err := conn.getOneItem(id)
+	item, err := conn.getOneItem(ctx, id)
 	if err != nil {
 		return pocketapi.ArticleTextResponse{}, err
 	}
@@ -150,12 +84,13 @@ func (conn *ReadeckConn) ArticleText(url string) (pocketapi.ArticleTextResponse,
 	copyFromGetItem(item, &article)
 
 	article.Encoding = "utf-8"
-	err = conn.getArticleHTML(id, func(articleText io.ReadCloser) error {
-		return parseArticleText(articleText, &article)
+	err = conn.getArticleHTML(ctx, id, func(articleText io.ReadCloser) error {
+		return pocketapi.ParseArticleText(articleText, &article)
 	})
 	if err != nil {
-		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error getting article HTML: %v", err)
+		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error getting article HTML: %w", err)
 	}
+	conn.resolveImages(&article)
 
 	return article, nil
 }
@@ -15,124 +15,110 @@
 package readeck
 
 import (
-	"io"
-	"proxyserver/pocketapi"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strconv"
-	"strings"
 	"testing"
-
-	"github.com/google/go-cmp/cmp"
 )
 
-func TestParseArticleText(t *testing.T) {
-	testCases := []struct {
-		name   string
-		text   string
-		itemID string
-		want   pocketapi.ArticleTextResponse
-	}{
-		{
-			name:   "Empty",
-			itemID: "item123",
-			text:   "",
-			want: pocketapi.ArticleTextResponse{
-				ItemID:  "item123",
-				Article: "<div></div>",
-				Images:  map[string]pocketapi.Image{},
-			},
-		},
-		{
-			name:   "Basic",
-			itemID: "item123",
-			text:   "<div><img src=\"http://test.com/img.png\" /></div>",
-			want: pocketapi.ArticleTextResponse{
-				ItemID:  "item123",
-				Article: "<div><div><!--IMG_1--></div></div>",
-				Images: map[string]pocketapi.Image{
-					"1": {
-						ItemID:  "item123",
-						ImageID: "1",
-						Src:     "http://test.com/img.png",
-					},
-				},
-			},
-		},
-		{
-			name:   "Malformed",
-			itemID: "item123",
-			text:   "<div><img src=\"http://test.com/img.png\" />",
-			want: pocketapi.ArticleTextResponse{
-				ItemID:  "item123",
-				Article: "<div><div><!--IMG_1--></div></div>",
-				Images: map[string]pocketapi.Image{
-					"1": {
-						ItemID:  "item123",
-						ImageID: "1",
-						Src:     "http://test.com/img.png",
-					},
-				},
-			},
-		},
-		{
-			name:   "Multiple Elements",
-			itemID: "item123",
-			text:   "<div>test</div><div><img src=\"http://test.com/img.png\" /></div>",
-			want: pocketapi.ArticleTextResponse{
-				ItemID:  "item123",
-				Article: "<div><div>test</div><div><!--IMG_1--></div></div>",
-				Images: map[string]pocketapi.Image{
-					"1": {
-						ItemID:  "item123",
-						ImageID: "1",
-						Src:     "http://test.com/img.png",
-					},
-				},
-			},
-		},
-		{
-			name:   "Multiple Images",
-			itemID: "item123",
-			text: `
-			<div>test</div>
-			<p><img src="http://test.com/img.png" /></p>
-			<div><figure><img src="http://test.com/img2.png" height="100" width="200" /></figure></div>
-			`,
-			want: pocketapi.ArticleTextResponse{
-				ItemID: "item123",
-				Article: `<div><div>test</div>
-			<p><!--IMG_1--></p>
-			<div><figure><!--IMG_2--></figure></div>
-			</div>`,
-				Images: map[string]pocketapi.Image{
-					"1": {
-						ItemID:  "item123",
-						ImageID: "1",
-						Src:     "http://test.com/img.png",
-					},
-					"2": {
-						ItemID:  "item123",
-						ImageID: "2",
-						Src:     "http://test.com/img2.png",
-						Height:  "100",
-						Width:   "200",
-					},
-				},
-			},
-		},
-	}
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			got := pocketapi.ArticleTextResponse{
-				ItemID: tc.itemID,
+// newArticleServer serves a fake Readeck instance with a single bookmark among
+// many others, so finding it requires paging through the search results.
+func newArticleServer(t *testing.T, requests map[string]int) *httptest.Server {
+	const targetURL = "https://test.com/article"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/bookmarks":
+			if site := r.URL.Query().Get("site"); site != "" {
+				requests["site:"+site]++
 			}
-			if err := parseArticleText(io.NopCloser(strings.NewReader(tc.text)), &got); err != nil {
-				t.Fatalf("Unexpected error: %v", err)
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			if offset > 0 {
+				fmt.Fprintf(w, `[{"id": "target", "url": %q}]`, targetURL)
+				return
 			}
-			tc.want.ContentLength = strconv.Itoa(len(tc.want.Article))
-
-			if diff := cmp.Diff(tc.want, got); diff != "" {
-				t.Errorf("parseArticleText mismatch (-want +got):\n%s", diff)
+			// A full page of other bookmarks.
+			w.Write([]byte("["))
+			for i := range indexPageSize {
+				if i > 0 {
+					w.Write([]byte(","))
+				}
+				fmt.Fprintf(w, `{"id": "other%d", "url": "https://test.com/other/%d"}`, i, i)
 			}
-		})
+			w.Write([]byte("]"))
+		case "/api/bookmarks/target":
+			fmt.Fprintf(w, `{"id": "target", "url": %q, "title": "Target"}`, targetURL)
+		case "/api/bookmarks/target/article":
+			w.Write([]byte("<p>Article text</p>"))
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestReadeck_ArticleTextIndexMiss(t *testing.T) {
+	requests := map[string]int{}
+	server := newArticleServer(t, requests)
+	defer server.Close()
+
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	conn, err := NewReadeckConnWithIndex(server.URL, "token", indexPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	article, err := conn.ArticleText(context.Background(), "https://test.com/article")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if article.ItemID != "target" || article.Article != "<div><p>Article text</p></div>" {
+		t.Errorf("Unexpected article: %+v", article)
+	}
+	if requests["/api/bookmarks"] != 2 || requests["site:test.com"] != 2 {
+		t.Errorf("Expected 2 search requests filtered by site, got %v", requests)
+	}
+
+	// After a restart, the persisted index should be used instead of searching again.
+	restarted, err := NewReadeckConnWithIndex(server.URL, "token", indexPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := restarted.ArticleText(context.Background(), "https://test.com/article"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if requests["/api/bookmarks"] != 2 {
+		t.Errorf("Expected the index to be used after restarting, got %d search requests", requests["/api/bookmarks"])
+	}
+}
+
+func TestReadeck_ArticleTextNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	if _, err := NewReadeckConn(server.URL, "token").ArticleText(context.Background(), "https://test.com/missing"); err == nil {
+		t.Error("Wanted error, got nil instead")
+	}
+}
+
+func TestReadeck_WarmIndex(t *testing.T) {
+	requests := map[string]int{}
+	server := newArticleServer(t, requests)
+	defer server.Close()
+
+	conn := NewReadeckConn(server.URL, "token")
+	if err := conn.WarmIndex(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id, exists := conn.index.get("https://test.com/article"); !exists || id != "target" {
+		t.Errorf("Unexpected indexed ID, want target got %s", id)
+	}
+	if conn.index.size() != indexPageSize+1 {
+		t.Errorf("Unexpected index size, want %d got %d", indexPageSize+1, conn.index.size())
 	}
 }
@@ -0,0 +1,240 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// The most the credentials are looked for in a request body.
+const maxAuthBodySize = 10 << 20
+
+// authChecks are the checks a route's requests go through, on top of the
+// client's address, which is always checked. They can be combined.
+type authChecks int
+
+const (
+	// Only the client's address is checked, for URLs which are signed instead.
+	checkNetwork authChecks = 0
+	// Basic auth is checked, for pages opened in a browser. The Kobo's Pocket
+	// client can't send it, so the Pocket API doesn't use it.
+	checkBasicAuth authChecks = 1
+	// The Pocket consumer key is checked, for logging in.
+	checkConsumerKey authChecks = 2
+	// The Pocket consumer key and access token are checked, for everything else.
+	checkAccessToken authChecks = 4 | checkConsumerKey
+)
+
+// has returns whether all of the other checks are included.
+func (checks authChecks) has(other authChecks) bool {
+	return checks&other == other
+}
+
+// authenticate only passes requests on to next which are allowed by the
+// configured address allowlist, basic auth, and consumer key and access token
+// allowlists. Checks which aren't configured let every request through.
+func (s *server) authenticate(checks authChecks, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !networkAllowed(s.options.AllowedNetworks(), r.RemoteAddr) {
+			writePocketError(w, http.StatusForbidden, 158, "Access denied.")
+			return
+		}
+		if checks.has(checkBasicAuth) && !s.basicAuthValid(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Pocket proxy", charset="UTF-8"`)
+			writePocketError(w, http.StatusUnauthorized, 107, "Invalid username or password.")
+			return
+		}
+
+		consumerKeys := s.options.AllowedConsumerKeys()
+		accessTokens := s.options.AllowedAccessTokens()
+		if !checks.has(checkConsumerKey) || (len(consumerKeys) == 0 && (!checks.has(checkAccessToken) || len(accessTokens) == 0)) {
+			next(w, r)
+			return
+		}
+
+		creds, err := requestCredentials(r)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to parse request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(consumerKeys) > 0 {
+			if creds.ConsumerKey == "" {
+				writePocketError(w, http.StatusBadRequest, 138, "Missing consumer key.")
+				return
+			}
+			if !containsSecret(consumerKeys, creds.ConsumerKey) {
+				writePocketError(w, http.StatusForbidden, 152, "Invalid consumer key.")
+				return
+			}
+		}
+		if checks.has(checkAccessToken) && len(accessTokens) > 0 && !s.accessTokenKnown(accessTokens, creds.AccessToken) {
+			writePocketError(w, http.StatusUnauthorized, 107, "Invalid access token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// credentials are what identifies the app and user in a Pocket API request.
+type credentials struct {
+	ConsumerKey string `json:"consumer_key"`
+	AccessToken string `json:"access_token"`
+}
+
+// requestCredentials finds the credentials in a request's JSON or form body, or
+// its query. The body is put back for the handler to read.
+func requestCredentials(r *http.Request) (credentials, error) {
+	creds := credentials{
+		ConsumerKey: r.URL.Query().Get("consumer_key"),
+		AccessToken: r.URL.Query().Get("access_token"),
+	}
+	if r.Body == nil {
+		return creds, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxAuthBodySize))
+	r.Body.Close()
+	if err != nil {
+		return credentials{}, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return creds, nil
+	}
+	// Devices send JSON without saying so, and form data for article text.
+	if trimmed[0] == '{' {
+		var bodyCreds credentials
+		if err := json.Unmarshal(trimmed, &bodyCreds); err != nil {
+			return credentials{}, err
+		}
+		return mergeCredentials(creds, bodyCreds), nil
+	}
+	form, err := url.ParseQuery(string(trimmed))
+	if err != nil {
+		return credentials{}, err
+	}
+	return mergeCredentials(creds, credentials{ConsumerKey: form.Get("consumer_key"), AccessToken: form.Get("access_token")}), nil
+}
+
+// mergeCredentials prefers the credentials in the body to those in the query.
+func mergeCredentials(query, body credentials) credentials {
+	if body.ConsumerKey != "" {
+		query.ConsumerKey = body.ConsumerKey
+	}
+	if body.AccessToken != "" {
+		query.AccessToken = body.AccessToken
+	}
+	return query
+}
+
+func (s *server) basicAuthValid(r *http.Request) bool {
+	wantUser, wantPassword := s.options.BasicAuthUsername(), s.options.BasicAuthPassword()
+	if wantUser == "" && wantPassword == "" {
+		return true
+	}
+	user, password, ok := r.BasicAuth()
+	// Both are compared, so a wrong username takes as long as a wrong password.
+	userValid := subtle.ConstantTimeCompare([]byte(user), []byte(wantUser)) == 1
+	passwordValid := subtle.ConstantTimeCompare([]byte(password), []byte(wantPassword)) == 1
+	return ok && userValid && passwordValid
+}
+
+// accessTokenKnown returns whether the access token is in the allowlist, or
+// belongs to a user from the users file or who logged in.
+func (s *server) accessTokenKnown(allowed []string, accessToken string) bool {
+	if accessToken == "" {
+		return false
+	}
+	if containsSecret(allowed, accessToken) {
+		return true
+	}
+	if _, exists := s.tenants[accessToken]; exists {
+		return true
+	}
+	user, exists := s.oauth.user(accessToken)
+	return exists && user.BearerToken != ""
+}
+
+func containsSecret(secrets []string, value string) bool {
+	found := false
+	for _, secret := range secrets {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(value)) == 1 {
+			found = true
+		}
+	}
+	return found
+}
+
+// networkAllowed returns whether the client address is in one of the networks,
+// given as CIDR prefixes or single addresses. Every address is allowed if
+// there are no networks.
+func networkAllowed(networks []string, remoteAddr string) bool {
+	if len(networks) == 0 {
+		return true
+	}
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, network := range networks {
+		prefix, err := parseNetwork(network)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetwork(network string) (netip.Prefix, error) {
+	if strings.Contains(network, "/") {
+		prefix, err := netip.ParsePrefix(network)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(network)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// ValidateAuthOptions checks the allowlists can be parsed, so mistakes are
+// found at startup or when reloading rather than by locking everyone out.
+func ValidateAuthOptions(options Options) error {
+	for _, network := range options.AllowedNetworks() {
+		if _, err := parseNetwork(network); err != nil {
+			return fmt.Errorf("invalid allowed network %q: %w", network, err)
+		}
+	}
+	if (options.BasicAuthUsername() == "") != (options.BasicAuthPassword() == "") {
+		return errors.New("basic auth needs both a username and a password")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// authOptions configures the authentication checks.
+type authOptions struct {
+	fakeOptions
+	consumerKeys []string
+	accessTokens []string
+	networks     []string
+	username     string
+	password     string
+}
+
+func (o authOptions) AllowedConsumerKeys() []string { return o.consumerKeys }
+func (o authOptions) AllowedAccessTokens() []string { return o.accessTokens }
+func (o authOptions) AllowedNetworks() []string     { return o.networks }
+func (o authOptions) BasicAuthUsername() string     { return o.username }
+func (o authOptions) BasicAuthPassword() string     { return o.password }
+
+// authenticated returns a handler which echoes the request body, to check it's
+// still there after the credentials were read.
+func authenticated(s *server, checks authChecks) http.HandlerFunc {
+	return s.authenticate(checks, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+}
+
+func TestAuthenticate_Credentials(t *testing.T) {
+	s := newTestServer(t, &fakeBackend{})
+	s.options = authOptions{consumerKeys: []string{"kobo-key"}, accessTokens: []string{"kobo-token"}}
+	s.tenants["tenant-token"] = s.defaultAccount
+
+	for _, tc := range []struct {
+		name        string
+		body        string
+		contentType string
+		checks      authChecks
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "JSON", body: `{"consumer_key": "kobo-key", "access_token": "kobo-token"}`, checks: checkAccessToken, wantStatus: http.StatusOK},
+		{name: "form", body: "consumer_key=kobo-key&access_token=kobo-token&url=https://test.com", contentType: "application/x-www-form-urlencoded", checks: checkAccessToken, wantStatus: http.StatusOK},
+		{name: "users file token", body: `{"consumer_key": "kobo-key", "access_token": "tenant-token"}`, checks: checkAccessToken, wantStatus: http.StatusOK},
+		{name: "missing consumer key", body: `{"access_token": "kobo-token"}`, checks: checkAccessToken, wantStatus: http.StatusBadRequest, wantCode: "138"},
+		{name: "invalid consumer key", body: `{"consumer_key": "other", "access_token": "kobo-token"}`, checks: checkAccessToken, wantStatus: http.StatusForbidden, wantCode: "152"},
+		{name: "invalid access token", body: `{"consumer_key": "kobo-key", "access_token": "other"}`, checks: checkAccessToken, wantStatus: http.StatusUnauthorized, wantCode: "107"},
+		{name: "missing access token", body: `{"consumer_key": "kobo-key"}`, checks: checkAccessToken, wantStatus: http.StatusUnauthorized, wantCode: "107"},
+		{name: "logging in needs no access token", body: `{"consumer_key": "kobo-key"}`, checks: checkConsumerKey, wantStatus: http.StatusOK},
+		{name: "signed URLs need no credentials", checks: checkNetwork, wantStatus: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			authenticated(s, tc.checks)(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("Wanted status %d, got %d: %s", tc.wantStatus, rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("X-Error-Code"); got != tc.wantCode {
+				t.Errorf("Wanted error code %q, got %q", tc.wantCode, got)
+			}
+			if tc.wantStatus == http.StatusOK && rec.Body.String() != tc.body {
+				t.Errorf("Wanted the handler to get the body %q, got %q", tc.body, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAuthenticate_BasicAuth(t *testing.T) {
+	s := newTestServer(t, &fakeBackend{})
+	s.options = authOptions{username: "kobo", password: "secret"}
+
+	for _, tc := range []struct {
+		name       string
+		username   string
+		password   string
+		wantStatus int
+	}{
+		{name: "valid", username: "kobo", password: "secret", wantStatus: http.StatusOK},
+		{name: "wrong password", username: "kobo", password: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/auth/authorize", nil)
+			if tc.username != "" {
+				req.SetBasicAuth(tc.username, tc.password)
+			}
+			rec := httptest.NewRecorder()
+			authenticated(s, checkBasicAuth)(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Errorf("Wanted status %d, got %d", tc.wantStatus, rec.Code)
+			}
+			if tc.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Wanted a basic auth challenge")
+			}
+		})
+	}
+
+	// Signed URLs are fetched without the credentials.
+	rec := httptest.NewRecorder()
+	authenticated(s, checkNetwork)(rec, httptest.NewRequest(http.MethodGet, "/v3/image", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Wanted images allowed without basic auth, got status %d", rec.Code)
+	}
+
+	// The Kobo can't send basic auth, so the Pocket API doesn't ask for it.
+	rec = httptest.NewRecorder()
+	authenticated(s, checkAccessToken)(rec, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Wanted the Pocket API allowed without basic auth, got status %d", rec.Code)
+	}
+
+	// Browser pages which take an access token need both.
+	rec = httptest.NewRecorder()
+	authenticated(s, checkBasicAuth|checkAccessToken)(rec, httptest.NewRequest(http.MethodGet, "/export?access_token=a", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Wanted exports to need basic auth, got status %d", rec.Code)
+	}
+}
+
+func TestNetworkAllowed(t *testing.T) {
+	networks := []string{"10.0.0.0/8", "192.168.1.5", "fd00::/8"}
+	for _, tc := range []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{remoteAddr: "10.1.2.3:1234", want: true},
+		{remoteAddr: "192.168.1.5:1234", want: true},
+		{remoteAddr: "192.168.1.6:1234", want: false},
+		{remoteAddr: "[::ffff:10.1.2.3]:1234", want: true},
+		{remoteAddr: "[fd12::1]:1234", want: true},
+		{remoteAddr: "[2001:db8::1]:1234", want: false},
+		{remoteAddr: "invalid", want: false},
+	} {
+		if got := networkAllowed(networks, tc.remoteAddr); got != tc.want {
+			t.Errorf("networkAllowed(%s) = %v, want %v", tc.remoteAddr, got, tc.want)
+		}
+	}
+	if !networkAllowed(nil, "203.0.113.1:1234") {
+		t.Error("Wanted every address allowed without networks")
+	}
+
+	s := newTestServer(t, &fakeBackend{})
+	s.options = authOptions{networks: networks}
+	req := httptest.NewRequest(http.MethodGet, "/v3/image", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	rec := httptest.NewRecorder()
+	authenticated(s, checkNetwork)(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("X-Error") == "" {
+		t.Errorf("Wanted a Pocket error for a disallowed address, got status %d", rec.Code)
+	}
+}
+
+func TestValidateAuthOptions(t *testing.T) {
+	if err := ValidateAuthOptions(authOptions{networks: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("Wanted an error for an invalid network")
+	}
+	if err := ValidateAuthOptions(authOptions{username: "kobo"}); err == nil {
+		t.Error("Wanted an error for basic auth without a password")
+	}
+	if err := ValidateAuthOptions(authOptions{networks: []string{"10.0.0.0/8", "::1"}, username: "kobo", password: "secret"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -15,17 +15,52 @@
 package server
 
 import (
+	"context"
+	"io"
 	"proxyserver/pocketapi"
 	"time"
 )
 
+// Backend is a read-it-later service the Pocket API is translated to. The
+// contexts are cancelled when the device gives up on the request or the
+// operation's timeout passes, and should be passed on to outgoing requests.
 type Backend interface {
-	Get(req pocketapi.GetRequest) (pocketapi.GetResponse, error)
-	ArticleText(url string) (pocketapi.ArticleTextResponse, error)
-	Add(url string, title string, time time.Time) error
-	Archive(itemID string, time time.Time) error
-	Unarchive(itemID string, time time.Time) error
-	Delete(itemID string, time time.Time) error
-	Favorite(itemID string, time time.Time) error
-	Unfavorite(itemID string, time time.Time) error
+	Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error)
+	ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error)
+	// Add saves the URL, returning the ID of its item, or "" if the backend didn't say.
+	Add(ctx context.Context, url string, title string, time time.Time) (string, error)
+	Archive(ctx context.Context, itemID string, time time.Time) error
+	Unarchive(ctx context.Context, itemID string, time time.Time) error
+	Delete(ctx context.Context, itemID string, time time.Time) error
+	Favorite(ctx context.Context, itemID string, time time.Time) error
+	Unfavorite(ctx context.Context, itemID string, time time.Time) error
+}
+
+// TagBackend is implemented by backends which support Pocket tags.
+// Tag names are passed through as-is, it's up to the backend how to store them.
+type TagBackend interface {
+	AddTags(ctx context.Context, itemID string, tags []string, time time.Time) error
+	RemoveTags(ctx context.Context, itemID string, tags []string, time time.Time) error
+	// ReplaceTags sets the item's tags to exactly the given list.
+	ReplaceTags(ctx context.Context, itemID string, tags []string, time time.Time) error
+	ClearTags(ctx context.Context, itemID string, time time.Time) error
+	// RenameTag and DeleteTag apply to every item with the tag.
+	RenameTag(ctx context.Context, oldTag string, newTag string, time time.Time) error
+	DeleteTag(ctx context.Context, tag string, time time.Time) error
+}
+
+// DeletionBackend is implemented by backends which can report items that were
+// deleted outside of the proxy, so they can be removed from devices too.
+type DeletionBackend interface {
+	// DeletedSince returns the IDs of items deleted since the given time.
+	// Backends which can't tell when items were deleted may report each one once instead.
+	DeletedSince(ctx context.Context, since time.Time) ([]string, error)
+}
+
+// ImageBackend is implemented by backends which host their own copies of
+// article images, and need credentials to download them.
+type ImageBackend interface {
+	// OwnsImage returns whether the image is hosted by the backend.
+	OwnsImage(src string) bool
+	FetchImage(ctx context.Context, src string) (io.ReadCloser, error)
 }
@@ -0,0 +1,294 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"proxyserver/epub"
+	"proxyserver/imageproxy"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BookSelection picks the items to put in a book.
+type BookSelection struct {
+	// "unread", "archive" or "all".
+	State string
+	// Only items with this tag, if it's set.
+	Tag string
+	// At most this many items, the oldest first, or all of them if it's 0.
+	Count int
+}
+
+func (selection BookSelection) validate() error {
+	switch selection.State {
+	case "unread", "archive", "all":
+	default:
+		return fmt.Errorf("unknown state %q, expected unread, archive or all", selection.State)
+	}
+	if selection.Count < 0 {
+		return fmt.Errorf("invalid count %d", selection.Count)
+	}
+	return nil
+}
+
+// title names the book after the items in it and the day it was made.
+func (selection BookSelection) title(now time.Time) string {
+	var title string
+	switch {
+	case selection.Tag != "":
+		title = fmt.Sprintf("Articles tagged %s", selection.Tag)
+	case selection.State == "archive":
+		title = "Archived articles"
+	case selection.State == "all":
+		title = "All articles"
+	default:
+		title = "Unread articles"
+	}
+	return fmt.Sprintf("%s, %s", title, now.Format("2 January 2006"))
+}
+
+// fileName is a name to save the book as.
+func (selection BookSelection) fileName(now time.Time) string {
+	name := selection.State
+	if selection.Tag != "" {
+		name = selection.Tag
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	return fmt.Sprintf("pocket-%s-%s.epub", name, now.Format("2006-01-02"))
+}
+
+// bookMaker turns articles into EPUBs, embedding their images.
+type bookMaker struct {
+	options Options
+	images  *imageproxy.Proxy
+	// Whether images are shrunk for e-readers, rather than embedded as they are.
+	transcodeImages bool
+}
+
+func newBookMaker(options Options, images *imageproxy.Proxy) bookMaker {
+	return bookMaker{options: options, images: images, transcodeImages: options.ImageMaxWidth() > 0}
+}
+
+// Book writes an EPUB of the selected items in the backend of the options to out.
+func Book(ctx context.Context, options Options, selection BookSelection, out io.Writer) error {
+	if err := selection.validate(); err != nil {
+		return err
+	}
+	backend, err := newBackend(options)
+	if err != nil {
+		return err
+	}
+	images, err := newImageProxy(options)
+	if err != nil {
+		return fmt.Errorf("unable to set up image proxy: %w", err)
+	}
+
+	book, err := newBookMaker(options, images).selected(ctx, &account{backend: backend}, selection, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Writing a book of %d articles", len(book.Chapters))
+	return book.Write(out)
+}
+
+// selected makes a book of the account's items picked by the selection.
+func (m bookMaker) selected(ctx context.Context, acc *account, selection BookSelection, now time.Time) (*epub.Book, error) {
+	var items []pocketapi.GetResponseItem
+	err := listItems(ctx, acc.backend, m.options.GetTimeout(), pocketapi.GetRequest{State: selection.State, Tag: selection.Tag}, func(page []pocketapi.GetResponseItem) error {
+		items = append(items, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the items: %w", err)
+	}
+	if selection.Count > 0 && len(items) > selection.Count {
+		items = items[:selection.Count]
+	}
+	return m.make(ctx, acc, selection.title(now), items, now)
+}
+
+// make makes a book with a chapter for each of the items' articles, skipping
+// the ones which can't be fetched.
+func (m bookMaker) make(ctx context.Context, acc *account, title string, items []pocketapi.GetResponseItem, now time.Time) (*epub.Book, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no items to put in the book")
+	}
+	book := &epub.Book{Title: title, Author: "Pocket Proxy", Modified: now}
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chapter, images, err := m.chapter(ctx, acc, len(book.Chapters)+1, item)
+		if err != nil {
+			log.Printf("Leaving %s out of the book: %v", item.GivenURL, err)
+			continue
+		}
+		book.Chapters = append(book.Chapters, chapter)
+		book.Images = append(book.Images, images...)
+	}
+	if len(book.Chapters) == 0 {
+		return nil, fmt.Errorf("none of the %d articles could be fetched", len(items))
+	}
+	return book, nil
+}
+
+// chapter fetches the item's article and its images, with the images named
+// after the number of the chapter.
+func (m bookMaker) chapter(ctx context.Context, acc *account, number int, item pocketapi.GetResponseItem) (epub.Chapter, []epub.Image, error) {
+	exported := pocketapi.NewExportItem(item)
+	opCtx, cancel := timeoutContext(ctx, m.options.ArticleTimeout())
+	article, err := acc.articleText(opCtx, exported.URL)
+	cancel()
+	if err != nil {
+		return epub.Chapter{}, nil, err
+	}
+
+	chapter := epub.Chapter{Title: article.Title, URL: exported.URL}
+	if chapter.Title == "" {
+		chapter.Title = exported.Title
+	}
+	if chapter.Title == "" {
+		chapter.Title = exported.URL
+	}
+	images := m.embedImages(ctx, acc, number, &article)
+	chapter.HTML = pocketapi.RenderArticleImages(article)
+	return chapter, images, nil
+}
+
+// embedImages fetches the article's images, pointing them at their files in
+// the book. Images which can't be fetched, or which EPUB readers don't
+// support, are dropped from the article.
+func (m bookMaker) embedImages(ctx context.Context, acc *account, number int, article *pocketapi.ArticleTextResponse) []epub.Image {
+	ids := slices.Sorted(maps.Keys(article.Images))
+	fetched := make([]imageproxy.Image, len(ids))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, imageFetchParallelism)
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			src := article.Images[id].Src
+			img, err := m.fetchImage(ctx, acc, src)
+			if err != nil {
+				log.Printf("Leaving image %s out of the book: %v", src, err)
+				return
+			}
+			if _, supported := epub.ImageExtension(img.ContentType); !supported {
+				log.Printf("Leaving image %s out of the book, e-readers don't support %s", src, img.ContentType)
+				return
+			}
+			fetched[i] = img
+		}()
+	}
+	wg.Wait()
+
+	// Backends may hand out the same article to other callers, so it's changed in a copy.
+	article.Images = maps.Clone(article.Images)
+	var images []epub.Image
+	for i, id := range ids {
+		img := fetched[i]
+		if img.Data == nil {
+			delete(article.Images, id)
+			continue
+		}
+		ext, _ := epub.ImageExtension(img.ContentType)
+		embedded := epub.Image{Name: fmt.Sprintf("images/%d-%d%s", number, len(images)+1, ext), ContentType: img.ContentType, Data: img.Data}
+		pImg := article.Images[id]
+		pImg.Src = embedded.Name
+		if img.Width > 0 {
+			pImg.Width, pImg.Height = strconv.Itoa(img.Width), strconv.Itoa(img.Height)
+		}
+		article.Images[id] = pImg
+		images = append(images, embedded)
+	}
+	return images
+}
+
+func (m bookMaker) fetchImage(ctx context.Context, acc *account, src string) (imageproxy.Image, error) {
+	fetch := backendImageFetcher(acc, src)
+	if fetch == nil {
+		fetch = m.images.Fetch
+	}
+	if m.transcodeImages {
+		return m.images.GetWith(ctx, src, fetch)
+	}
+	return imageproxy.GetOriginal(ctx, src, fetch)
+}
+
+// parseBookSelection reads the selection from the state, tag and count parameters.
+func parseBookSelection(form url.Values) (BookSelection, error) {
+	selection := BookSelection{State: form.Get("state"), Tag: form.Get("tag")}
+	if selection.State == "" {
+		selection.State = "unread"
+	}
+	if count := form.Get("count"); count != "" {
+		var err error
+		if selection.Count, err = strconv.Atoi(count); err != nil {
+			return BookSelection{}, fmt.Errorf("invalid count %q", count)
+		}
+	}
+	return selection, selection.validate()
+}
+
+// epubBook sends an EPUB of the access token's items, all the unread ones by
+// default, or the ones picked by the state, tag and count parameters.
+func (s *server) epubBook(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	acc, err := s.accountFor(r.Form.Get("access_token"))
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	selection, err := parseBookSelection(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	book, err := newBookMaker(s.options, s.images).selected(r.Context(), acc, selection, now)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to make the book: %v", err), backendErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/epub+zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, selection.fileName(now)))
+	if err := book.Write(w); err != nil {
+		log.Printf("Unable to send the book: %v", err)
+	}
+}
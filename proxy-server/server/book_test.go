@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"proxyserver/imageproxy"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBookBackend(t *testing.T) *importBackend {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.png":
+			png.Encode(w, image.NewGray(image.Rect(0, 0, 20, 10)))
+		case "/drawing.svg":
+			io.WriteString(w, `<svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(imageServer.Close)
+
+	backend := newImportBackend()
+	backend.items = map[string]pocketapi.GetResponseItem{
+		"1": {ItemID: "1", GivenURL: "https://a.com", GivenTitle: "A", TimeAdded: "100", Status: "0", Tags: pocketapi.NewTags("1", []string{"commute"})},
+		"2": {ItemID: "2", GivenURL: "https://b.com", GivenTitle: "B", TimeAdded: "200", Status: "0"},
+		"3": {ItemID: "3", GivenURL: "https://c.com", GivenTitle: "C", TimeAdded: "300", Status: "1", Tags: pocketapi.NewTags("3", []string{"commute"})},
+	}
+	backend.article = pocketapi.ArticleTextResponse{
+		Article: "<div><p>Text</p><!--IMG_1--><!--IMG_2--><!--IMG_3--></div>",
+		Images: map[string]pocketapi.Image{
+			"1": {ImageID: "1", Src: imageServer.URL + "/photo.png"},
+			"2": {ImageID: "2", Src: imageServer.URL + "/drawing.svg"},
+			"3": {ImageID: "3", Src: imageServer.URL + "/missing.png"},
+		},
+	}
+	return backend
+}
+
+func TestBookMaker_Selected(t *testing.T) {
+	backend := newBookBackend(t)
+	maker := newBookMaker(fakeOptions{}, imageproxy.New("", 0, []byte("key")))
+	now := time.Date(2025, 3, 4, 7, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		selection BookSelection
+		title     string
+		chapters  []string
+	}{
+		{"Unread", BookSelection{State: "unread"}, "Unread articles, 4 March 2025", []string{"A", "B"}},
+		{"Tagged", BookSelection{State: "all", Tag: "commute"}, "Articles tagged commute, 4 March 2025", []string{"A", "C"}},
+		{"Count", BookSelection{State: "all", Count: 1}, "All articles, 4 March 2025", []string{"A"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			book, err := maker.selected(context.Background(), &account{backend: backend}, tc.selection, now)
+			if err != nil {
+				t.Fatalf("selected() returned error: %v", err)
+			}
+			if book.Title != tc.title {
+				t.Errorf("Unexpected title %q, want %q", book.Title, tc.title)
+			}
+			var chapters []string
+			for _, chapter := range book.Chapters {
+				chapters = append(chapters, chapter.Title)
+			}
+			if strings.Join(chapters, ",") != strings.Join(tc.chapters, ",") {
+				t.Errorf("Unexpected chapters %v, want %v", chapters, tc.chapters)
+			}
+		})
+	}
+}
+
+func TestBookMaker_EmbedsImages(t *testing.T) {
+	backend := newBookBackend(t)
+	maker := newBookMaker(fakeOptions{}, imageproxy.New("", 0, []byte("key")))
+	items := []pocketapi.GetResponseItem{backend.items["1"], backend.items["2"]}
+
+	book, err := maker.make(context.Background(), &account{backend: backend}, "Book", items, time.Now())
+	if err != nil {
+		t.Fatalf("make() returned error: %v", err)
+	}
+	// Only the PNG is embedded, readers don't have to support SVGs, and the last one is missing.
+	if len(book.Images) != 2 || book.Images[0].Name != "images/1-1.png" || book.Images[1].Name != "images/2-1.png" || book.Images[0].ContentType != "image/png" {
+		t.Fatalf("Unexpected images: %+v", book.Images)
+	}
+	if want := `<div><p>Text</p><img src="images/2-1.png" width="20" height="10"/></div>`; book.Chapters[1].HTML != want {
+		t.Errorf("Unexpected chapter HTML %q, want %q", book.Chapters[1].HTML, want)
+	}
+}
+
+func TestServer_EpubBook(t *testing.T) {
+	s := newTestServer(t, newBookBackend(t))
+	s.images = imageproxy.New("", 0, []byte("key"))
+
+	rec := httptest.NewRecorder()
+	s.epubBook(rec, httptest.NewRequest(http.MethodGet, "/epub?tag=commute", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/epub+zip" {
+		t.Fatalf("Unexpected response: [%d] %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="pocket-commute-`) {
+		t.Errorf("Unexpected content disposition %q", got)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("Unable to read the book: %v", err)
+	}
+	chapters := 0
+	for _, f := range reader.File {
+		if strings.HasPrefix(f.Name, "OEBPS/chapter-") {
+			chapters++
+		}
+	}
+	// Only the unread tagged item.
+	if chapters != 1 {
+		t.Errorf("Wanted 1 chapter, got %d", chapters)
+	}
+
+	for _, query := range []string{"state=deleted", "count=-1"} {
+		rec = httptest.NewRecorder()
+		s.epubBook(rec, httptest.NewRequest(http.MethodGet, "/epub?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Wanted status %d for %s, got %d", http.StatusBadRequest, query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"proxyserver/internal/atomicfile"
+	"proxyserver/pocketapi"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// How long cached responses are kept to fall back on when the backend fails.
+	cacheRetention = 30 * 24 * time.Hour
+	// How long refreshing a cached response in the background can take.
+	cacheRevalidateTimeout = time.Minute
+	// How many responses are kept when there's no directory to keep them in.
+	memoryCacheSize = 500
+
+	cacheKindGet     = "get"
+	cacheKindArticle = "article"
+)
+
+type cacheEntry struct {
+	// Unix time the response was stored.
+	Stored int64           `json:"stored"`
+	Value  json.RawMessage `json:"value"`
+}
+
+func (e cacheEntry) age() time.Duration {
+	return time.Since(time.Unix(e.Stored, 0))
+}
+
+// responseCache keeps the last good responses from the backend, so devices can
+// still sync while it's down. Responses are kept as JSON, in a file each if a
+// directory is given, so callers always get their own copy.
+type responseCache struct {
+	dir string
+
+	mu sync.Mutex
+	// Used instead of files when there's no directory.
+	memory map[string]cacheEntry
+	// Keys of memory in insertion order, oldest first.
+	order []string
+	// The keys being refreshed in the background.
+	revalidating map[string]bool
+}
+
+func newResponseCache(dir string) *responseCache {
+	cache := &responseCache{dir: dir, memory: make(map[string]cacheEntry), revalidating: make(map[string]bool)}
+	if dir != "" {
+		cache.prune()
+	}
+	return cache
+}
+
+func (cache *responseCache) path(kind, key string) string {
+	h := sha1.Sum([]byte(key))
+	return filepath.Join(cache.dir, kind, hex.EncodeToString(h[:])+".json")
+}
+
+func (cache *responseCache) load(kind, key string) (cacheEntry, bool) {
+	if cache.dir == "" {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		entry, exists := cache.memory[kind+"/"+key]
+		return entry, exists
+	}
+
+	data, err := os.ReadFile(cache.path(kind, key))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Unable to read cached %s response: %v", kind, err)
+		}
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("Unable to read cached %s response: %v", kind, err)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (cache *responseCache) store(kind, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Unable to cache %s response: %v", kind, err)
+		return
+	}
+	entry := cacheEntry{Stored: time.Now().Unix(), Value: data}
+	if cache.dir == "" {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if _, exists := cache.memory[kind+"/"+key]; !exists {
+			cache.order = append(cache.order, kind+"/"+key)
+		}
+		cache.memory[kind+"/"+key] = entry
+		for len(cache.order) > memoryCacheSize {
+			delete(cache.memory, cache.order[0])
+			cache.order = cache.order[1:]
+		}
+		return
+	}
+
+	data, err = json.Marshal(entry)
+	if err == nil {
+		err = atomicfile.WriteFile(cache.path(kind, key), data)
+	}
+	if err != nil {
+		// Not fatal, there just won't be anything to fall back on.
+		log.Printf("Unable to cache %s response: %v", kind, err)
+	}
+}
+
+// invalidate forgets every response of the kind.
+func (cache *responseCache) invalidate(kind string) {
+	if cache.dir == "" {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		cache.order = slices.DeleteFunc(cache.order, func(key string) bool {
+			if strings.HasPrefix(key, kind+"/") {
+				delete(cache.memory, key)
+				return true
+			}
+			return false
+		})
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(cache.dir, kind)); err != nil {
+		log.Printf("Unable to clear cached %s responses: %v", kind, err)
+	}
+}
+
+// prune removes responses which are too old to fall back on.
+func (cache *responseCache) prune() {
+	cutoff := time.Now().Add(-cacheRetention)
+	filepath.WalkDir(cache.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// startRevalidating marks the key as being refreshed, returning false if it already is.
+func (cache *responseCache) startRevalidating(kind, key string) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.revalidating[kind+"/"+key] {
+		return false
+	}
+	cache.revalidating[kind+"/"+key] = true
+	return true
+}
+
+func (cache *responseCache) doneRevalidating(kind, key string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	delete(cache.revalidating, kind+"/"+key)
+}
+
+// getCacheKey identifies the list a request asks for. The time it asks for
+// changes since is left out, so any earlier list can stand in for it.
+func getCacheKey(req pocketapi.GetRequest) string {
+	req.AccessToken = ""
+	req.ConsumerKey = ""
+	req.Since = nil
+	data, _ := json.Marshal(req)
+	return string(data)
+}
+
+// get lists the account's items. Lists are always fetched from the backend
+// unless they're younger than the TTL, and the last list for the same request
+// is returned if the backend fails. For cached lists, the time they were stored
+// is returned too, as they're missing any changes since; otherwise it's zero.
+func (acc *account) get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, time.Time, error) {
+	if acc.cache == nil {
+		res, err := acc.backend.Get(ctx, req)
+		return res, time.Time{}, err
+	}
+
+	key := getCacheKey(req)
+	entry, cached := acc.cache.load(cacheKindGet, key)
+	if cached && entry.age() < acc.getCacheTTL {
+		var res pocketapi.GetResponse
+		if err := json.Unmarshal(entry.Value, &res); err == nil {
+			return res, time.Unix(entry.Stored, 0), nil
+		}
+	}
+
+	res, err := acc.backend.Get(ctx, req)
+	if err != nil {
+		if cached && entry.age() < cacheRetention && !errors.Is(err, context.Canceled) {
+			var stale pocketapi.GetResponse
+			if jsonErr := json.Unmarshal(entry.Value, &stale); jsonErr == nil {
+				log.Printf("Serving list from %s ago, the backend failed: %v", entry.age().Round(time.Second), err)
+				return stale, time.Unix(entry.Stored, 0), nil
+			}
+		}
+		return pocketapi.GetResponse{}, time.Time{}, err
+	}
+	// Changes since a time are only part of the list, so they'd make a poor stand in.
+	if req.Since == nil {
+		acc.cache.store(cacheKindGet, key, res)
+	}
+	return res, time.Time{}, nil
+}
+
+// articleText returns the article at the URL, from the prefetcher if it has it.
+// Otherwise, articles rarely change, so a cached copy is returned straight away,
+// and refreshed in the background once it's older than the TTL. Cached copies
+// are also returned if the backend fails.
+func (acc *account) articleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	if article, exists := acc.prefetch.get(ctx, url); exists {
+		return article, nil
+	}
+
+	if acc.cache != nil {
+		entry, cached := acc.cache.load(cacheKindArticle, url)
+		var res pocketapi.ArticleTextResponse
+		if cached && json.Unmarshal(entry.Value, &res) == nil {
+			if entry.age() >= acc.articleCacheTTL {
+				acc.revalidateArticle(url)
+			}
+			return res, nil
+		}
+	}
+
+	res, err := acc.fetchArticle(ctx, url)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+	acc.prefetch.store(url, res)
+	return res, nil
+}
+
+// cachedArticleText returns the article at the URL if it's been prefetched or
+// cached, without asking the backend.
+func (acc *account) cachedArticleText(url string) (pocketapi.ArticleTextResponse, bool) {
+	if article, exists := acc.prefetch.peek(url); exists {
+		return article, true
+	}
+	if acc.cache != nil {
+		entry, cached := acc.cache.load(cacheKindArticle, url)
+		var res pocketapi.ArticleTextResponse
+		if cached && json.Unmarshal(entry.Value, &res) == nil {
+			return res, true
+		}
+	}
+	return pocketapi.ArticleTextResponse{}, false
+}
+
+// fetchArticle gets the article at the URL from the backend, and caches it.
+func (acc *account) fetchArticle(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	res, err := acc.backend.ArticleText(ctx, url)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+	if acc.cache != nil {
+		acc.cache.store(cacheKindArticle, url, res)
+	}
+	return res, nil
+}
+
+// revalidateArticle fetches the article again in the background, unless it
+// already is being.
+func (acc *account) revalidateArticle(url string) {
+	if !acc.cache.startRevalidating(cacheKindArticle, url) {
+		return
+	}
+	go func() {
+		defer acc.cache.doneRevalidating(cacheKindArticle, url)
+		ctx, cancel := context.WithTimeout(context.Background(), cacheRevalidateTimeout)
+		defer cancel()
+
+		if _, err := acc.fetchArticle(ctx, url); err != nil {
+			// The cached copy is kept until the backend works again.
+			log.Printf("Unable to refresh cached article %s: %v", url, err)
+		}
+	}()
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"proxyserver/pocketapi"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unreliableBackend fails every call while it's down, and counts the articles fetched.
+type unreliableBackend struct {
+	fakeBackend
+	down           atomic.Bool
+	articleFetches atomic.Int32
+}
+
+func (b *unreliableBackend) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	if b.down.Load() {
+		return pocketapi.GetResponse{}, errors.New("backend is down")
+	}
+	return b.fakeBackend.Get(ctx, req)
+}
+
+func (b *unreliableBackend) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	if b.down.Load() {
+		return pocketapi.ArticleTextResponse{}, errors.New("backend is down")
+	}
+	b.articleFetches.Add(1)
+	return pocketapi.ArticleTextResponse{Title: "Title", ResolvedURL: url}, nil
+}
+
+func TestAccount_GetFallsBackToCache(t *testing.T) {
+	backend := &unreliableBackend{fakeBackend: fakeBackend{items: map[string]pocketapi.GetResponseItem{"1": {ItemID: "1"}}}}
+	acc := &account{backend: backend, tombstones: &tombstoneStore{deleted: map[string]int64{}}, cache: newResponseCache(t.TempDir())}
+	ctx := context.Background()
+
+	if _, _, err := acc.get(ctx, pocketapi.GetRequest{State: "all"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	backend.down.Store(true)
+	since := int64(100)
+	for _, req := range []pocketapi.GetRequest{{State: "all"}, {State: "all", Since: &since}} {
+		res, _, err := acc.get(ctx, req)
+		if err != nil {
+			t.Fatalf("Wanted the cached list, got error %v", err)
+		}
+		if _, exists := res.List["1"]; !exists {
+			t.Errorf("Wanted the cached list, got %+v", res)
+		}
+	}
+	if _, _, err := acc.get(ctx, pocketapi.GetRequest{State: "unread"}); err == nil {
+		t.Error("Wanted an error for a list which was never cached")
+	}
+
+	// Changing an item makes the cached lists out of date.
+	backend.down.Store(false)
+	if err := acc.apply(ctx, pocketapi.SendAction{Action: "archive", ItemID: "1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	backend.down.Store(true)
+	if _, _, err := acc.get(ctx, pocketapi.GetRequest{State: "all"}); err == nil {
+		t.Error("Wanted the cached list to be forgotten after archiving")
+	}
+}
+
+func TestAccount_ArticleStaleWhileRevalidate(t *testing.T) {
+	backend := &unreliableBackend{}
+	dir := t.TempDir()
+	acc := &account{backend: backend, cache: newResponseCache(dir), articleCacheTTL: time.Hour}
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := acc.articleText(ctx, "https://test.com/1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if got := backend.articleFetches.Load(); got != 1 {
+		t.Errorf("Wanted the fresh article to come from the cache, got %d fetches", got)
+	}
+
+	// Once it's stale, the cached copy is returned and refreshed in the background.
+	acc.articleCacheTTL = 0
+	if _, err := acc.articleText(ctx, "https://test.com/1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for backend.articleFetches.Load() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Wanted the article to be refreshed in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The cache survives restarts, and is used while the backend is down.
+	backend.down.Store(true)
+	restarted := &account{backend: backend, cache: newResponseCache(dir), articleCacheTTL: time.Hour}
+	article, err := restarted.articleText(ctx, "https://test.com/1")
+	if err != nil || article.Title != "Title" {
+		t.Errorf("Wanted the cached article, got %+v and error %v", article, err)
+	}
+}
+
+func TestServer_GetServesCachedList(t *testing.T) {
+	backend := &unreliableBackend{fakeBackend: fakeBackend{items: map[string]pocketapi.GetResponseItem{"1": {ItemID: "1"}}}}
+	s := newTestServer(t, backend)
+	cache := newResponseCache("")
+	s.defaultAccount.cache = cache
+
+	get := func() (int, pocketapi.GetResponse) {
+		rec := httptest.NewRecorder()
+		s.getArticles(rec, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(`{"state": "all"}`)))
+		var res pocketapi.GetResponse
+		json.NewDecoder(rec.Body).Decode(&res)
+		return rec.Code, res
+	}
+	if code, _ := get(); code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", code)
+	}
+
+	// Pretend the list was cached an hour ago.
+	stored := time.Now().Add(-time.Hour).Unix()
+	for key, entry := range cache.memory {
+		entry.Stored = stored
+		cache.memory[key] = entry
+	}
+	backend.down.Store(true)
+	code, res := get()
+	if code != http.StatusOK {
+		t.Errorf("Wanted the cached list while the backend is down, got status %d", code)
+	}
+	if int64(res.Since) != stored {
+		t.Errorf("Wanted since to be when the list was cached, %d, got %d", stored, res.Since)
+	}
+
+	// Lists within the TTL are also only as recent as when they were cached.
+	backend.down.Store(false)
+	s.defaultAccount.getCacheTTL = 2 * time.Hour
+	if _, res := get(); int64(res.Since) != stored {
+		t.Errorf("Wanted since to be when the list was cached, %d, got %d", stored, res.Since)
+	}
+}
+
+func TestResponseCache_MemoryEviction(t *testing.T) {
+	cache := newResponseCache("")
+	for i := range memoryCacheSize + 1 {
+		cache.store(cacheKindArticle, strconv.Itoa(i), pocketapi.ArticleTextResponse{})
+	}
+	if _, exists := cache.load(cacheKindArticle, "0"); exists {
+		t.Error("Wanted the oldest entry to be evicted")
+	}
+	if _, exists := cache.load(cacheKindArticle, strconv.Itoa(memoryCacheSize)); !exists {
+		t.Error("Wanted the newest entry to be cached")
+	}
+
+	// Invalidating forgets the keys too, so they don't count towards the limit.
+	cache.store(cacheKindGet, "list", pocketapi.GetResponse{})
+	cache.invalidate(cacheKindGet)
+	if len(cache.order) != memoryCacheSize-1 || len(cache.memory) != memoryCacheSize-1 {
+		t.Errorf("Wanted %d entries, got %d keys and %d entries", memoryCacheSize-1, len(cache.order), len(cache.memory))
+	}
+}
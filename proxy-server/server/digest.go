@@ -0,0 +1,203 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"proxyserver/cron"
+	"proxyserver/imageproxy"
+	"proxyserver/internal/atomicfile"
+	"proxyserver/pocketapi"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Digests are named after when they were made, so they sort by it.
+	digestPrefix     = "digest-"
+	digestTimeLayout = "2006-01-02-1504"
+	digestStateFile  = "digest-state.json"
+	// How far back the first digest goes.
+	firstDigestPeriod = 24 * time.Hour
+)
+
+// digestFormat is a kind of book digests can be made as.
+type digestFormat struct {
+	extension   string
+	contentType string
+	kepub       bool
+}
+
+var digestFormats = map[string]digestFormat{
+	"epub":  {".epub", "application/epub+zip", false},
+	"kepub": {".kepub.epub", "application/kepub+zip", true},
+}
+
+// digestFormatOf returns the format of a digest file, from its name.
+func digestFormatOf(name string) (digestFormat, bool) {
+	// The longest extension first, since a KEPUB's also ends in .epub.
+	for _, format := range []digestFormat{digestFormats["kepub"], digestFormats["epub"]} {
+		if strings.HasPrefix(name, digestPrefix) && strings.HasSuffix(name, format.extension) {
+			return format, true
+		}
+	}
+	return digestFormat{}, false
+}
+
+// digestDir returns the directory digests are kept in, or "" if there's nowhere to keep them.
+func digestDir(options Options) string {
+	if options.DigestDir() != "" {
+		return options.DigestDir()
+	}
+	if options.DataDir() != "" {
+		return filepath.Join(options.DataDir(), "digests")
+	}
+	return ""
+}
+
+// digestState records when the last digest was made, so the next one has the items added since.
+type digestState struct {
+	Last time.Time `json:"last"`
+}
+
+// digester makes books of the account's unread items which were added since the last one.
+type digester struct {
+	options Options
+	maker   bookMaker
+	acc     *account
+	dir     string
+}
+
+func newDigester(options Options, images *imageproxy.Proxy, acc *account) (*digester, error) {
+	dir := digestDir(options)
+	if dir == "" {
+		return nil, errors.New("need to specify --digest_dir or --data_dir to save digests in")
+	}
+	if _, exists := digestFormats[options.DigestFormat()]; !exists {
+		return nil, fmt.Errorf("unknown digest format %q, expected epub or kepub", options.DigestFormat())
+	}
+	return &digester{options: options, maker: newBookMaker(options, images), acc: acc, dir: dir}, nil
+}
+
+// Digest makes a book of the unread items added since the last digest, and saves it in the digest directory.
+func Digest(ctx context.Context, options Options) error {
+	backend, err := newBackend(options)
+	if err != nil {
+		return err
+	}
+	images, err := newImageProxy(options)
+	if err != nil {
+		return fmt.Errorf("unable to set up image proxy: %w", err)
+	}
+	d, err := newDigester(options, images, &account{backend: backend})
+	if err != nil {
+		return err
+	}
+	_, err = d.make(ctx, time.Now())
+	return err
+}
+
+// schedule makes a digest at each time in the schedule, until the context is done.
+func (d *digester) schedule(ctx context.Context, schedule cron.Schedule) {
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("The digest schedule never comes around again, no more digests will be made")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if _, err := d.make(ctx, time.Now()); err != nil {
+			log.Printf("Unable to make a digest: %v", err)
+		}
+	}
+}
+
+// make saves a digest of the unread items added since the last one, returning
+// its path, or "" if there weren't any new items.
+func (d *digester) make(ctx context.Context, now time.Time) (string, error) {
+	statePath := filepath.Join(d.dir, digestStateFile)
+	var state digestState
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return "", fmt.Errorf("unable to read %s: %w", statePath, err)
+		}
+	}
+	since := state.Last
+	if since.IsZero() {
+		since = now.Add(-firstDigestPeriod)
+	}
+
+	var items []pocketapi.GetResponseItem
+	err = listItems(ctx, d.acc.backend, d.options.GetTimeout(), pocketapi.GetRequest{State: "unread"}, func(page []pocketapi.GetResponseItem) error {
+		for _, item := range page {
+			if added, err := strconv.ParseInt(item.TimeAdded, 10, 64); err == nil && added > since.Unix() {
+				items = append(items, item)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to list the items: %w", err)
+	}
+	if len(items) == 0 {
+		log.Printf("No items were added since the last digest, not making one")
+		return "", nil
+	}
+
+	book, err := d.maker.make(ctx, d.acc, fmt.Sprintf("Digest, %s", now.Format("Monday 2 January 2006")), items, now)
+	if err != nil {
+		return "", err
+	}
+	format := digestFormats[d.options.DigestFormat()]
+	book.Kepub = format.kepub
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		return "", err
+	}
+	path := filepath.Join(d.dir, digestPrefix+now.Format(digestTimeLayout)+format.extension)
+	if err := atomicfile.WriteFile(path, buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	// Only once the book's safely saved, so the items aren't lost if it fails.
+	data, err = json.Marshal(digestState{Last: now})
+	if err != nil {
+		return "", err
+	}
+	if err := atomicfile.WriteFile(statePath, data); err != nil {
+		return "", err
+	}
+	log.Printf("Saved a digest of %d articles to %s", len(book.Chapters), path)
+	return path, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"proxyserver/imageproxy"
+	"proxyserver/pocketapi"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type digestOptions struct {
+	fakeOptions
+	dir    string
+	format string
+}
+
+func (o digestOptions) DigestDir() string    { return o.dir }
+func (o digestOptions) DigestFormat() string { return o.format }
+
+// digestChapters returns the number of chapters in the digest.
+func digestChapters(t *testing.T, path string) int {
+	t.Helper()
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Unable to open the digest: %v", err)
+	}
+	defer reader.Close()
+	chapters := 0
+	for _, f := range reader.File {
+		if strings.HasPrefix(f.Name, "OEBPS/chapter-") {
+			chapters++
+		}
+	}
+	return chapters
+}
+
+func TestDigester_Make(t *testing.T) {
+	now := time.Date(2025, 3, 4, 7, 0, 0, 0, time.Local)
+	added := func(ago time.Duration) string { return strconv.FormatInt(now.Add(-ago).Unix(), 10) }
+	backend := newBookBackend(t)
+	backend.items = map[string]pocketapi.GetResponseItem{
+		"1": {ItemID: "1", GivenURL: "https://a.com", TimeAdded: added(2 * time.Hour), Status: "0"},
+		"2": {ItemID: "2", GivenURL: "https://b.com", TimeAdded: added(time.Hour), Status: "0"},
+		// Too old for the first digest, and already read.
+		"3": {ItemID: "3", GivenURL: "https://c.com", TimeAdded: added(48 * time.Hour), Status: "0"},
+		"4": {ItemID: "4", GivenURL: "https://d.com", TimeAdded: added(time.Hour), Status: "1"},
+	}
+	options := digestOptions{dir: t.TempDir(), format: "kepub"}
+	d, err := newDigester(options, imageproxy.New("", 0, []byte("key")), &account{backend: backend})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	path, err := d.make(context.Background(), now)
+	if err != nil {
+		t.Fatalf("make() returned error: %v", err)
+	}
+	if want := filepath.Join(options.dir, "digest-2025-03-04-0700.kepub.epub"); path != want {
+		t.Errorf("Digest saved to %s, want %s", path, want)
+	}
+	if chapters := digestChapters(t, path); chapters != 2 {
+		t.Errorf("Wanted 2 chapters in the first digest, got %d", chapters)
+	}
+
+	// Nothing new the next day.
+	now = now.Add(24 * time.Hour)
+	if path, err := d.make(context.Background(), now); err != nil || path != "" {
+		t.Errorf("make() = %q, %v, wanted no digest", path, err)
+	}
+
+	// Only the new item the day after, even though the last digest was two days ago.
+	now = now.Add(24 * time.Hour)
+	backend.items["5"] = pocketapi.GetResponseItem{ItemID: "5", GivenURL: "https://e.com", TimeAdded: added(30 * time.Hour), Status: "0"}
+	path, err = d.make(context.Background(), now)
+	if err != nil {
+		t.Fatalf("make() returned error: %v", err)
+	}
+	if chapters := digestChapters(t, path); chapters != 1 {
+		t.Errorf("Wanted 1 chapter in the last digest, got %d", chapters)
+	}
+}
+
+func TestNewDigester_Invalid(t *testing.T) {
+	if _, err := newDigester(digestOptions{format: "epub"}, nil, nil); err == nil {
+		t.Error("Wanted an error without a directory to save digests in")
+	}
+	if _, err := newDigester(digestOptions{dir: t.TempDir(), format: "pdf"}, nil, nil); err == nil {
+		t.Error("Wanted an error for an unknown format")
+	}
+}
+
+func TestServer_DigestFeed(t *testing.T) {
+	s := newTestServer(t, &fakeBackend{})
+	s.digestDir = t.TempDir()
+	for name, content := range map[string]string{
+		"digest-2025-03-03-0700.epub":       "first",
+		"digest-2025-03-04-0700.kepub.epub": "second",
+		"digest-state.json":                 "{}",
+		"digest-2025-03-05-0700.epub.tmp1":  "unfinished",
+	} {
+		if err := os.WriteFile(filepath.Join(s.digestDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.digestFeed(rec, httptest.NewRequest(http.MethodGet, "/opds", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/atom+xml") {
+		t.Fatalf("Unexpected response: [%d] %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var feed opdsFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Unable to parse feed: %v\n%s", err, rec.Body.String())
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Wanted 2 entries, got:\n%s", rec.Body.String())
+	}
+	// Newest first.
+	newest := feed.Entries[0]
+	if newest.Title != "Digest, Tuesday 4 March 2025, 07:00" || newest.Links[0].Href != "/opds/digests/digest-2025-03-04-0700.kepub.epub" || newest.Links[0].Type != "application/kepub+zip" {
+		t.Errorf("Unexpected entry: %+v", newest)
+	}
+
+	rec = httptest.NewRecorder()
+	s.digestFile(rec, httptest.NewRequest(http.MethodGet, newest.Links[0].Href, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "second" || rec.Header().Get("Content-Type") != "application/kepub+zip" {
+		t.Errorf("Unexpected digest response: [%d] %s %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	for _, path := range []string{"/opds/digests/digest-state.json", "/opds/digests/..%2Fdigest-x.epub", "/opds/digests/digest-2025-01-01-0700.epub"} {
+		rec = httptest.NewRecorder()
+		s.digestFile(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Wanted status %d for %s, got %d", http.StatusNotFound, path, rec.Code)
+		}
+	}
+}
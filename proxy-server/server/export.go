@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"proxyserver/pocketapi"
+)
+
+// exportStates are listed in turn, so unread items come before archived ones.
+var exportStates = []string{"unread", "archive"}
+
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"html": "text/html; charset=utf-8",
+	"json": "application/json",
+}
+
+// articleFetcher returns the text of the article at the URL.
+type articleFetcher func(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error)
+
+// Export writes every item in the backend of the options to out, in one of
+// pocketapi.ExportFormats. JSON exports also include the text of the articles.
+func Export(ctx context.Context, options Options, format string, out io.Writer) error {
+	writer, err := pocketapi.NewExportWriter(out, format)
+	if err != nil {
+		return err
+	}
+	backend, err := newBackend(options)
+	if err != nil {
+		return err
+	}
+
+	var articleText articleFetcher
+	if format == "json" {
+		articleText = backend.ArticleText
+	}
+	count, err := exportItems(ctx, options, backend, articleText, writer)
+	if err != nil {
+		return err
+	}
+	log.Printf("Exported %d items", count)
+	return nil
+}
+
+// exportItems streams the items in the backend to the writer a page at a time,
+// adding the text of their articles if articleText isn't nil.
+func exportItems(ctx context.Context, options Options, backend Backend, articleText articleFetcher, writer pocketapi.ExportWriter) (int, error) {
+	count := 0
+	for _, state := range exportStates {
+		err := listItems(ctx, backend, options.GetTimeout(), pocketapi.GetRequest{State: state}, func(page []pocketapi.GetResponseItem) error {
+			for _, item := range page {
+				exported := pocketapi.NewExportItem(item)
+				exported.Archived = state == "archive"
+				if articleText != nil {
+					exported.Article = exportArticle(ctx, options, articleText, exported.URL)
+				}
+				if err := writer.Write(exported); err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			return count, fmt.Errorf("unable to list the %s items: %w", state, err)
+		}
+	}
+	return count, writer.Close()
+}
+
+// exportArticle returns the HTML of the article, or "" if it couldn't be
+// fetched, so one broken article doesn't stop the export.
+func exportArticle(ctx context.Context, options Options, articleText articleFetcher, url string) string {
+	opCtx, cancel := timeoutContext(ctx, options.ArticleTimeout())
+	defer cancel()
+	article, err := articleText(opCtx, url)
+	if err != nil {
+		log.Printf("Unable to export the article of %s: %v", url, err)
+		return ""
+	}
+	return pocketapi.RenderArticleImages(article)
+}
+
+// exportList sends the access token's whole reading list as a download, in
+// the format given by the format parameter, CSV by default.
+func (s *server) exportList(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	acc, err := s.accountFor(r.Form.Get("access_token"))
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	format := r.Form.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	writer, err := pocketapi.NewExportWriter(w, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var articleText articleFetcher
+	if format == "json" {
+		articleText = acc.articleText
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="pocket-export.%s"`, format))
+	// The response has started by the time most errors could happen, so they can only be logged.
+	count, err := exportItems(r.Context(), s.options, acc.backend, articleText, writer)
+	if err != nil {
+		log.Printf("Export stopped after %d items: %v", count, err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newExportBackend() *importBackend {
+	backend := newImportBackend()
+	backend.items = map[string]pocketapi.GetResponseItem{
+		"1": {ItemID: "1", GivenURL: "https://a.com", GivenTitle: "A", TimeAdded: "200", Status: "1", Favorite: "1"},
+		"2": {ItemID: "2", GivenURL: "https://b.com", GivenTitle: "B", TimeAdded: "100", Status: "0", Tags: pocketapi.NewTags("2", []string{"news"})},
+		"3": {ItemID: "3", GivenURL: "https://c.com", GivenTitle: "C", TimeAdded: "300", Status: "0"},
+	}
+	backend.article = pocketapi.ArticleTextResponse{
+		Article: "<div><!--IMG_1--></div>",
+		Images:  map[string]pocketapi.Image{"1": {ImageID: "1", Src: "https://a.com/a.png"}},
+	}
+	return backend
+}
+
+func TestExportItems(t *testing.T) {
+	backend := newExportBackend()
+	backend.pageLimit = 1
+
+	for _, withArticles := range []bool{false, true} {
+		var out strings.Builder
+		writer, err := pocketapi.NewExportWriter(&out, "json")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var articleText articleFetcher
+		if withArticles {
+			articleText = backend.ArticleText
+		}
+		count, err := exportItems(context.Background(), fakeOptions{}, backend, articleText, writer)
+		if err != nil || count != 3 {
+			t.Fatalf("exportItems() = %d, %v, want 3 items", count, err)
+		}
+
+		got, err := pocketapi.ParseExport(strings.NewReader(out.String()))
+		if err != nil {
+			t.Fatalf("Unable to parse export: %v\n%s", err, out.String())
+		}
+		article := ""
+		if withArticles {
+			article = `<div><img src="https://a.com/a.png"/></div>`
+		}
+		// Unread items first, each oldest first.
+		want := []pocketapi.ExportItem{
+			{URL: "https://b.com", Title: "B", TimeAdded: time.Unix(100, 0), Tags: []string{"news"}, Article: article},
+			{URL: "https://c.com", Title: "C", TimeAdded: time.Unix(300, 0), Article: article},
+			{URL: "https://a.com", Title: "A", TimeAdded: time.Unix(200, 0), Archived: true, Favorite: true, Article: article},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Export mismatch with articles %t (-want +got):\n%s", withArticles, diff)
+		}
+	}
+}
+
+func TestServer_ExportList(t *testing.T) {
+	s := newTestServer(t, newExportBackend())
+
+	rec := httptest.NewRecorder()
+	s.exportList(rec, httptest.NewRequest(http.MethodGet, "/export?format=csv", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", got)
+	}
+	want := "title,url,time_added,tags,status,favorite\n" +
+		"B,https://b.com,100,news,unread,0\n" +
+		"C,https://c.com,300,,unread,0\n" +
+		"A,https://a.com,200,,archive,1\n"
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Errorf("Export mismatch (-want +got):\n%s", diff)
+	}
+
+	rec = httptest.NewRecorder()
+	s.exportList(rec, httptest.NewRequest(http.MethodGet, "/export?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Wanted status %d for an unknown format, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
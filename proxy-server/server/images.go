@@ -0,0 +1,227 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"proxyserver/imageproxy"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// How many images of an article are fetched at once.
+const imageFetchParallelism = 4
+
+func newImageProxy(options Options) (*imageproxy.Proxy, error) {
+	var proxy *imageproxy.Proxy
+	if options.DataDir() == "" {
+		// Without anywhere to keep the key, image URLs only work until a restart.
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		proxy = imageproxy.New("", options.ImageMaxWidth(), key)
+	} else {
+		key, err := imageproxy.LoadKey(filepath.Join(options.DataDir(), "image-proxy.key"))
+		if err != nil {
+			return nil, err
+		}
+		proxy = imageproxy.New(filepath.Join(options.DataDir(), "images"), options.ImageMaxWidth(), key)
+	}
+	proxy.SetHTTPClient(newPageClient(options))
+	return proxy, nil
+}
+
+// signedImagePayload is what's signed for an image URL. The account key is
+// included for images which have to be fetched with the user's backend credentials.
+func signedImagePayload(src string, accountKey string) string {
+	if accountKey == "" {
+		return src
+	}
+	return src + "\x00" + accountKey
+}
+
+// imageAccountKey returns the key identifying the access token's account in
+// image URLs. Image URLs end up in logs and caches, so they can't carry the
+// access token itself.
+func (s *server) imageAccountKey(accessToken string) string {
+	return s.images.Sign("account\x00" + accessToken)
+}
+
+// imageAccount returns the account an image URL's key was made for. Only the
+// tokens of users from the users file or who logged in have their own account,
+// every other token uses the default one.
+func (s *server) imageAccount(key string) (*account, error) {
+	tokens := slices.Collect(maps.Keys(s.tenants))
+	tokens = append(tokens, s.oauth.accessTokens()...)
+	for _, token := range tokens {
+		if s.images.Verify("account\x00"+token, key) {
+			return s.accountFor(token)
+		}
+	}
+	if s.defaultAccount == nil {
+		return nil, errInvalidAccessToken
+	}
+	return s.defaultAccount, nil
+}
+
+// imageURL returns the URL of the proxied image, on the same host the device
+// used to reach us. The access token is only needed for images which have to be
+// fetched with the user's backend credentials.
+func (s *server) imageURL(r *http.Request, src string, accessToken string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	accountKey := ""
+	if accessToken != "" {
+		accountKey = s.imageAccountKey(accessToken)
+	}
+	query := url.Values{"url": {src}, "sig": {s.images.Sign(signedImagePayload(src, accountKey))}}
+	if accountKey != "" {
+		query.Set("account", accountKey)
+	}
+	return fmt.Sprintf("%s://%s/v3/image?%s", scheme, r.Host, query.Encode())
+}
+
+// backendImageFetcher returns the fetcher for images hosted by the account's
+// backend, or nil if the image is hosted elsewhere.
+func backendImageFetcher(acc *account, src string) imageproxy.Fetcher {
+	if imageBackend, supported := acc.backend.(ImageBackend); supported && imageBackend.OwnsImage(src) {
+		return imageBackend.FetchImage
+	}
+	return nil
+}
+
+// proxyArticleImages points the article's images at the image proxy. Images
+// hosted by the backend always go through the proxy, so it can add the user's
+// credentials. Other images only do if they're being shrunk for the device, in
+// which case they're fetched now to fill in their real sizes, and so they're
+// cached by the time the device asks for them. Images which can't be converted
+// are left as they are.
+func (s *server) proxyArticleImages(ctx context.Context, r *http.Request, acc *account, accessToken string, article *pocketapi.ArticleTextResponse) {
+	if s.images == nil || len(article.Images) == 0 {
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	proxied := make(map[string]pocketapi.Image, len(article.Images))
+	limit := make(chan struct{}, imageFetchParallelism)
+	for id, pImg := range article.Images {
+		fetch := backendImageFetcher(acc, pImg.Src)
+		if fetch == nil && !s.transcodeImages {
+			continue
+		}
+		// Only backend images need the access token to be fetched.
+		token := ""
+		if fetch != nil {
+			token = accessToken
+		}
+		if !s.transcodeImages {
+			pImg.Src = s.imageURL(r, pImg.Src, token)
+			proxied[id] = pImg
+			continue
+		}
+		if fetch == nil {
+			fetch = s.images.Fetch
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			img, err := s.images.GetWith(ctx, pImg.Src, fetch)
+			if err != nil {
+				log.Printf("Unable to proxy image %s: %v", pImg.Src, err)
+				return
+			}
+			pImg.Width = strconv.Itoa(img.Width)
+			pImg.Height = strconv.Itoa(img.Height)
+			pImg.Src = s.imageURL(r, pImg.Src, token)
+
+			mu.Lock()
+			defer mu.Unlock()
+			proxied[id] = pImg
+		}()
+	}
+	wg.Wait()
+
+	for id, pImg := range proxied {
+		article.Images[id] = pImg
+	}
+}
+
+func (s *server) proxyImage(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if s.images == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	src := r.URL.Query().Get("url")
+	accountKey := r.URL.Query().Get("account")
+	if src == "" || !s.images.Verify(signedImagePayload(src, accountKey), r.URL.Query().Get("sig")) {
+		http.Error(w, "Invalid image signature", http.StatusForbidden)
+		return
+	}
+
+	fetch := s.images.Fetch
+	if accountKey != "" {
+		acc, err := s.imageAccount(accountKey)
+		if err != nil {
+			writeAccountError(w, err)
+			return
+		}
+		if backendFetch := backendImageFetcher(acc, src); backendFetch != nil {
+			fetch = backendFetch
+		}
+	}
+
+	ctx, cancel := backendContext(r, s.options.ArticleTimeout())
+	defer cancel()
+	var img imageproxy.Image
+	var err error
+	if s.transcodeImages {
+		img, err = s.images.GetWith(ctx, src, fetch)
+	} else {
+		img, err = imageproxy.GetOriginal(ctx, src, fetch)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to fetch image: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(img.Data)))
+	// The URL is signed and the image never changes, so it can be cached forever,
+	// but images fetched with the user's backend credentials only by the device.
+	if accountKey != "" {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	w.Write(img.Data)
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"proxyserver/imageproxy"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+)
+
+func TestServer_ProxyArticleImages(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/big.png" {
+			http.NotFound(w, r)
+			return
+		}
+		png.Encode(w, image.NewGray(image.Rect(0, 0, 2000, 1000)))
+	}))
+	defer imageServer.Close()
+
+	backend := &fakeBackend{article: pocketapi.ArticleTextResponse{
+		Images: map[string]pocketapi.Image{
+			"1": {ImageID: "1", Src: imageServer.URL + "/big.png"},
+			"2": {ImageID: "2", Src: imageServer.URL + "/missing.png"},
+		},
+	}}
+	s := newTestServer(t, backend)
+	s.images = imageproxy.New(t.TempDir(), 500, []byte("key"))
+	s.transcodeImages = true
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "http://proxy.lan:8080/v3beta/text", strings.NewReader("url=https://test.com/article"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.articleText(rec, r)
+	var article pocketapi.ArticleTextResponse
+	if err := json.NewDecoder(rec.Body).Decode(&article); err != nil {
+		t.Fatalf("Unable to decode response: %v", err)
+	}
+
+	proxied := article.Images["1"]
+	if !strings.HasPrefix(proxied.Src, "http://proxy.lan:8080/v3/image?") || proxied.Width != "500" || proxied.Height != "250" {
+		t.Errorf("Unexpected proxied image: %+v", proxied)
+	}
+	// Images which can't be fetched are left alone.
+	if got := article.Images["2"].Src; got != imageServer.URL+"/missing.png" {
+		t.Errorf("Unexpected source for a missing image: %s", got)
+	}
+
+	// Fetch the image through the proxy.
+	rec = httptest.NewRecorder()
+	s.proxyImage(rec, httptest.NewRequest(http.MethodGet, proxied.Src, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Unexpected image response: [%d] %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil || config.Width != 500 {
+		t.Errorf("Unexpected proxied image: %+v %v", config, err)
+	}
+
+	// Only signed URLs are proxied.
+	query := url.Values{"url": {imageServer.URL + "/big.png"}, "sig": {"00"}}
+	rec = httptest.NewRecorder()
+	s.proxyImage(rec, httptest.NewRequest(http.MethodGet, "/v3/image?"+query.Encode(), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Unexpected status for a bad signature: %d", rec.Code)
+	}
+}
+
+// fakeImageBackend hosts images which need the right credentials to download.
+type fakeImageBackend struct {
+	fakeBackend
+	credentials string
+}
+
+func (b *fakeImageBackend) OwnsImage(src string) bool {
+	return strings.HasPrefix(src, "https://backend.lan/")
+}
+
+func (b *fakeImageBackend) FetchImage(ctx context.Context, src string) (io.ReadCloser, error) {
+	if b.credentials != "alice" {
+		return nil, errors.New("unauthorized")
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 20, 10)))
+	return io.NopCloser(&buf), nil
+}
+
+func TestServer_ProxyBackendImages(t *testing.T) {
+	backend := &fakeImageBackend{
+		fakeBackend: fakeBackend{article: pocketapi.ArticleTextResponse{
+			Images: map[string]pocketapi.Image{
+				"1": {ImageID: "1", Src: "https://backend.lan/bm/1.png"},
+				"2": {ImageID: "2", Src: "https://elsewhere.com/2.png"},
+			},
+		}},
+		credentials: "alice",
+	}
+	s := newTestServer(t, nil)
+	s.images = imageproxy.New("", 0, []byte("key"))
+	s.defaultAccount = nil
+	s.tenants["alice-token"] = &account{backend: backend, tombstones: &tombstoneStore{deleted: map[string]int64{}}}
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "http://proxy.lan/v3beta/text", strings.NewReader("url=https://test.com/article&access_token=alice-token"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.articleText(rec, r)
+	var article pocketapi.ArticleTextResponse
+	if err := json.NewDecoder(rec.Body).Decode(&article); err != nil {
+		t.Fatalf("Unable to decode response: %v", err)
+	}
+
+	// Without transcoding, only the backend's images are proxied.
+	if got := article.Images["2"].Src; got != "https://elsewhere.com/2.png" {
+		t.Errorf("Unexpected source for a third party image: %s", got)
+	}
+	proxied := article.Images["1"].Src
+	if !strings.HasPrefix(proxied, "http://proxy.lan/v3/image?") || !strings.Contains(proxied, "account=") {
+		t.Fatalf("Unexpected source for a backend image: %s", proxied)
+	}
+	// Image URLs end up in logs and caches, so they mustn't give the access token away.
+	if strings.Contains(proxied, "alice-token") {
+		t.Errorf("Wanted the access token left out of the image URL, got %s", proxied)
+	}
+
+	rec = httptest.NewRecorder()
+	s.proxyImage(rec, httptest.NewRequest(http.MethodGet, proxied, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Unexpected image response: [%d] %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if config, err := png.DecodeConfig(bytes.NewReader(rec.Body.Bytes())); err != nil || config.Width != 20 {
+		t.Errorf("Wanted the original image, got %+v %v", config, err)
+	}
+	if got := rec.Header().Get("Cache-Control"); !strings.HasPrefix(got, "private") {
+		t.Errorf("Wanted images fetched with the user's credentials to be private, got Cache-Control %q", got)
+	}
+
+	// The signature covers the account, so it can't be swapped for someone else's.
+	u, err := url.Parse(proxied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("account", s.imageAccountKey("bob-token"))
+	rec = httptest.NewRecorder()
+	s.proxyImage(rec, httptest.NewRequest(http.MethodGet, "/v3/image?"+query.Encode(), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Unexpected status for a swapped account: %d", rec.Code)
+	}
+}
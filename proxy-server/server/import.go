@@ -0,0 +1,367 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"proxyserver/internal/atomicfile"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// How many items are asked for at a time when listing everything in a backend.
+	listPageSize = 100
+	// How many items are imported between saving the progress.
+	importSaveInterval = 20
+)
+
+// importState records which items have been imported into a backend, so an
+// interrupted import carries on where it stopped. It's persisted to disk if a
+// path is given.
+type importState struct {
+	path string
+	// Keyed by URL.
+	items map[string]importedItem
+	// Items changed since the last save.
+	unsaved int
+}
+
+type importedItem struct {
+	// The ID the backend gave the item, once it's been added.
+	ItemID string `json:"item_id,omitempty"`
+	// Whether the item was also archived, favorited and tagged as needed.
+	Done bool `json:"done,omitempty"`
+}
+
+func newImportState(path string) (*importState, error) {
+	state := &importState{path: path, items: make(map[string]importedItem)}
+	if path == "" {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state.items); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// set records the item's progress, saving every few items.
+func (state *importState) set(url string, item importedItem) error {
+	state.items[url] = item
+	state.unsaved++
+	if state.unsaved < importSaveInterval {
+		return nil
+	}
+	return state.save()
+}
+
+func (state *importState) save() error {
+	if state.path == "" || state.unsaved == 0 {
+		return nil
+	}
+	data, err := json.Marshal(state.items)
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(state.path, data); err != nil {
+		return err
+	}
+	state.unsaved = 0
+	return nil
+}
+
+// importFailure is an item which couldn't be imported.
+type importFailure struct {
+	item pocketapi.ExportItem
+	err  error
+}
+
+type importReport struct {
+	// Nothing was changed, imported is what would have been.
+	dryRun   bool
+	imported int
+	// Items which were already in the backend, and were left alone.
+	alreadyPresent int
+	// Items imported by an earlier run.
+	alreadyImported int
+	failures        []importFailure
+}
+
+func (report importReport) print(w io.Writer) {
+	verb := "Imported"
+	if report.dryRun {
+		verb = "Would import"
+	}
+	fmt.Fprintf(w, "%s %d items, skipped %d already in the backend and %d imported before.\n", verb, report.imported, report.alreadyPresent, report.alreadyImported)
+	if len(report.failures) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Unable to import %d items, run the import again to retry them:\n", len(report.failures))
+	for _, failure := range report.failures {
+		url := failure.item.URL
+		if url == "" {
+			url = fmt.Sprintf("(no URL, %q)", failure.item.Title)
+		}
+		fmt.Fprintf(w, "  %s: %v\n", url, failure.err)
+	}
+}
+
+// ImportOptions control how items are imported into a backend.
+type ImportOptions struct {
+	// The file progress is kept in, by default one in the data directory.
+	Checkpoint string
+	// Only report what would be imported, without changing the backend.
+	DryRun bool
+}
+
+// Import adds the items in Pocket export files (CSV or HTML) to the backend in
+// the options, then archives, favorites and tags them to match. Items whose
+// URL is already in the backend are left alone. Progress is kept in a
+// checkpoint file, so running it again after an interruption or failures
+// carries on where it stopped. A report is written to out.
+func Import(ctx context.Context, options Options, paths []string, importOptions ImportOptions, out io.Writer) error {
+	var items []pocketapi.ExportItem
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		fileItems, err := pocketapi.ParseExport(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		items = append(items, fileItems...)
+	}
+
+	backend, err := newBackend(options)
+	if err != nil {
+		return err
+	}
+	return importInto(ctx, options, backend, items, importOptions, out)
+}
+
+// importInto imports the items into the backend, keeping track of the progress and reporting to out.
+func importInto(ctx context.Context, options Options, backend Backend, items []pocketapi.ExportItem, importOptions ImportOptions, out io.Writer) error {
+	statePath := importOptions.Checkpoint
+	if statePath == "" && options.DataDir() != "" {
+		statePath = stateFilePath(options.DataDir(), "import", options.BackendName(), options.BackendEndpoint(), options.BackendBearerToken(), options.BackendUsername())
+	}
+	if statePath == "" {
+		log.Printf("No --data_dir or --checkpoint given, an interrupted import will start over")
+	}
+	state, err := newImportState(statePath)
+	if err != nil {
+		return fmt.Errorf("unable to load import progress: %w", err)
+	}
+
+	report, err := importItems(ctx, options, backend, items, state, importOptions.DryRun)
+	if saveErr := state.save(); saveErr != nil {
+		log.Printf("Unable to save import progress: %v", saveErr)
+	}
+	report.print(out)
+	if err != nil {
+		return err
+	}
+	if len(report.failures) > 0 {
+		return fmt.Errorf("%d items couldn't be imported", len(report.failures))
+	}
+	return nil
+}
+
+func importItems(ctx context.Context, options Options, backend Backend, items []pocketapi.ExportItem, state *importState, dryRun bool) (importReport, error) {
+	report := importReport{dryRun: dryRun}
+	existing, err := listAllItems(ctx, backend, options.GetTimeout())
+	if err != nil {
+		return report, fmt.Errorf("unable to list the items already in the backend: %w", err)
+	}
+	existingURLs := make(map[string]bool, len(existing))
+	for _, item := range existing {
+		existingURLs[item.GivenURL] = true
+		existingURLs[item.ResolvedURL] = true
+	}
+
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if i > 0 && i%100 == 0 {
+			log.Printf("Importing item %d of %d", i+1, len(items))
+		}
+		if item.URL == "" {
+			report.failures = append(report.failures, importFailure{item, errors.New("no URL")})
+			continue
+		}
+		// Exports can list the same URL more than once.
+		if seen[item.URL] {
+			continue
+		}
+		seen[item.URL] = true
+
+		progress, started := state.items[item.URL]
+		switch {
+		case progress.Done:
+			report.alreadyImported++
+			continue
+		case !started && existingURLs[item.URL]:
+			report.alreadyPresent++
+			continue
+		}
+
+		if dryRun {
+			report.imported++
+			continue
+		}
+		if err := importItem(ctx, options, backend, item, progress, state); err != nil {
+			report.failures = append(report.failures, importFailure{item, err})
+			continue
+		}
+		report.imported++
+	}
+	return report, nil
+}
+
+// importItem adds the item, unless an earlier run already did, then archives,
+// favorites and tags it. Progress is recorded in the state after each step.
+func importItem(ctx context.Context, options Options, backend Backend, item pocketapi.ExportItem, progress importedItem, state *importState) error {
+	addTime := item.TimeAdded
+	if addTime.IsZero() {
+		addTime = time.Now()
+	}
+	if progress.ItemID == "" {
+		opCtx, cancel := timeoutContext(ctx, options.SendTimeout())
+		itemID, err := backend.Add(opCtx, item.URL, item.Title, addTime)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("unable to add: %w", err)
+		}
+		progress.ItemID = itemID
+		if err := state.set(item.URL, progress); err != nil {
+			log.Printf("Unable to save import progress: %v", err)
+		}
+	}
+
+	tagBackend, supportsTags := backend.(TagBackend)
+	needsID := item.Archived || item.Favorite || (supportsTags && len(item.Tags) > 0)
+	if needsID && progress.ItemID == "" {
+		return errors.New("added, but the backend didn't return its ID to archive, favorite or tag it")
+	}
+
+	steps := []struct {
+		needed bool
+		name   string
+		do     func(ctx context.Context) error
+	}{
+		{item.Archived, "archive", func(ctx context.Context) error { return backend.Archive(ctx, progress.ItemID, addTime) }},
+		{item.Favorite, "favorite", func(ctx context.Context) error { return backend.Favorite(ctx, progress.ItemID, addTime) }},
+		{supportsTags && len(item.Tags) > 0, "tag", func(ctx context.Context) error {
+			return tagBackend.AddTags(ctx, progress.ItemID, item.Tags, addTime)
+		}},
+	}
+	for _, step := range steps {
+		if !step.needed {
+			continue
+		}
+		opCtx, cancel := timeoutContext(ctx, options.SendTimeout())
+		err := step.do(opCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("added, but unable to %s: %w", step.name, err)
+		}
+	}
+
+	progress.Done = true
+	if err := state.set(item.URL, progress); err != nil {
+		log.Printf("Unable to save import progress: %v", err)
+	}
+	return nil
+}
+
+// listAllItems lists every item in the backend.
+func listAllItems(ctx context.Context, backend Backend, timeout time.Duration) ([]pocketapi.GetResponseItem, error) {
+	var items []pocketapi.GetResponseItem
+	err := listItems(ctx, backend, timeout, pocketapi.GetRequest{State: "all"}, func(page []pocketapi.GetResponseItem) error {
+		items = append(items, page...)
+		return nil
+	})
+	return items, err
+}
+
+// listItems lists the items in the backend matching the request's filters, a
+// page at a time, passing each page to yield oldest first.
+func listItems(ctx context.Context, backend Backend, timeout time.Duration, filter pocketapi.GetRequest, yield func(page []pocketapi.GetResponseItem) error) error {
+	seen := make(map[string]bool)
+	for offset := 0; ; {
+		count := listPageSize
+		req := filter
+		req.DetailType, req.Sort, req.Count, req.Offset = "simple", "oldest", &count, &offset
+		opCtx, cancel := timeoutContext(ctx, timeout)
+		page, err := backend.Get(opCtx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		var items []pocketapi.GetResponseItem
+		for id, item := range page.List {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			items = append(items, item)
+		}
+		// Backends may return fewer items than asked for, so keep going until
+		// a page has nothing new.
+		if len(items) == 0 {
+			return nil
+		}
+		sortOldestFirst(items)
+		if err := yield(items); err != nil {
+			return err
+		}
+		offset += len(page.List)
+	}
+}
+
+// sortOldestFirst sorts items by the time they were added, then by ID.
+func sortOldestFirst(items []pocketapi.GetResponseItem) {
+	slices.SortStableFunc(items, func(a, b pocketapi.GetResponseItem) int {
+		aTime, _ := strconv.ParseInt(a.TimeAdded, 10, 64)
+		bTime, _ := strconv.ParseInt(b.TimeAdded, 10, 64)
+		if c := cmp.Compare(aTime, bTime); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ItemID, b.ItemID)
+	})
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"proxyserver/pocketapi"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// importBackend stores added items, returning at most pageLimit of them at a time.
+type importBackend struct {
+	fakeBackend
+	pageLimit   int
+	adds        int
+	archived    []string
+	favorited   []string
+	tags        map[string][]string
+	failArchive bool
+}
+
+func newImportBackend(urls ...string) *importBackend {
+	b := &importBackend{fakeBackend: fakeBackend{items: make(map[string]pocketapi.GetResponseItem)}, pageLimit: 30, tags: make(map[string][]string)}
+	for _, url := range urls {
+		b.Add(context.Background(), url, "", time.Time{})
+	}
+	b.adds = 0
+	return b
+}
+
+func (b *importBackend) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	ids := slices.SortedFunc(maps.Keys(b.items), func(a, b string) int {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	})
+	ids = slices.DeleteFunc(ids, func(id string) bool {
+		archived := b.items[id].Status == "1"
+		if _, tagged := b.items[id].Tags[req.Tag]; req.Tag != "" && !tagged {
+			return true
+		}
+		return (req.State == "unread" && archived) || (req.State == "archive" && !archived)
+	})
+	offset := min(*req.Offset, len(ids))
+	count := min(*req.Count, b.pageLimit, len(ids)-offset)
+	res := pocketapi.GetResponse{List: make(map[string]pocketapi.GetResponseItem)}
+	for _, id := range ids[offset : offset+count] {
+		res.List[id] = b.items[id]
+	}
+	return res, nil
+}
+
+func (b *importBackend) Add(ctx context.Context, url string, title string, time time.Time) (string, error) {
+	b.adds++
+	id := strconv.Itoa(len(b.items) + 1)
+	b.items[id] = pocketapi.GetResponseItem{ItemID: id, GivenURL: url, GivenTitle: title}
+	return id, nil
+}
+
+func (b *importBackend) Archive(ctx context.Context, itemID string, time time.Time) error {
+	if b.failArchive {
+		return errors.New("archive failed")
+	}
+	b.archived = append(b.archived, itemID)
+	return nil
+}
+
+func (b *importBackend) Favorite(ctx context.Context, itemID string, time time.Time) error {
+	b.favorited = append(b.favorited, itemID)
+	return nil
+}
+
+func (b *importBackend) AddTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	b.tags[itemID] = append(b.tags[itemID], tags...)
+	return nil
+}
+func (b *importBackend) RemoveTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return nil
+}
+func (b *importBackend) ReplaceTags(ctx context.Context, itemID string, tags []string, time time.Time) error {
+	return nil
+}
+func (b *importBackend) ClearTags(ctx context.Context, itemID string, time time.Time) error {
+	return nil
+}
+func (b *importBackend) RenameTag(ctx context.Context, oldTag string, newTag string, time time.Time) error {
+	return nil
+}
+func (b *importBackend) DeleteTag(ctx context.Context, tag string, time time.Time) error { return nil }
+
+// dataDirOptions keeps state in a directory.
+type dataDirOptions struct {
+	fakeOptions
+	dataDir string
+}
+
+func (o dataDirOptions) DataDir() string { return o.dataDir }
+
+func TestImport(t *testing.T) {
+	backend := newImportBackend("https://present.com")
+	items := []pocketapi.ExportItem{
+		{URL: "https://a.com", Title: "A", Tags: []string{"news", "tech"}},
+		{URL: "https://b.com", Title: "B", Archived: true, Favorite: true},
+		{URL: "https://present.com", Title: "Already there", Archived: true},
+		{URL: "https://a.com", Title: "A again"},
+		{Title: "No URL"},
+	}
+
+	var out strings.Builder
+	err := importInto(context.Background(), dataDirOptions{dataDir: t.TempDir()}, backend, items, ImportOptions{}, &out)
+	if err == nil {
+		t.Error("Wanted an error for the item without a URL")
+	}
+
+	if backend.adds != 2 {
+		t.Errorf("Wanted 2 items added, got %d", backend.adds)
+	}
+	if diff := cmp.Diff([]string{"3"}, backend.archived); diff != "" {
+		t.Errorf("Archived mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"3"}, backend.favorited); diff != "" {
+		t.Errorf("Favorited mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string][]string{"2": {"news", "tech"}}, backend.tags); diff != "" {
+		t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+	}
+	for _, want := range []string{"Imported 2 items, skipped 1 already in the backend", `(no URL, "No URL"): no URL`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Wanted the report to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestImport_Resume(t *testing.T) {
+	backend := newImportBackend()
+	backend.failArchive = true
+	options := dataDirOptions{dataDir: t.TempDir()}
+	items := []pocketapi.ExportItem{
+		{URL: "https://a.com", Archived: true},
+		{URL: "https://b.com"},
+	}
+
+	var out strings.Builder
+	if err := importInto(context.Background(), options, backend, items, ImportOptions{}, &out); err == nil {
+		t.Fatal("Wanted an error when archiving fails")
+	}
+	if !strings.Contains(out.String(), "https://a.com: added, but unable to archive: archive failed") {
+		t.Errorf("Wanted the failure reported, got:\n%s", out.String())
+	}
+
+	// The next run only archives the item, without adding it again.
+	backend.failArchive = false
+	out.Reset()
+	if err := importInto(context.Background(), options, backend, items, ImportOptions{}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, out.String())
+	}
+	if backend.adds != 2 {
+		t.Errorf("Wanted each item added once, got %d adds", backend.adds)
+	}
+	if diff := cmp.Diff([]string{"1"}, backend.archived); diff != "" {
+		t.Errorf("Archived mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.Contains(out.String(), "Imported 1 items, skipped 0 already in the backend and 1 imported before") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+func TestListAllItems(t *testing.T) {
+	var urls []string
+	for i := range 75 {
+		urls = append(urls, "https://test.com/"+strconv.Itoa(i))
+	}
+	backend := newImportBackend(urls...)
+
+	items, err := listAllItems(context.Background(), backend, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(items) != 75 {
+		t.Errorf("Wanted all 75 items across pages of 30, got %d", len(items))
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"proxyserver/pocketapi"
+)
+
+// How many items' media are remembered per account. Enough for a large list,
+// since devices ask for the whole list again on every full sync.
+const mediaCacheSize = 5000
+
+// itemMedia is the images and videos of an item's article.
+type itemMedia struct {
+	images map[string]pocketapi.Image
+	videos map[string]pocketapi.Video
+}
+
+// mediaCache remembers the media of items, so the articles don't have to be
+// fetched again on every sync. Entries are keyed by item ID and update time, so
+// edited items are looked at again. The zero value is ready to use.
+type mediaCache struct {
+	mu      sync.Mutex
+	entries map[string]itemMedia
+	// Keys in insertion order, oldest first.
+	order []string
+}
+
+func mediaCacheKey(item pocketapi.GetResponseItem) string {
+	return item.ItemID + "@" + item.TimeUpdated
+}
+
+func (cache *mediaCache) get(key string) (itemMedia, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	media, exists := cache.entries[key]
+	return media, exists
+}
+
+func (cache *mediaCache) put(key string, media itemMedia) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.entries == nil {
+		cache.entries = make(map[string]itemMedia)
+	}
+	if _, exists := cache.entries[key]; !exists {
+		cache.order = append(cache.order, key)
+	}
+	cache.entries[key] = media
+	for len(cache.order) > mediaCacheSize {
+		delete(cache.entries, cache.order[0])
+		cache.order = cache.order[1:]
+	}
+}
+
+// addItemMedia fills in the images and videos of the items, as Pocket does for
+// detailType=complete. Backends don't list them, so they're taken from each
+// item's article. Prefetched or cached articles are used when there are any,
+// and the others are fetched a few at a time.
+func (s *server) addItemMedia(ctx context.Context, r *http.Request, acc *account, accessToken string, res *pocketapi.GetResponse) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	medias := make(map[string]itemMedia, len(res.List))
+	found := func(id string, media itemMedia) {
+		mu.Lock()
+		defer mu.Unlock()
+		medias[id] = media
+	}
+	limit := make(chan struct{}, imageFetchParallelism)
+	for id, item := range res.List {
+		// Deleted items only carry their status.
+		if item.Status == "2" {
+			continue
+		}
+		key := mediaCacheKey(item)
+		if media, exists := acc.media.get(key); exists {
+			found(id, media)
+			continue
+		}
+		if article, available := acc.cachedArticleText(item.GivenURL); available {
+			media := articleMedia(item, article)
+			acc.media.put(key, media)
+			found(id, media)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			article, err := acc.articleText(ctx, item.GivenURL)
+			if err != nil {
+				// The item is still listed, just without its media.
+				log.Printf("Unable to get the media of %s: %v", item.GivenURL, err)
+				return
+			}
+			media := articleMedia(item, article)
+			acc.media.put(key, media)
+			found(id, media)
+		}()
+	}
+	wg.Wait()
+
+	for id, media := range medias {
+		item := res.List[id]
+		item.Images = s.itemImages(r, acc, accessToken, item.ItemID, media.images)
+		item.Videos = media.videos
+		if len(item.Images) > 0 {
+			item.HasImage = "1"
+		}
+		if len(item.Videos) > 0 {
+			item.HasVideo = "1"
+		}
+		res.List[id] = item
+	}
+}
+
+func articleMedia(item pocketapi.GetResponseItem, article pocketapi.ArticleTextResponse) itemMedia {
+	return itemMedia{
+		images: article.Images,
+		videos: pocketapi.ParseVideos(article.Article, item.ItemID),
+	}
+}
+
+// itemImages returns a copy of the cached images for an item, with backend hosted
+// images pointed at the image proxy, as the device can't fetch them itself.
+func (s *server) itemImages(r *http.Request, acc *account, accessToken string, itemID string, images map[string]pocketapi.Image) map[string]pocketapi.Image {
+	if len(images) == 0 {
+		return nil
+	}
+	res := make(map[string]pocketapi.Image, len(images))
+	for id, pImg := range images {
+		pImg.ItemID = itemID
+		if s.images != nil && backendImageFetcher(acc, pImg.Src) != nil {
+			pImg.Src = s.imageURL(r, pImg.Src, accessToken)
+		}
+		res[id] = pImg
+	}
+	return res
+}
+
+func wantsCompleteDetail(req pocketapi.GetRequest) bool {
+	return strings.EqualFold(req.DetailType, "complete")
+}
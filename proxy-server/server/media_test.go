@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"proxyserver/pocketapi"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingBackend counts how many articles were fetched.
+type countingBackend struct {
+	fakeBackend
+	articleFetches atomic.Int32
+	// Returned by ArticleText if set.
+	articleErr error
+}
+
+func (b *countingBackend) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	b.articleFetches.Add(1)
+	if b.articleErr != nil {
+		return pocketapi.ArticleTextResponse{}, b.articleErr
+	}
+	return b.fakeBackend.ArticleText(ctx, url)
+}
+
+func TestServer_GetCompleteMedia(t *testing.T) {
+	backend := &countingBackend{fakeBackend: fakeBackend{
+		items: map[string]pocketapi.GetResponseItem{
+			"1": {ItemID: "1", GivenURL: "https://test.com/1", TimeUpdated: "100", Status: "0", HasImage: "0", HasVideo: "0"},
+			"2": {ItemID: "2", GivenURL: "https://test.com/2", TimeUpdated: "200", Status: "0", HasImage: "0", HasVideo: "0"},
+		},
+		article: pocketapi.ArticleTextResponse{
+			Article: `<div><!--IMG_1--><iframe src="https://www.youtube.com/embed/abc"></iframe></div>`,
+			Images: map[string]pocketapi.Image{
+				"1": {ImageID: "1", Src: "https://test.com/image.png", Width: "10", Height: "10"},
+			},
+		},
+	}}
+	s := newTestServer(t, backend)
+	acc := s.defaultAccount
+	acc.prefetch = newPrefetcher(2, 0, acc.fetchArticle)
+
+	get := func(body string) pocketapi.GetResponse {
+		rec := httptest.NewRecorder()
+		s.getArticles(rec, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", rec.Code)
+		}
+		var res pocketapi.GetResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatalf("Unable to decode response: %v", err)
+		}
+		return res
+	}
+	waitForPrefetch := func(want int32, urls ...string) {
+		waitForFetches(t, backend, want)
+		for _, url := range urls {
+			acc.prefetch.get(context.Background(), url)
+		}
+	}
+
+	res := get(`{"detailType": "simple"}`)
+	if len(res.List["1"].Images) != 0 {
+		t.Errorf("Wanted no images for simple detail, got %v", res.List["1"].Images)
+	}
+	waitForPrefetch(2, "https://test.com/1", "https://test.com/2")
+
+	res = get(`{"detailType": "complete"}`)
+	for _, id := range []string{"1", "2"} {
+		item := res.List[id]
+		wantImages := map[string]pocketapi.Image{
+			"1": {ItemID: id, ImageID: "1", Src: "https://test.com/image.png", Width: "10", Height: "10"},
+		}
+		if diff := cmp.Diff(wantImages, item.Images); diff != "" {
+			t.Errorf("Item %s images mismatch (-want +got):\n%s", id, diff)
+		}
+		wantVideos := map[string]pocketapi.Video{
+			"1": {ItemID: id, VideoID: "1", Src: "https://www.youtube.com/embed/abc", Type: pocketapi.VideoTypeYouTube, Vid: "abc"},
+		}
+		if diff := cmp.Diff(wantVideos, item.Videos); diff != "" {
+			t.Errorf("Item %s videos mismatch (-want +got):\n%s", id, diff)
+		}
+		if item.HasImage != "1" || item.HasVideo != "1" {
+			t.Errorf("Item %s: wanted has_image and has_video to be 1, got %s and %s", id, item.HasImage, item.HasVideo)
+		}
+	}
+	if got := backend.articleFetches.Load(); got != 2 {
+		t.Errorf("Wanted the media to come from the prefetched articles, got %d fetches", got)
+	}
+
+	// Unchanged items come from the media cache, updated ones from the article
+	// prefetched again.
+	item := backend.items["2"]
+	item.TimeUpdated = "300"
+	backend.items["2"] = item
+	get(`{"detailType": "simple"}`)
+	waitForPrefetch(3, "https://test.com/2")
+	res = get(`{"detailType": "complete"}`)
+	if got := backend.articleFetches.Load(); got != 3 {
+		t.Errorf("Wanted 3 articles fetched in total, got %d", got)
+	}
+	if len(res.List["1"].Images) != 1 || len(res.List["2"].Images) != 1 {
+		t.Errorf("Wanted images for both items, got %v and %v", res.List["1"].Images, res.List["2"].Images)
+	}
+
+	// Items whose article isn't available yet have it fetched during the sync,
+	// even without a prefetcher.
+	acc.prefetch = nil
+	backend.items["3"] = pocketapi.GetResponseItem{ItemID: "3", GivenURL: "https://test.com/3", TimeUpdated: "400", Status: "0", HasImage: "0", HasVideo: "0"}
+	res = get(`{"detailType": "complete"}`)
+	if got := backend.articleFetches.Load(); got != 4 {
+		t.Errorf("Wanted only item 3's article fetched during the sync, got %d fetches in total", got)
+	}
+	if item := res.List["3"]; len(item.Images) != 1 || len(item.Videos) != 1 || item.HasImage != "1" {
+		t.Errorf("Wanted item 3 with its media, got %+v", item)
+	}
+
+	// Articles which can't be fetched leave the item without media.
+	backend.items["4"] = pocketapi.GetResponseItem{ItemID: "4", GivenURL: "https://test.com/4", TimeUpdated: "500", Status: "0", HasImage: "0", HasVideo: "0"}
+	backend.articleErr = errors.New("backend down")
+	res = get(`{"detailType": "complete"}`)
+	if item := res.List["4"]; len(item.Images) != 0 || item.HasImage != "0" {
+		t.Errorf("Wanted item 4 without media, got %+v", item)
+	}
+	if len(res.List["3"].Images) != 1 {
+		t.Errorf("Wanted item 3's media from the cache, got %+v", res.List["3"])
+	}
+}
+
+func TestMediaCache_Eviction(t *testing.T) {
+	var cache mediaCache
+	for i := range mediaCacheSize + 1 {
+		cache.put(strings.Repeat("k", i+1), itemMedia{})
+	}
+	if _, exists := cache.get("k"); exists {
+		t.Error("Wanted the oldest entry to be evicted")
+	}
+	if _, exists := cache.get(strings.Repeat("k", mediaCacheSize+1)); !exists {
+		t.Error("Wanted the newest entry to be cached")
+	}
+}
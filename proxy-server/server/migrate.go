@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"proxyserver/pocketapi"
+	"slices"
+)
+
+// Migrate copies every item in the source backend to the destination backend,
+// oldest first, keeping whether they're archived or favorited and their tags.
+// It works like Import, so items already in the destination are left alone and
+// an interrupted migration carries on from its checkpoint.
+func Migrate(ctx context.Context, from Options, to Options, importOptions ImportOptions, out io.Writer) error {
+	source, err := newBackend(from)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the source backend: %w", err)
+	}
+	destination, err := newBackend(to)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the destination backend: %w", err)
+	}
+
+	return migrateBetween(ctx, from, source, to, destination, importOptions, out)
+}
+
+func migrateBetween(ctx context.Context, from Options, source Backend, to Options, destination Backend, importOptions ImportOptions, out io.Writer) error {
+	listed, err := listAllItems(ctx, source, from.GetTimeout())
+	if err != nil {
+		return fmt.Errorf("unable to list the items in the source backend: %w", err)
+	}
+	items := make([]pocketapi.ExportItem, 0, len(listed))
+	for _, item := range listed {
+		items = append(items, pocketapi.NewExportItem(item))
+	}
+	slices.SortStableFunc(items, func(a, b pocketapi.ExportItem) int {
+		return a.TimeAdded.Compare(b.TimeAdded)
+	})
+	log.Printf("Found %d items in %s", len(items), from.BackendName())
+	return importInto(ctx, to, destination, items, importOptions, out)
+}
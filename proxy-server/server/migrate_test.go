@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMigrate(t *testing.T) {
+	source := newImportBackend()
+	source.items = map[string]pocketapi.GetResponseItem{
+		"10": {ItemID: "10", GivenURL: "https://b.com", TimeAdded: "200", Status: "1", Favorite: "1"},
+		"11": {ItemID: "11", GivenURL: "https://a.com", TimeAdded: "100", Status: "0", Tags: pocketapi.NewTags("11", []string{"news"})},
+		"12": {ItemID: "12", GivenURL: "https://present.com", TimeAdded: "300", Status: "0"},
+	}
+	destination := newImportBackend("https://present.com")
+	options := dataDirOptions{dataDir: t.TempDir()}
+
+	var out strings.Builder
+	if err := migrateBetween(context.Background(), fakeOptions{}, source, options, destination, ImportOptions{DryRun: true}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if destination.adds != 0 || !strings.Contains(out.String(), "Would import 2 items, skipped 1 already in the backend") {
+		t.Errorf("Wanted a dry run to change nothing, got %d adds and report:\n%s", destination.adds, out.String())
+	}
+
+	out.Reset()
+	if err := migrateBetween(context.Background(), fakeOptions{}, source, options, destination, ImportOptions{}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, out.String())
+	}
+	// Added oldest first, after the item already there.
+	want := map[string]pocketapi.GetResponseItem{
+		"1": {ItemID: "1", GivenURL: "https://present.com"},
+		"2": {ItemID: "2", GivenURL: "https://a.com"},
+		"3": {ItemID: "3", GivenURL: "https://b.com"},
+	}
+	if diff := cmp.Diff(want, destination.items); diff != "" {
+		t.Errorf("Destination items mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"3"}, destination.archived); diff != "" {
+		t.Errorf("Archived mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"3"}, destination.favorited); diff != "" {
+		t.Errorf("Favorited mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string][]string{"2": {"news"}}, destination.tags); diff != "" {
+		t.Errorf("Tags mismatch (-want +got):\n%s", diff)
+	}
+
+	// Running it again finds everything done.
+	out.Reset()
+	if err := migrateBetween(context.Background(), fakeOptions{}, source, options, destination, ImportOptions{}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if destination.adds != 2 || !strings.Contains(out.String(), "Imported 0 items, skipped 1 already in the backend and 2 imported before") {
+		t.Errorf("Wanted nothing copied again, got %d adds and report:\n%s", destination.adds, out.String())
+	}
+}
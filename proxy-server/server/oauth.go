@@ -0,0 +1,408 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"proxyserver/internal/atomicfile"
+	"proxyserver/pocketapi"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The Pocket OAuth flow is emulated so apps can log in the usual way:
+//  1. The app gets a request token from /v3/oauth/request.
+//  2. The user logs into the backend on the /auth/authorize page, which mints
+//     a backend token for them.
+//  3. The app swaps the request token for an access token at /v3/oauth/authorize,
+//     and the access token is used to find the user's backend token from then on.
+
+// How long the user has to log in after the app requests a token.
+const requestTokenExpiry = 10 * time.Minute
+
+var (
+	errUnknownCode   = errors.New("unknown or expired request token")
+	errNotAuthorized = errors.New("request token hasn't been authorized")
+)
+
+type pendingLogin struct {
+	redirectURI string
+	state       string
+	created     time.Time
+	// Set once the user has logged in.
+	accessToken string
+}
+
+// loggedInUser is what an access token stands for.
+type loggedInUser struct {
+	Username string `json:"username"`
+	// Empty if the backend doesn't support logging in, so the default account is used.
+	BearerToken string    `json:"bearer_token,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+// oauthStore keeps track of request tokens in memory, and access tokens on disk
+// if a path is given.
+type oauthStore struct {
+	mu      sync.Mutex
+	pending map[string]*pendingLogin
+	// Keyed by access token.
+	users map[string]loggedInUser
+	path  string
+}
+
+func newOAuthStore(path string) (*oauthStore, error) {
+	store := &oauthStore{
+		pending: make(map[string]*pendingLogin),
+		users:   make(map[string]loggedInUser),
+		path:    path,
+	}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.users); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (store *oauthStore) newRequestToken(redirectURI, state string) (string, error) {
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for c, p := range store.pending {
+		if time.Since(p.created) > requestTokenExpiry {
+			delete(store.pending, c)
+		}
+	}
+	store.pending[code] = &pendingLogin{redirectURI: redirectURI, state: state, created: time.Now()}
+	return code, nil
+}
+
+// findPending returns the request token's details, if it hasn't expired.
+// Must be called with mu held.
+func (store *oauthStore) findPending(code string) (*pendingLogin, bool) {
+	p, exists := store.pending[code]
+	if !exists || time.Since(p.created) > requestTokenExpiry {
+		return nil, false
+	}
+	return p, true
+}
+
+func (store *oauthStore) pendingLogin(code string) (pendingLogin, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	p, exists := store.findPending(code)
+	if !exists {
+		return pendingLogin{}, false
+	}
+	return *p, true
+}
+
+// completeLogin authorizes the request token, and creates the access token the app will get for it.
+func (store *oauthStore) completeLogin(code string, user loggedInUser) error {
+	accessToken, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	p, exists := store.findPending(code)
+	if !exists {
+		return errUnknownCode
+	}
+	p.accessToken = accessToken
+	store.users[accessToken] = user
+	return store.save()
+}
+
+// authorize swaps an authorized request token for its access token. Request tokens can only be used once.
+func (store *oauthStore) authorize(code string) (string, loggedInUser, pendingLogin, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	p, exists := store.findPending(code)
+	if !exists {
+		return "", loggedInUser{}, pendingLogin{}, errUnknownCode
+	}
+	if p.accessToken == "" {
+		return "", loggedInUser{}, pendingLogin{}, errNotAuthorized
+	}
+	delete(store.pending, code)
+	return p.accessToken, store.users[p.accessToken], *p, nil
+}
+
+func (store *oauthStore) user(accessToken string) (loggedInUser, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	user, exists := store.users[accessToken]
+	return user, exists
+}
+
+// accessTokens returns the access tokens of every logged in user.
+func (store *oauthStore) accessTokens() []string {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return slices.Collect(maps.Keys(store.users))
+}
+
+// save writes the access tokens to disk. Must be called with mu held.
+func (store *oauthStore) save() error {
+	if store.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(store.users)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(store.path, data)
+}
+
+// decodeOAuthBody reads a request sent either as JSON or as form data.
+func decodeOAuthBody(r *http.Request, v any) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	// Go through JSON so the same struct tags apply.
+	fields := make(map[string]string, len(r.PostForm))
+	for k := range r.PostForm {
+		fields[k] = r.PostForm.Get(k)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeOAuthResponse sends the response as JSON if the app asked for it, or as form data otherwise.
+func writeOAuthResponse(w http.ResponseWriter, r *http.Request, res any, form url.Values) {
+	if r.Header.Get("X-Accept") == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			http.Error(w, fmt.Sprintf("Unable to serialize response: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+	w.Write([]byte(form.Encode()))
+}
+
+func (s *server) oauthRequest(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body pocketapi.OAuthRequest
+	if err := decodeOAuthBody(r, &body); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.ConsumerKey == "" {
+		writePocketError(w, http.StatusBadRequest, 138, "Missing consumer key.")
+		return
+	}
+
+	code, err := s.oauth.newRequestToken(body.RedirectURI, body.State)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to create request token: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	form := url.Values{"code": {code}}
+	if body.State != "" {
+		form.Set("state", body.State)
+	}
+	writeOAuthResponse(w, r, pocketapi.OAuthRequestResponse{Code: code, State: body.State}, form)
+}
+
+func (s *server) oauthAuthorize(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body pocketapi.OAuthAuthorizeRequest
+	if err := decodeOAuthBody(r, &body); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.ConsumerKey == "" {
+		writePocketError(w, http.StatusBadRequest, 138, "Missing consumer key.")
+		return
+	}
+	if body.Code == "" {
+		writePocketError(w, http.StatusBadRequest, 182, "Missing code.")
+		return
+	}
+
+	accessToken, user, pending, err := s.oauth.authorize(body.Code)
+	if errors.Is(err, errUnknownCode) {
+		writePocketError(w, http.StatusBadRequest, 185, "Code not found.")
+		return
+	}
+	if errors.Is(err, errNotAuthorized) {
+		writePocketError(w, http.StatusForbidden, 158, "User rejected code.")
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to authorize: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	res := pocketapi.OAuthAuthorizeResponse{AccessToken: accessToken, Username: user.Username, State: pending.state}
+	form := url.Values{"access_token": {accessToken}, "username": {user.Username}}
+	if pending.state != "" {
+		form.Set("state", pending.state)
+	}
+	writeOAuthResponse(w, r, res, form)
+}
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Log in to {{.Backend}}</title>
+</head>
+<body>
+{{if .Done}}
+<p>You're logged in, you can go back to your app now.</p>
+{{else}}
+<h1>Log in to {{.Backend}}</h1>
+{{with .Error}}<p><strong>{{.}}</strong></p>{{end}}
+<form method="post" action="/auth/authorize">
+<input type="hidden" name="request_token" value="{{.RequestToken}}">
+{{if .NeedsLogin}}
+<p><label>Username <input name="username" autocomplete="username" required></label></p>
+<p><label>Password <input type="password" name="password" autocomplete="current-password" required></label></p>
+{{end}}
+<p><button type="submit">Authorize</button></p>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type authorizePageData struct {
+	Backend      string
+	RequestToken string
+	NeedsLogin   bool
+	Error        string
+	Done         bool
+}
+
+func (s *server) renderAuthorizePage(w http.ResponseWriter, statusCode int, data authorizePageData) {
+	data.Backend = s.options.BackendName()
+	data.NeedsLogin = s.login != nil
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := authorizeTemplate.Execute(w, data); err != nil {
+		log.Printf("Unable to render login page: %v", err)
+	}
+}
+
+// authorizePage is where the user logs into the backend to authorize a request token.
+// Backends without a login just need the user to confirm.
+func (s *server) authorizePage(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	code := r.Form.Get("request_token")
+	pending, exists := s.oauth.pendingLogin(code)
+	if !exists {
+		s.renderAuthorizePage(w, http.StatusBadRequest, authorizePageData{Error: "This login link has expired, please try again from your app."})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.renderAuthorizePage(w, http.StatusOK, authorizePageData{RequestToken: code})
+		return
+	case http.MethodPost:
+		// Handled below.
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := loggedInUser{Username: s.options.BackendUsername(), Created: time.Now()}
+	if s.login != nil {
+		username := r.PostForm.Get("username")
+		ctx, cancel := backendContext(r, s.options.SendTimeout())
+		token, err := s.login(ctx, s.options, username, r.PostForm.Get("password"))
+		cancel()
+		if err != nil {
+			log.Printf("Login failed for %s: %v", username, err)
+			s.renderAuthorizePage(w, http.StatusUnauthorized, authorizePageData{RequestToken: code, Error: "Unable to log in, please check your username and password."})
+			return
+		}
+		user.Username = username
+		user.BearerToken = token
+	}
+
+	if err := s.oauth.completeLogin(code, user); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to save login: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// Only the redirect given by the app is used, so the page can't be used to redirect elsewhere.
+	if pending.redirectURI != "" {
+		http.Redirect(w, r, pending.redirectURI, http.StatusSeeOther)
+		return
+	}
+	s.renderAuthorizePage(w, http.StatusOK, authorizePageData{Done: true})
+}
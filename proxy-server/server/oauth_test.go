@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+)
+
+func TestServer_OAuthLogin(t *testing.T) {
+	s := newTestServer(t, nil)
+	// Every device has to log in.
+	s.defaultAccount = nil
+	s.login = func(ctx context.Context, options Options, username, password string) (string, error) {
+		if username != "alice" || password != "secret" {
+			return "", errors.New("wrong password")
+		}
+		return "alice-token", nil
+	}
+	var gotBearerToken string
+	s.newAccount = func(options Options) (*account, error) {
+		gotBearerToken = options.BackendBearerToken()
+		return &account{
+			backend:    &fakeBackend{items: map[string]pocketapi.GetResponseItem{"1": {ItemID: "1"}}},
+			tombstones: &tombstoneStore{deleted: map[string]int64{}},
+		}, nil
+	}
+
+	serve := func(handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		return rec
+	}
+	authorize := func(code string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v3/oauth/authorize", strings.NewReader(url.Values{"consumer_key": {"key"}, "code": {code}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(s.oauthAuthorize, r)
+	}
+	login := func(code, password string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/auth/authorize", strings.NewReader(url.Values{"request_token": {code}, "username": {"alice"}, "password": {password}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(s.authorizePage, r)
+	}
+
+	// Get a request token.
+	r := httptest.NewRequest(http.MethodPost, "/v3/oauth/request", strings.NewReader(`{"consumer_key": "key", "redirect_uri": "app:done", "state": "abc"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Accept", "application/json")
+	rec := serve(s.oauthRequest, r)
+	var requestRes pocketapi.OAuthRequestResponse
+	if err := json.NewDecoder(rec.Body).Decode(&requestRes); err != nil {
+		t.Fatalf("Unable to decode request token response: %v", err)
+	}
+	if requestRes.Code == "" || requestRes.State != "abc" {
+		t.Fatalf("Unexpected request token response: %+v", requestRes)
+	}
+	code := requestRes.Code
+
+	// The login page.
+	rec = serve(s.authorizePage, httptest.NewRequest(http.MethodGet, "/auth/authorize?request_token="+code+"&redirect_uri=https://evil.com", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="password"`) {
+		t.Errorf("Unexpected login page: [%d] %s", rec.Code, rec.Body.String())
+	}
+
+	// The app can't get an access token until the user logs in.
+	if rec := authorize(code); rec.Code != http.StatusForbidden || rec.Header().Get("X-Error-Code") != "158" {
+		t.Errorf("Unexpected response before logging in: [%d] %s", rec.Code, rec.Header().Get("X-Error"))
+	}
+	if rec := login(code, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status for a wrong password: %d", rec.Code)
+	}
+	rec = login(code, "secret")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "app:done" {
+		t.Errorf("Wanted redirect to the app, got [%d] %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = authorize(code)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status authorizing: %d", rec.Code)
+	}
+	form, err := url.ParseQuery(rec.Body.String())
+	if err != nil {
+		t.Fatalf("Unable to parse authorize response: %v", err)
+	}
+	accessToken := form.Get("access_token")
+	if accessToken == "" || form.Get("username") != "alice" || form.Get("state") != "abc" {
+		t.Errorf("Unexpected authorize response: %v", form)
+	}
+	// Request tokens can only be used once.
+	if rec := authorize(code); rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "185" {
+		t.Errorf("Unexpected response reusing a request token: [%d] %s", rec.Code, rec.Header().Get("X-Error"))
+	}
+
+	// The access token now uses the user's backend token.
+	rec = serve(s.getArticles, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(`{"access_token": "`+accessToken+`"}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Unexpected status getting articles: %d", rec.Code)
+	}
+	if gotBearerToken != "alice-token" {
+		t.Errorf("Unexpected backend token, want alice-token got %s", gotBearerToken)
+	}
+
+	rec = serve(s.getArticles, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(`{"access_token": "unknown"}`)))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("X-Error-Code") != "107" {
+		t.Errorf("Unexpected response for an unknown access token: [%d] %s", rec.Code, rec.Header().Get("X-Error"))
+	}
+
+	// Logins survive a restart.
+	reloaded, err := newOAuthStore(s.oauth.path)
+	if err != nil {
+		t.Fatalf("Unable to reload OAuth store: %v", err)
+	}
+	if user, exists := reloaded.user(accessToken); !exists || user.BearerToken != "alice-token" {
+		t.Errorf("Unexpected reloaded user: %+v", user)
+	}
+}
+
+func TestServer_OAuthExpiredLink(t *testing.T) {
+	s := newTestServer(t, &fakeBackend{})
+	rec := httptest.NewRecorder()
+	s.authorizePage(rec, httptest.NewRequest(http.MethodGet, "/auth/authorize?request_token=missing", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected status for an unknown request token: %d", rec.Code)
+	}
+}
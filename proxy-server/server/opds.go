@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const opdsFeedType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+
+// opdsFeed is an OPDS 1.2 acquisition feed, an Atom feed of books to download.
+type opdsFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []opdsLink  `xml:"link"`
+	Entries []opdsEntry `xml:"entry"`
+}
+
+type opdsEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Links   []opdsLink `xml:"link"`
+}
+
+type opdsLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// digestTitle names the digest after the time in its file name.
+func digestTitle(name string, format digestFormat) string {
+	made, err := time.ParseInLocation(digestTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, digestPrefix), format.extension), time.Local)
+	if err != nil {
+		return name
+	}
+	return fmt.Sprintf("Digest, %s", made.Format("Monday 2 January 2006, 15:04"))
+}
+
+// digestFeed lists the digests, newest first, for e-reader apps which can
+// download books from OPDS catalogs.
+func (s *server) digestFeed(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	if s.digestDir == "" {
+		http.NotFound(w, r)
+		return
+	}
+	entries, err := os.ReadDir(s.digestDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, fmt.Sprintf("Unable to list digests: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	feed := opdsFeed{
+		ID:    "urn:pocket-proxy:digests",
+		Title: "Pocket digests",
+		Links: []opdsLink{
+			{Rel: "self", Href: "/opds", Type: opdsFeedType},
+			{Rel: "start", Href: "/opds", Type: opdsFeedType},
+		},
+	}
+	var updated time.Time
+	for _, entry := range slices.Backward(entries) {
+		format, isDigest := digestFormatOf(entry.Name())
+		if !isDigest || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(updated) {
+			updated = info.ModTime()
+		}
+		feed.Entries = append(feed.Entries, opdsEntry{
+			ID:      "urn:pocket-proxy:digest:" + entry.Name(),
+			Title:   digestTitle(entry.Name(), format),
+			Updated: info.ModTime().UTC().Format(time.RFC3339),
+			Links: []opdsLink{
+				{Rel: "http://opds-spec.org/acquisition", Href: "/opds/digests/" + url.PathEscape(entry.Name()), Type: format.contentType},
+			},
+		})
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", opdsFeedType)
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(&feed); err != nil {
+		log.Printf("Unable to write OPDS feed: %v", err)
+	}
+}
+
+// digestFile sends a digest listed in the OPDS feed.
+func (s *server) digestFile(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	name := strings.TrimPrefix(r.URL.Path, "/opds/digests/")
+	format, isDigest := digestFormatOf(name)
+	if s.digestDir == "" || !isDigest || name != filepath.Base(name) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	http.ServeFile(w, r, filepath.Join(s.digestDir, name))
+}
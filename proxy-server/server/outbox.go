@@ -0,0 +1,255 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"proxyserver/httpclient"
+	"proxyserver/internal/atomicfile"
+	"proxyserver/pocketapi"
+	"sync"
+	"time"
+)
+
+const (
+	// How long to wait before replaying queued actions, doubling up to the
+	// maximum while the backend stays unavailable.
+	outboxRetryMin = 5 * time.Second
+	outboxRetryMax = 5 * time.Minute
+	// How long each queued action can take when it's replayed.
+	outboxReplayTimeout = 30 * time.Second
+	// How many failed actions are kept for the user to look at, oldest are dropped first.
+	outboxMaxDeadLetters = 100
+)
+
+type outboxEntry struct {
+	ID     int64                `json:"id"`
+	Action pocketapi.SendAction `json:"action"`
+	// Unix time the action was queued.
+	Queued    int64  `json:"queued"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type outboxState struct {
+	NextID  int64         `json:"next_id"`
+	Pending []outboxEntry `json:"pending"`
+	// Actions the backend rejected when they were replayed.
+	DeadLetters []outboxEntry `json:"dead_letters"`
+}
+
+// outbox keeps /v3/send actions which couldn't be sent because the backend was
+// unavailable, and replays them in order in the background once it's back.
+// The device is told they succeeded, so they're persisted to disk if a path is
+// given, and survive restarts.
+type outbox struct {
+	mu        sync.Mutex
+	state     outboxState
+	path      string
+	apply     func(context.Context, pocketapi.SendAction) error
+	replaying bool
+	// The first delay before replaying, replaceable for tests.
+	retryMin time.Duration
+}
+
+func newOutbox(path string, apply func(context.Context, pocketapi.SendAction) error) (*outbox, error) {
+	box := &outbox{path: path, apply: apply, retryMin: outboxRetryMin}
+	if path == "" {
+		return box, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return box, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &box.state); err != nil {
+		return nil, err
+	}
+	return box, nil
+}
+
+// resume starts replaying the actions which were still queued when the server stopped.
+func (box *outbox) resume() {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	if len(box.state.Pending) > 0 {
+		log.Printf("Replaying %d queued actions", len(box.state.Pending))
+		box.startReplay()
+	}
+}
+
+func (box *outbox) hasPending() bool {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	return len(box.state.Pending) > 0
+}
+
+// enqueue adds the action to the end of the queue, along with the error from
+// trying to send it if there was one.
+func (box *outbox) enqueue(action pocketapi.SendAction, sendErr error) error {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	box.state.NextID++
+	entry := outboxEntry{ID: box.state.NextID, Action: action, Queued: time.Now().Unix()}
+	if sendErr != nil {
+		entry.Attempts = 1
+		entry.LastError = sendErr.Error()
+	}
+	box.state.Pending = append(box.state.Pending, entry)
+	if err := box.save(); err != nil {
+		// Not queued after all, so the device needs to know it failed.
+		box.state.Pending = box.state.Pending[:len(box.state.Pending)-1]
+		return err
+	}
+	box.startReplay()
+	return nil
+}
+
+// snapshot returns a copy of the queued and failed actions.
+func (box *outbox) snapshot() outboxState {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	return outboxState{
+		NextID:      box.state.NextID,
+		Pending:     append([]outboxEntry{}, box.state.Pending...),
+		DeadLetters: append([]outboxEntry{}, box.state.DeadLetters...),
+	}
+}
+
+// startReplay starts replaying in the background, unless it's already running.
+// Must be called with mu held.
+func (box *outbox) startReplay() {
+	if box.replaying {
+		return
+	}
+	box.replaying = true
+	go box.replayLoop()
+}
+
+func (box *outbox) replayLoop() {
+	delay := box.retryMin
+	for {
+		time.Sleep(delay)
+		if box.replay() {
+			box.mu.Lock()
+			// Something may have been queued since the last check.
+			if len(box.state.Pending) == 0 {
+				box.replaying = false
+				box.mu.Unlock()
+				return
+			}
+			box.mu.Unlock()
+			delay = box.retryMin
+			continue
+		}
+		delay = min(delay*2, outboxRetryMax)
+	}
+}
+
+// replay sends the queued actions in order, stopping at the first one the
+// backend is still unavailable for. Actions the backend rejects are moved to
+// the dead letters, so they don't hold up the rest. Returns whether the queue
+// was emptied.
+func (box *outbox) replay() bool {
+	for {
+		box.mu.Lock()
+		if len(box.state.Pending) == 0 {
+			box.mu.Unlock()
+			return true
+		}
+		entry := box.state.Pending[0]
+		box.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), outboxReplayTimeout)
+		err := box.apply(ctx, entry.Action)
+		cancel()
+
+		box.mu.Lock()
+		entry.Attempts++
+		if httpclient.IsUnavailable(err) {
+			entry.LastError = err.Error()
+			box.state.Pending[0] = entry
+			if saveErr := box.save(); saveErr != nil {
+				log.Printf("Unable to save queued actions: %v", saveErr)
+			}
+			box.mu.Unlock()
+			return false
+		}
+
+		box.state.Pending = box.state.Pending[1:]
+		if err != nil {
+			log.Printf("Giving up on queued %s action: %v", entry.Action.Action, err)
+			entry.LastError = err.Error()
+			box.state.DeadLetters = append(box.state.DeadLetters, entry)
+			if over := len(box.state.DeadLetters) - outboxMaxDeadLetters; over > 0 {
+				box.state.DeadLetters = box.state.DeadLetters[over:]
+			}
+		}
+		if saveErr := box.save(); saveErr != nil {
+			log.Printf("Unable to save queued actions: %v", saveErr)
+		}
+		box.mu.Unlock()
+	}
+}
+
+// save writes the outbox to disk. Must be called with mu held.
+func (box *outbox) save() error {
+	if box.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(box.state)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(box.path, data)
+}
+
+type outboxStatusResponse struct {
+	Pending     []outboxEntry `json:"pending"`
+	DeadLetters []outboxEntry `json:"dead_letters"`
+}
+
+// outboxStatus lists the actions of the access token's account which are
+// waiting for the backend, and the ones the backend rejected.
+func (s *server) outboxStatus(w http.ResponseWriter, r *http.Request) {
+	s.log(r)
+	acc, err := s.accountFor(r.URL.Query().Get("access_token"))
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	res := outboxStatusResponse{Pending: []outboxEntry{}, DeadLetters: []outboxEntry{}}
+	if acc.outbox != nil {
+		state := acc.outbox.snapshot()
+		res.Pending = state.Pending
+		res.DeadLetters = state.DeadLetters
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to serialize response: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"proxyserver/httpclient"
+	"proxyserver/pocketapi"
+)
+
+// flakyBackend archives items, unless it's down or the item is rejected.
+type flakyBackend struct {
+	fakeBackend
+	down     bool
+	rejected map[string]bool
+	archived []string
+}
+
+func (b *flakyBackend) Archive(ctx context.Context, itemID string, time time.Time) error {
+	if b.down {
+		return fmt.Errorf("error calling backend: %w", httpclient.ErrUnavailable)
+	}
+	if b.rejected[itemID] {
+		return errors.New("no such item")
+	}
+	b.archived = append(b.archived, itemID)
+	return nil
+}
+
+func newOutboxTestServer(t *testing.T, backend Backend, path string) *server {
+	s := newTestServer(t, backend)
+	box, err := newOutbox(path, s.defaultAccount.apply)
+	if err != nil {
+		t.Fatalf("Unable to create outbox: %v", err)
+	}
+	// Replayed by the tests instead.
+	box.retryMin = time.Hour
+	s.defaultAccount.outbox = box
+	return s
+}
+
+func send(t *testing.T, s *server, body string) pocketapi.SendResponse {
+	rec := httptest.NewRecorder()
+	s.modifyArticles(rec, httptest.NewRequest(http.MethodPost, "/v3/send", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", rec.Code)
+	}
+	var res pocketapi.SendResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("Unable to decode response: %v", err)
+	}
+	return res
+}
+
+func TestServer_SendQueuesWhileUnavailable(t *testing.T) {
+	backend := &flakyBackend{down: true, rejected: map[string]bool{"2": true}}
+	s := newOutboxTestServer(t, backend, filepath.Join(t.TempDir(), "outbox.json"))
+
+	res := send(t, s, `{"actions": [{"action": "archive", "item_id": "1"}]}`)
+	if res.Status != 1 || !res.ActionResults[0] {
+		t.Errorf("Wanted the queued action to be acknowledged, got %+v", res)
+	}
+
+	// Once the backend is back, later actions still wait for the queued ones.
+	backend.down = false
+	send(t, s, `{"actions": [{"action": "archive", "item_id": "2"}, {"action": "archive", "item_id": "3"}]}`)
+	if len(backend.archived) != 0 {
+		t.Errorf("Wanted nothing sent ahead of the queue, got %v", backend.archived)
+	}
+
+	rec := httptest.NewRecorder()
+	s.outboxStatus(rec, httptest.NewRequest(http.MethodGet, "/v3/outbox", nil))
+	var status outboxStatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Unable to decode status: %v", err)
+	}
+	if len(status.Pending) != 3 || status.Pending[0].LastError == "" {
+		t.Errorf("Wanted 3 pending actions, the first with an error, got %+v", status.Pending)
+	}
+
+	if !s.defaultAccount.outbox.replay() {
+		t.Fatal("Wanted the queue to be emptied")
+	}
+	if want := []string{"1", "3"}; !slices.Equal(want, backend.archived) {
+		t.Errorf("Unexpected archived items, want %v got %v", want, backend.archived)
+	}
+	state := s.defaultAccount.outbox.snapshot()
+	if len(state.Pending) != 0 {
+		t.Errorf("Wanted no pending actions, got %+v", state.Pending)
+	}
+	if len(state.DeadLetters) != 1 || state.DeadLetters[0].Action.ItemID != "2" || state.DeadLetters[0].LastError == "" {
+		t.Errorf("Wanted the rejected action to be a dead letter, got %+v", state.DeadLetters)
+	}
+
+	// With nothing queued, actions go straight to the backend again.
+	send(t, s, `{"actions": [{"action": "archive", "item_id": "4"}]}`)
+	if !slices.Contains(backend.archived, "4") {
+		t.Errorf("Wanted item 4 to be archived directly, got %v", backend.archived)
+	}
+}
+
+func TestServer_SendRejectedNotQueued(t *testing.T) {
+	backend := &flakyBackend{rejected: map[string]bool{"1": true}}
+	s := newOutboxTestServer(t, backend, "")
+
+	res := send(t, s, `{"actions": [{"action": "archive", "item_id": "1"}]}`)
+	if res.Status != 0 || res.ActionResults[0] {
+		t.Errorf("Wanted the rejected action to fail, got %+v", res)
+	}
+	if s.defaultAccount.outbox.hasPending() {
+		t.Error("Wanted the rejected action not to be queued")
+	}
+}
+
+func TestOutbox_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "outbox.json")
+	backend := &flakyBackend{down: true}
+	s := newOutboxTestServer(t, backend, path)
+	send(t, s, `{"actions": [{"action": "archive", "item_id": "1"}, {"action": "archive", "item_id": "2"}]}`)
+	if s.defaultAccount.outbox.replay() {
+		t.Error("Wanted the queue to stay while the backend is down")
+	}
+
+	backend.down = false
+	restarted := newOutboxTestServer(t, backend, path)
+	state := restarted.defaultAccount.outbox.snapshot()
+	if len(state.Pending) != 2 || state.Pending[0].Attempts != 2 {
+		t.Fatalf("Wanted 2 pending actions after restarting, got %+v", state.Pending)
+	}
+	restarted.defaultAccount.outbox.replay()
+	if want := []string{"1", "2"}; !slices.Equal(want, backend.archived) {
+		t.Errorf("Unexpected archived items, want %v got %v", want, backend.archived)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"log"
+	"maps"
+	"proxyserver/pocketapi"
+	"sync"
+	"time"
+)
+
+const (
+	// How many prefetched articles are kept per account. Devices fetch the
+	// articles straight after the list, so only the latest few are needed.
+	prefetchCacheSize = 250
+	// How many items can wait to be prefetched. Items beyond that are left for
+	// devices to fetch themselves.
+	prefetchQueueSize = 1000
+)
+
+// prefetcher fetches the articles of listed items in the background, before
+// devices ask for them. Articles are keyed by item ID and update time, like the
+// media cache, so edited items are fetched again. A nil prefetcher does nothing.
+type prefetcher struct {
+	fetch   func(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error)
+	timeout time.Duration
+	queue   chan pocketapi.GetResponseItem
+
+	mu       sync.Mutex
+	articles map[string]pocketapi.ArticleTextResponse
+	// Keys in insertion order, oldest first.
+	order []string
+	// The key of the latest listed version of each item, by URL. Only items
+	// which are queued, being fetched or cached are kept, so it doesn't grow with
+	// the whole library.
+	urls map[string]string
+	// The URLs pointing at each key in urls.
+	keyURLs map[string][]string
+	// Keys waiting to be fetched.
+	queued map[string]bool
+	// Keys being fetched, with a channel closed once they're done.
+	fetching map[string]chan struct{}
+}
+
+// newPrefetcher starts the given number of workers fetching articles with fetch,
+// each given up to timeout, or returns nil if there are no workers.
+func newPrefetcher(workers int, timeout time.Duration, fetch func(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error)) *prefetcher {
+	if workers <= 0 {
+		return nil
+	}
+	p := &prefetcher{
+		fetch:    fetch,
+		timeout:  timeout,
+		queue:    make(chan pocketapi.GetResponseItem, prefetchQueueSize),
+		articles: make(map[string]pocketapi.ArticleTextResponse),
+		urls:     make(map[string]string),
+		keyURLs:  make(map[string][]string),
+		queued:   make(map[string]bool),
+		fetching: make(map[string]chan struct{}),
+	}
+	for range workers {
+		go p.work()
+	}
+	return p
+}
+
+// enqueue queues the articles of the listed items which haven't been fetched yet.
+func (p *prefetcher) enqueue(items map[string]pocketapi.GetResponseItem) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, item := range items {
+		// Deleted items only carry their status.
+		if item.Status == "2" || item.GivenURL == "" {
+			continue
+		}
+		key := mediaCacheKey(item)
+		if _, exists := p.articles[key]; exists || p.queued[key] || p.fetching[key] != nil {
+			p.track(item, key)
+			continue
+		}
+		select {
+		case p.queue <- item:
+			p.queued[key] = true
+			p.track(item, key)
+		default:
+			// The queue is full, so devices will have to wait for the rest.
+			return
+		}
+	}
+}
+
+func (p *prefetcher) work() {
+	for item := range p.queue {
+		key := mediaCacheKey(item)
+		p.mu.Lock()
+		delete(p.queued, key)
+		// The device may have asked for it in the meantime.
+		if _, exists := p.articles[key]; exists {
+			p.mu.Unlock()
+			continue
+		}
+		// Or the item may have been edited since.
+		if p.urls[item.GivenURL] != key {
+			p.forget(key)
+			p.mu.Unlock()
+			continue
+		}
+		done := make(chan struct{})
+		p.fetching[key] = done
+		p.mu.Unlock()
+
+		ctx, cancel := timeoutContext(context.Background(), p.timeout)
+		article, err := p.fetch(ctx, item.GivenURL)
+		cancel()
+		if err != nil {
+			// Not fatal, the device will ask for it anyway.
+			log.Printf("Unable to prefetch article of item %s: %v", item.ItemID, err)
+		}
+
+		p.mu.Lock()
+		if err == nil {
+			p.put(key, article)
+		} else {
+			p.forget(key)
+		}
+		delete(p.fetching, key)
+		close(done)
+		p.mu.Unlock()
+	}
+}
+
+// get returns the prefetched article for the URL, waiting for it if it's being
+// fetched.
+func (p *prefetcher) get(ctx context.Context, url string) (pocketapi.ArticleTextResponse, bool) {
+	if p == nil {
+		return pocketapi.ArticleTextResponse{}, false
+	}
+	p.mu.Lock()
+	key, known := p.urls[url]
+	done := p.fetching[key]
+	p.mu.Unlock()
+	if !known {
+		return pocketapi.ArticleTextResponse{}, false
+	}
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return pocketapi.ArticleTextResponse{}, false
+		}
+	}
+
+	return p.peek(url)
+}
+
+// peek returns the prefetched article for the URL, without waiting for it.
+func (p *prefetcher) peek(url string) (pocketapi.ArticleTextResponse, bool) {
+	if p == nil {
+		return pocketapi.ArticleTextResponse{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	article, exists := p.articles[p.urls[url]]
+	if !exists {
+		return pocketapi.ArticleTextResponse{}, false
+	}
+	// Images are rewritten for each device, so they mustn't be shared.
+	article.Images = maps.Clone(article.Images)
+	return article, true
+}
+
+// store keeps an article fetched for a device, if its item has been listed, so
+// it isn't prefetched again.
+func (p *prefetcher) store(url string, article pocketapi.ArticleTextResponse) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, known := p.urls[url]; known {
+		article.Images = maps.Clone(article.Images)
+		p.put(key, article)
+	}
+}
+
+// put adds the article to the cache, which must be locked.
+func (p *prefetcher) put(key string, article pocketapi.ArticleTextResponse) {
+	if _, exists := p.articles[key]; !exists {
+		p.order = append(p.order, key)
+	}
+	p.articles[key] = article
+	for len(p.order) > prefetchCacheSize {
+		delete(p.articles, p.order[0])
+		p.forget(p.order[0])
+		p.order = p.order[1:]
+	}
+}
+
+// track points the item's URLs at its key. The prefetcher must be locked.
+func (p *prefetcher) track(item pocketapi.GetResponseItem, key string) {
+	urls := []string{item.GivenURL}
+	if item.ResolvedURL != "" && item.ResolvedURL != item.GivenURL {
+		urls = append(urls, item.ResolvedURL)
+	}
+	for _, url := range urls {
+		p.urls[url] = key
+	}
+	p.keyURLs[key] = urls
+}
+
+// forget removes the key's URLs, unless they point at a newer version of the
+// item by now. The prefetcher must be locked.
+func (p *prefetcher) forget(key string) {
+	for _, url := range p.keyURLs[key] {
+		if p.urls[url] == key {
+			delete(p.urls, url)
+		}
+	}
+	delete(p.keyURLs, key)
+}
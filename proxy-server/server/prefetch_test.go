@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"proxyserver/pocketapi"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForFetches waits until the backend has fetched the given number of articles.
+func waitForFetches(t *testing.T, backend *countingBackend, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for backend.articleFetches.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Wanted %d articles fetched, got %d", want, backend.articleFetches.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_PrefetchArticles(t *testing.T) {
+	backend := &countingBackend{fakeBackend: fakeBackend{
+		items: map[string]pocketapi.GetResponseItem{
+			"1": {ItemID: "1", GivenURL: "https://test.com/1", TimeUpdated: "100", Status: "0"},
+			"2": {ItemID: "2", GivenURL: "https://test.com/2", TimeUpdated: "200", Status: "0"},
+			"3": {ItemID: "3", GivenURL: "https://test.com/3", TimeUpdated: "300", Status: "2"},
+		},
+		article: pocketapi.ArticleTextResponse{
+			Title:  "Title",
+			Images: map[string]pocketapi.Image{"1": {ImageID: "1", Src: "https://test.com/image.png"}},
+		},
+	}}
+	s := newTestServer(t, backend)
+	acc := s.defaultAccount
+	acc.prefetch = newPrefetcher(2, 0, acc.fetchArticle)
+
+	get := func() {
+		rec := httptest.NewRecorder()
+		s.getArticles(rec, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(`{"state": "unread"}`)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", rec.Code)
+		}
+	}
+	articleText := func(articleURL string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v3beta/text", strings.NewReader(url.Values{"url": {articleURL}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.articleText(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", rec.Code)
+		}
+	}
+
+	get()
+	waitForFetches(t, backend, 2)
+	articleText("https://test.com/1")
+	articleText("https://test.com/2")
+	if got := backend.articleFetches.Load(); got != 2 {
+		t.Errorf("Wanted the articles to come from the prefetcher, got %d fetches", got)
+	}
+
+	// Devices get their own copy of the images to rewrite.
+	article, _ := acc.prefetch.get(context.Background(), "https://test.com/1")
+	article.Images["1"] = pocketapi.Image{Src: "changed"}
+	if article, _ := acc.prefetch.get(context.Background(), "https://test.com/1"); article.Images["1"].Src != "https://test.com/image.png" {
+		t.Errorf("Wanted the prefetched images unchanged, got %v", article.Images)
+	}
+
+	// Only the edited item is fetched again.
+	item := backend.items["1"]
+	item.TimeUpdated = "150"
+	backend.items["1"] = item
+	get()
+	waitForFetches(t, backend, 3)
+	articleText("https://test.com/1")
+	time.Sleep(50 * time.Millisecond)
+	if got := backend.articleFetches.Load(); got != 3 {
+		t.Errorf("Wanted only the edited article fetched again, got %d fetches", got)
+	}
+}
+
+func TestPrefetcher_WaitsForFetch(t *testing.T) {
+	var fetches atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p := newPrefetcher(1, 0, func(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+		fetches.Add(1)
+		close(started)
+		<-release
+		return pocketapi.ArticleTextResponse{Title: "Title"}, nil
+	})
+
+	p.enqueue(map[string]pocketapi.GetResponseItem{"1": {ItemID: "1", GivenURL: "https://test.com/1", TimeUpdated: "100"}})
+	<-started
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	article, exists := p.get(context.Background(), "https://test.com/1")
+	if !exists || article.Title != "Title" {
+		t.Errorf("Wanted the article being prefetched, got %+v", article)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("Wanted the article fetched once, got %d", got)
+	}
+
+	if _, exists := p.get(context.Background(), "https://test.com/2"); exists {
+		t.Error("Wanted no article for an item which wasn't listed")
+	}
+}
+
+func TestPrefetcher_ForgetsEvictedURLs(t *testing.T) {
+	var fetches atomic.Int32
+	p := newPrefetcher(1, 0, func(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+		fetches.Add(1)
+		return pocketapi.ArticleTextResponse{Title: "Title"}, nil
+	})
+
+	items := make(map[string]pocketapi.GetResponseItem)
+	for i := range prefetchCacheSize + 10 {
+		id := strconv.Itoa(i)
+		items[id] = pocketapi.GetResponseItem{ItemID: id, GivenURL: "https://test.com/" + id, TimeUpdated: "100"}
+	}
+	p.enqueue(items)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		done := len(p.queued) == 0 && len(p.fetching) == 0
+		p.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Wanted every article prefetched, got %d fetches", fetches.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.urls) != prefetchCacheSize || len(p.keyURLs) != prefetchCacheSize {
+		t.Errorf("Wanted only the URLs of the %d cached articles kept, got %d URLs and %d keys", prefetchCacheSize, len(p.urls), len(p.keyURLs))
+	}
+}
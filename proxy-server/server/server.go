@@ -15,14 +15,27 @@
 package server
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
+	"proxyserver/cron"
+	"proxyserver/httpclient"
+	"proxyserver/imageproxy"
+	"proxyserver/karakeep"
+	"proxyserver/linkding"
+	"proxyserver/local"
 	"proxyserver/pocketapi"
 	"proxyserver/readeck"
+	"proxyserver/wallabag"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -32,10 +45,87 @@ type Options interface {
 	BackendName() string
 	BackendEndpoint() string
 	BackendBearerToken() string
+	BackendClientID() string
+	BackendClientSecret() string
+	BackendUsername() string
+	BackendPassword() string
+	// The directory where the server keeps its state, like the local backend's database.
+	DataDir() string
+	// A JSON file mapping access tokens to each user's backend, or empty to
+	// use the backend options for everyone.
+	UsersFile() string
+	// The width article images are shrunk to, or 0 to link to the original images.
+	ImageMaxWidth() int
+	// How long each kind of request can wait on the backend, or 0 for no limit.
+	GetTimeout() time.Duration
+	SendTimeout() time.Duration
+	ArticleTimeout() time.Duration
+	// How many times failed backend requests are retried.
+	BackendRetries() int
+	// How many backend requests in a row have to fail before the backend is
+	// assumed to be down, or 0 to always keep trying.
+	BackendFailureThreshold() int
+	// How long lists and articles from the backend are used before asking it again.
+	GetCacheTTL() time.Duration
+	ArticleCacheTTL() time.Duration
+	// How many articles of listed items are fetched at once in the background,
+	// before devices ask for them, or 0 not to.
+	PrefetchWorkers() int
+	// When digest books are made, as a cron schedule, or empty to not make them.
+	DigestSchedule() string
+	// Where digest books are saved, or empty for a directory in DataDir.
+	DigestDir() string
+	// "epub", or "kepub" for Kobo e-readers.
+	DigestFormat() string
+	// Which Pocket consumer keys and access tokens are accepted, or empty to
+	// accept any. Access tokens from the users file or logging in are always accepted.
+	AllowedConsumerKeys() []string
+	AllowedAccessTokens() []string
+	// Which client addresses are accepted, as CIDR prefixes or single
+	// addresses, or empty to accept any.
+	AllowedNetworks() []string
+	// The HTTP basic auth credentials clients need, or empty if they don't.
+	BasicAuthUsername() string
+	BasicAuthPassword() string
 }
 
 type backendInit func(Options) (Backend, error)
 
+// stateFilePath returns a path in dataDir for a JSON state file, which is unique
+// to the given key parts (e.g. the backend endpoint and credentials).
+func stateFilePath(dataDir string, prefix string, keyParts ...string) string {
+	return filepath.Join(dataDir, fmt.Sprintf("%s-%s.json", prefix, stateKey(keyParts...)))
+}
+
+// stateKey returns a short hash identifying the key parts.
+func stateKey(keyParts ...string) string {
+	h := sha1.New()
+	for _, p := range keyParts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// newBackendClient returns the HTTP client a backend calls its API with.
+func newBackendClient(options Options) *httpclient.Client {
+	clientOptions := httpclient.DefaultOptions()
+	clientOptions.MaxRetries = options.BackendRetries()
+	clientOptions.FailureThreshold = options.BackendFailureThreshold()
+	return httpclient.New(clientOptions)
+}
+
+// newPageClient returns the HTTP client web pages and images are downloaded
+// with. It retries like the backend client, but never stops sending requests,
+// since they go to many different sites and one being down says nothing about
+// the others.
+func newPageClient(options Options) *httpclient.Client {
+	clientOptions := httpclient.DefaultOptions()
+	clientOptions.MaxRetries = options.BackendRetries()
+	clientOptions.FailureThreshold = 0
+	return httpclient.New(clientOptions)
+}
+
 func initReadeck(options Options) (Backend, error) {
 	if options.BackendEndpoint() == "" {
 		return nil, errors.New("need to specify --backend_endpoint when using a Readeck backend")
@@ -43,11 +133,93 @@ func initReadeck(options Options) (Backend, error) {
 	if options.BackendBearerToken() == "" {
 		return nil, errors.New("need to specify --backend_bearer_token when using a Readeck backend")
 	}
-	return readeck.NewReadeckConn(options.BackendEndpoint(), options.BackendBearerToken()), nil
+	var conn *readeck.ReadeckConn
+	if options.DataDir() == "" {
+		conn = readeck.NewReadeckConn(options.BackendEndpoint(), options.BackendBearerToken())
+	} else {
+		indexPath := stateFilePath(options.DataDir(), "readeck-index", options.BackendEndpoint(), options.BackendBearerToken())
+		var err error
+		conn, err = readeck.NewReadeckConnWithIndex(options.BackendEndpoint(), options.BackendBearerToken(), indexPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	conn.SetHTTPClient(newBackendClient(options))
+	// Fill in the index in the background, so the server can start right away.
+	go func() {
+		if err := conn.WarmIndex(context.Background()); err != nil {
+			log.Printf("Unable to warm up Readeck URL index: %v", err)
+		}
+	}()
+	return conn, nil
+}
+
+func initWallabag(options Options) (Backend, error) {
+	if options.BackendEndpoint() == "" {
+		return nil, errors.New("need to specify --backend_endpoint when using a Wallabag backend")
+	}
+	if options.BackendClientID() == "" || options.BackendClientSecret() == "" {
+		return nil, errors.New("need to specify --backend_client_id and --backend_client_secret when using a Wallabag backend")
+	}
+	if options.BackendUsername() == "" || options.BackendPassword() == "" {
+		return nil, errors.New("need to specify --backend_username and --backend_password when using a Wallabag backend")
+	}
+	conn := wallabag.NewWallabagConn(
+		options.BackendEndpoint(),
+		options.BackendClientID(),
+		options.BackendClientSecret(),
+		options.BackendUsername(),
+		options.BackendPassword(),
+	)
+	conn.SetHTTPClient(newBackendClient(options))
+	return conn, nil
+}
+
+func initKarakeep(options Options) (Backend, error) {
+	if options.BackendEndpoint() == "" {
+		return nil, errors.New("need to specify --backend_endpoint when using a Karakeep backend")
+	}
+	if options.BackendBearerToken() == "" {
+		return nil, errors.New("need to specify --backend_bearer_token when using a Karakeep backend")
+	}
+	conn := karakeep.NewKarakeepConn(options.BackendEndpoint(), options.BackendBearerToken())
+	conn.SetHTTPClient(newBackendClient(options))
+	return conn, nil
+}
+
+func initLinkding(options Options) (Backend, error) {
+	if options.BackendEndpoint() == "" {
+		return nil, errors.New("need to specify --backend_endpoint when using a Linkding backend")
+	}
+	if options.BackendBearerToken() == "" {
+		return nil, errors.New("need to specify --backend_bearer_token when using a Linkding backend")
+	}
+	conn := linkding.NewLinkdingConn(options.BackendEndpoint(), options.BackendBearerToken())
+	conn.SetHTTPClient(newBackendClient(options))
+	conn.SetPageClient(newPageClient(options))
+	return conn, nil
+}
+
+func initLocal(options Options) (Backend, error) {
+	if options.DataDir() == "" {
+		return nil, errors.New("need to specify --data_dir when using the local backend")
+	}
+	conn, err := local.NewLocalConn(filepath.Join(options.DataDir(), "local.db"))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetHTTPClient(newPageClient(options))
+	return conn, nil
 }
 
 var allBackends = map[string]backendInit{
-	"readeck": initReadeck,
+	"readeck":  initReadeck,
+	"wallabag": initWallabag,
+	"karakeep": initKarakeep,
+	// Karakeep's old name.
+	"hoarder":  initKarakeep,
+	"linkding": initLinkding,
+	"local":    initLocal,
 }
 
 func allBackendNames() string {
@@ -58,26 +230,239 @@ func allBackendNames() string {
 	return strings.Join(names, ", ")
 }
 
-type server struct {
-	backend Backend
-	options Options
+// backendLogin exchanges a user's backend credentials for a bearer token.
+type backendLogin func(ctx context.Context, options Options, username, password string) (string, error)
+
+// The application name the proxy's tokens are listed under in the backend.
+const loginAppName = "Kobo Pocket Proxy"
+
+func loginReadeck(ctx context.Context, options Options, username, password string) (string, error) {
+	return readeck.GetAuthToken(ctx, options.BackendEndpoint(), loginAppName, username, password)
 }
 
-func NewServer(options Options) (*server, error) {
+// The backends which users can log into through the Pocket OAuth flow.
+var backendLogins = map[string]backendLogin{
+	"readeck": loginReadeck,
+}
+
+// userOptions overrides the backend credentials with a logged in user's.
+type userOptions struct {
+	Options
+	bearerToken string
+}
+
+func (o userOptions) BackendBearerToken() string { return o.bearerToken }
+
+// account is a backend connection along with the state the server keeps for it.
+type account struct {
+	backend     Backend
+	backendName string
+	tombstones  *tombstoneStore
+	media       mediaCache
+	// Actions waiting for the backend to be available, nil if they aren't queued.
+	outbox *outbox
+	// The last good responses from the backend, nil if they aren't cached.
+	cache           *responseCache
+	getCacheTTL     time.Duration
+	articleCacheTTL time.Duration
+	// Fetches the articles of listed items in the background, nil if they aren't.
+	prefetch *prefetcher
+}
+
+// newBackend connects to the backend named in the options.
+func newBackend(options Options) (Backend, error) {
 	backendInit, exists := allBackends[options.BackendName()]
 	if !exists {
 		return nil, fmt.Errorf("unknown backend \"%s\", available backends: %s", options.BackendName(), allBackendNames())
 	}
-	backend, err := backendInit(options)
+	return backendInit(options)
+}
+
+func newAccount(options Options) (*account, error) {
+	backend, err := newBackend(options)
 	if err != nil {
 		return nil, err
 	}
+
+	tombstonesPath := ""
+	if options.DataDir() != "" {
+		tombstonesPath = stateFilePath(options.DataDir(), "tombstones", options.BackendName(), options.BackendEndpoint(), options.BackendBearerToken(), options.BackendUsername())
+	}
+	tombstones, err := newTombstoneStore(tombstonesPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load deleted items: %w", err)
+	}
+
+	acc := &account{
+		backend:         backend,
+		backendName:     options.BackendName(),
+		tombstones:      tombstones,
+		getCacheTTL:     options.GetCacheTTL(),
+		articleCacheTTL: options.ArticleCacheTTL(),
+	}
+	outboxPath := ""
+	cacheDir := ""
+	if options.DataDir() != "" {
+		cacheDir = filepath.Join(options.DataDir(), "cache-"+stateKey(options.BackendName(), options.BackendEndpoint(), options.BackendBearerToken(), options.BackendUsername()))
+		outboxPath = stateFilePath(options.DataDir(), "outbox", options.BackendName(), options.BackendEndpoint(), options.BackendBearerToken(), options.BackendUsername())
+	}
+	if acc.outbox, err = newOutbox(outboxPath, acc.apply); err != nil {
+		return nil, fmt.Errorf("unable to load queued actions: %w", err)
+	}
+	acc.outbox.resume()
+	acc.cache = newResponseCache(cacheDir)
+	acc.prefetch = newPrefetcher(options.PrefetchWorkers(), options.ArticleTimeout(), acc.fetchArticle)
+	return acc, nil
+}
+
+var errInvalidAccessToken = errors.New("invalid access token")
+
+type server struct {
+	options Options
+	// Used for requests which don't have a known access token,
+	// or nil if they should be rejected.
+	defaultAccount *account
+	// The accounts of the users in the users file, keyed by access token.
+	tenants map[string]*account
+	oauth   *oauthStore
+	// nil if the backend doesn't support logging in.
+	login      backendLogin
+	newAccount func(Options) (*account, error)
+
+	images *imageproxy.Proxy
+	// Makes digests of the default account's new items on the schedule, or nil if it shouldn't.
+	digests        *digester
+	digestSchedule cron.Schedule
+	// Where digests are served from, or "" if there's nowhere.
+	digestDir string
+	// Whether third party images are shrunk for the device, otherwise only
+	// images which need the backend's credentials are proxied.
+	transcodeImages bool
+
+	// The accounts of logged in users, created on first use.
+	accountsMu sync.Mutex
+	accounts   map[string]*account
+}
+
+func NewServer(options Options) (*server, error) {
+	if _, exists := allBackends[options.BackendName()]; !exists {
+		return nil, fmt.Errorf("unknown backend \"%s\", available backends: %s", options.BackendName(), allBackendNames())
+	}
+	login := backendLogins[options.BackendName()]
+	if err := ValidateAuthOptions(options); err != nil {
+		return nil, err
+	}
+
+	var defaultAccount *account
+	tenants := make(map[string]*account)
+	if options.UsersFile() != "" {
+		users, err := loadUsers(options.UsersFile())
+		if err != nil {
+			return nil, err
+		}
+		tenants, err = newTenants(options, users)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Loaded %d users, requests with other access tokens will be rejected", len(users))
+	} else if login != nil && options.BackendBearerToken() == "" {
+		log.Printf("No --backend_bearer_token given, devices will need to log in through /auth/authorize")
+	} else {
+		var err error
+		defaultAccount, err = newAccount(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	oauthPath := ""
+	if options.DataDir() != "" {
+		oauthPath = stateFilePath(options.DataDir(), "oauth-tokens", options.BackendName(), options.BackendEndpoint())
+	}
+	oauth, err := newOAuthStore(oauthPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load logged in users: %w", err)
+	}
+
+	images, err := newImageProxy(options)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up image proxy: %w", err)
+	}
+
+	var digests *digester
+	var digestSchedule cron.Schedule
+	if options.DigestSchedule() != "" {
+		if defaultAccount == nil {
+			return nil, errors.New("digests are made for the backend account in the options, so need --backend_bearer_token and no --users_file")
+		}
+		digestSchedule, err = cron.Parse(options.DigestSchedule())
+		if err != nil {
+			return nil, err
+		}
+		digests, err = newDigester(options, images, defaultAccount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &server{
-		backend: backend,
-		options: options,
+		options:         options,
+		images:          images,
+		digests:         digests,
+		digestSchedule:  digestSchedule,
+		digestDir:       digestDir(options),
+		transcodeImages: options.ImageMaxWidth() > 0,
+		defaultAccount:  defaultAccount,
+		tenants:         tenants,
+		oauth:           oauth,
+		login:           login,
+		newAccount:      newAccount,
+		accounts:        make(map[string]*account),
 	}, nil
 }
 
+// accountFor returns the account for a Pocket access token, from either the users
+// file or logging in, falling back to the default account for unknown tokens.
+func (s *server) accountFor(accessToken string) (*account, error) {
+	if acc, exists := s.tenants[accessToken]; exists {
+		return acc, nil
+	}
+	if accessToken != "" {
+		s.accountsMu.Lock()
+		defer s.accountsMu.Unlock()
+		if acc, exists := s.accounts[accessToken]; exists {
+			return acc, nil
+		}
+		if user, exists := s.oauth.user(accessToken); exists && user.BearerToken != "" {
+			acc, err := s.newAccount(userOptions{Options: s.options, bearerToken: user.BearerToken})
+			if err != nil {
+				return nil, err
+			}
+			s.accounts[accessToken] = acc
+			return acc, nil
+		}
+	}
+	if s.defaultAccount == nil {
+		return nil, errInvalidAccessToken
+	}
+	return s.defaultAccount, nil
+}
+
+// writePocketError sends an error the way Pocket does, with the details in the X-Error headers.
+func writePocketError(w http.ResponseWriter, statusCode int, errorCode int, message string) {
+	w.Header().Set("X-Error-Code", strconv.Itoa(errorCode))
+	w.Header().Set("X-Error", message)
+	http.Error(w, message, statusCode)
+}
+
+func writeAccountError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInvalidAccessToken) {
+		writePocketError(w, http.StatusUnauthorized, 107, "Invalid access token")
+		return
+	}
+	http.Error(w, fmt.Sprintf("Unable to connect to backend: %v", err), http.StatusInternalServerError)
+}
+
 func (s *server) log(r *http.Request) {
 	log.Printf("%s %s received from %s", r.Method, r.URL, r.RemoteAddr)
 	if s.options.Verbose() {
@@ -107,17 +492,66 @@ func (s *server) getArticles(w http.ResponseWriter, r *http.Request) {
 	}
 	s.vlogJSON(r, body)
 
-	responseBody, err := s.backend.Get(body)
+	acc, err := s.accountFor(body.AccessToken)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Unable to forward request: %v", err), http.StatusBadRequest)
+		writeAccountError(w, err)
 		return
 	}
+
+	ctx, cancel := backendContext(r, s.options.GetTimeout())
+	defer cancel()
+
+	// Taken before asking the backend, so nothing changed during the request is missed next time.
+	now := time.Now()
+	responseBody, cachedAt, err := acc.get(ctx, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to forward request: %v", err), backendErrorStatus(err))
+		return
+	}
+	// Devices ask for the articles next, so start fetching them.
+	acc.prefetch.enqueue(responseBody.List)
+	// Only the first page of an incremental sync needs the deleted items.
+	if body.Since != nil && (body.Offset == nil || *body.Offset == 0) {
+		addDeletedItems(ctx, acc, time.Unix(*body.Since, 0), now, &responseBody)
+	}
+	if wantsCompleteDetail(body) {
+		s.addItemMedia(ctx, r, acc, body.AccessToken, &responseBody)
+	}
+	responseBody.Since = int(now.Unix())
+	if !cachedAt.IsZero() {
+		// A cached list is missing the changes made since, so the device should ask for them next time.
+		responseBody.Since = int(cachedAt.Unix())
+	}
 	if err := json.NewEncoder(w).Encode(&responseBody); err != nil {
 		http.Error(w, fmt.Sprintf("Unable to serialize response: %v", err), http.StatusInternalServerError)
 		return
 	}
 }
 
+// addDeletedItems adds the items deleted since the given time to the response,
+// with a status of "2" so devices remove them.
+func addDeletedItems(ctx context.Context, acc *account, since time.Time, now time.Time, res *pocketapi.GetResponse) {
+	if deletionBackend, supported := acc.backend.(DeletionBackend); supported {
+		deleted, err := deletionBackend.DeletedSince(ctx, since)
+		if err != nil {
+			// Not fatal, they'll be picked up by the next sync.
+			log.Printf("Unable to check for deleted items: %v", err)
+		} else if err := acc.tombstones.add(deleted, now); err != nil {
+			log.Printf("Unable to save deleted items: %v", err)
+		}
+	}
+
+	for _, id := range acc.tombstones.since(since) {
+		if _, exists := res.List[id]; exists {
+			continue
+		}
+		if res.List == nil {
+			res.List = map[string]pocketapi.GetResponseItem{}
+		}
+		res.List[id] = pocketapi.GetResponseItem{ItemID: id, Status: "2"}
+	}
+}
+
 func (s *server) modifyArticles(w http.ResponseWriter, r *http.Request) {
 	s.log(r)
 
@@ -128,30 +562,31 @@ func (s *server) modifyArticles(w http.ResponseWriter, r *http.Request) {
 	}
 	s.vlogJSON(r, body)
 
+	acc, err := s.accountFor(body.AccessToken)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	ctx, cancel := backendContext(r, s.options.SendTimeout())
+	defer cancel()
+
 	var responseBody pocketapi.SendResponse
 	responseBody.Status = 1
 	responseBody.ActionResults = make([]bool, len(body.Actions))
 	responseBody.ActionErrors = make([]*pocketapi.SendError, len(body.Actions))
 
 	for i, action := range body.Actions {
-		actionTime := time.Unix(int64(action.Time), 0)
 		var actionErr error
-		switch action.Action {
-		case "add":
-			actionErr = s.backend.Add(action.URL, "", actionTime)
-		case "archive":
-			actionErr = s.backend.Archive(action.ItemID, actionTime)
-		case "readd":
-			actionErr = s.backend.Unarchive(action.ItemID, actionTime)
-		case "favorite":
-			actionErr = s.backend.Favorite(action.ItemID, actionTime)
-		case "unfavorite":
-			actionErr = s.backend.Unfavorite(action.ItemID, actionTime)
-		case "delete":
-			actionErr = s.backend.Delete(action.ItemID, actionTime)
-		default:
-			// Do nothing, fail open.
-			actionErr = nil // For emphasis.
+		if acc.outbox != nil && acc.outbox.hasPending() {
+			// Earlier actions are still waiting for the backend, and this one has to happen after them.
+			actionErr = acc.outbox.enqueue(action, nil)
+		} else {
+			actionErr = acc.apply(ctx, action)
+			if acc.outbox != nil && httpclient.IsUnavailable(actionErr) {
+				log.Printf("Queueing %s action until the backend is available: %v", action.Action, actionErr)
+				actionErr = acc.outbox.enqueue(action, actionErr)
+			}
 		}
 
 		responseBody.ActionResults[i] = (actionErr == nil)
@@ -168,6 +603,76 @@ func (s *server) modifyArticles(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// apply performs a /v3/send action on the account's backend.
+func (acc *account) apply(ctx context.Context, action pocketapi.SendAction) (err error) {
+	defer func() {
+		// Cached lists no longer match the backend.
+		if err == nil && acc.cache != nil {
+			acc.cache.invalidate(cacheKindGet)
+		}
+	}()
+
+	actionTime := time.Unix(int64(action.Time), 0)
+	switch action.Action {
+	case "add":
+		_, err := acc.backend.Add(ctx, action.URL, "", actionTime)
+		return err
+	case "archive":
+		return acc.backend.Archive(ctx, action.ItemID, actionTime)
+	case "readd":
+		return acc.backend.Unarchive(ctx, action.ItemID, actionTime)
+	case "favorite":
+		return acc.backend.Favorite(ctx, action.ItemID, actionTime)
+	case "unfavorite":
+		return acc.backend.Unfavorite(ctx, action.ItemID, actionTime)
+	case "delete":
+		if err := acc.backend.Delete(ctx, action.ItemID, actionTime); err != nil {
+			return err
+		}
+		// Recorded at the current time rather than the action's, since that's
+		// when other devices' next sync needs to find it.
+		if err := acc.tombstones.add([]string{action.ItemID}, time.Now()); err != nil {
+			log.Printf("Unable to save deleted item %s: %v", action.ItemID, err)
+		}
+		return nil
+	case "tags_add", "tags_remove", "tags_replace", "tags_clear", "tag_rename", "tag_delete":
+		return acc.modifyTags(ctx, action, actionTime)
+	}
+	// Do nothing, fail open.
+	return nil
+}
+
+func (acc *account) modifyTags(ctx context.Context, action pocketapi.SendAction, actionTime time.Time) error {
+	tagBackend, supported := acc.backend.(TagBackend)
+	if !supported {
+		// Fail open, the same as other unsupported actions.
+		log.Printf("Ignoring %s action, backend %s doesn't support tags", action.Action, acc.backendName)
+		return nil
+	}
+
+	switch action.Action {
+	case "tags_add":
+		return tagBackend.AddTags(ctx, action.ItemID, action.Tags, actionTime)
+	case "tags_remove":
+		return tagBackend.RemoveTags(ctx, action.ItemID, action.Tags, actionTime)
+	case "tags_replace":
+		return tagBackend.ReplaceTags(ctx, action.ItemID, action.Tags, actionTime)
+	case "tags_clear":
+		return tagBackend.ClearTags(ctx, action.ItemID, actionTime)
+	case "tag_rename":
+		if action.OldTag == "" || action.NewTag == "" {
+			return errors.New("tag_rename needs both old_tag and new_tag")
+		}
+		return tagBackend.RenameTag(ctx, action.OldTag, action.NewTag, actionTime)
+	case "tag_delete":
+		if action.Tag == "" {
+			return errors.New("tag_delete needs a tag")
+		}
+		return tagBackend.DeleteTag(ctx, action.Tag, actionTime)
+	}
+	return fmt.Errorf("unknown tag action %s", action.Action)
+}
+
 func (s *server) articleText(w http.ResponseWriter, r *http.Request) {
 	s.log(r)
 	if err := r.ParseForm(); err != nil {
@@ -181,11 +686,21 @@ func (s *server) articleText(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseBody, err := s.backend.ArticleText(url[0])
+	acc, err := s.accountFor(r.Form.Get("access_token"))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Unable to forward request: %v", err), http.StatusBadRequest)
+		writeAccountError(w, err)
 		return
 	}
+
+	ctx, cancel := backendContext(r, s.options.ArticleTimeout())
+	defer cancel()
+
+	responseBody, err := acc.articleText(ctx, url[0])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to forward request: %v", err), backendErrorStatus(err))
+		return
+	}
+	s.proxyArticleImages(ctx, r, acc, r.Form.Get("access_token"), &responseBody)
 	if err := json.NewEncoder(w).Encode(&responseBody); err != nil {
 		http.Error(w, fmt.Sprintf("Unable to serialize response: %v", err), http.StatusInternalServerError)
 		return
@@ -205,11 +720,25 @@ func StartServing(options Options) {
 		return
 	}
 
-	mux.HandleFunc("/v3/get", server.getArticles)
-	mux.HandleFunc("/v3/send", server.modifyArticles)
-	mux.HandleFunc("/v3beta/text", server.articleText)
+	mux.HandleFunc("/v3/get", server.authenticate(checkAccessToken, server.getArticles))
+	mux.HandleFunc("/v3/send", server.authenticate(checkAccessToken, server.modifyArticles))
+	mux.HandleFunc("/v3beta/text", server.authenticate(checkAccessToken, server.articleText))
+	mux.HandleFunc("/v3/image", server.authenticate(checkNetwork, server.proxyImage))
+	mux.HandleFunc("/v3/outbox", server.authenticate(checkAccessToken, server.outboxStatus))
+	mux.HandleFunc("/export", server.authenticate(checkBasicAuth|checkAccessToken, server.exportList))
+	mux.HandleFunc("/epub", server.authenticate(checkBasicAuth|checkAccessToken, server.epubBook))
+	mux.HandleFunc("/opds", server.authenticate(checkBasicAuth, server.digestFeed))
+	mux.HandleFunc("/opds/digests/", server.authenticate(checkBasicAuth, server.digestFile))
+	mux.HandleFunc("/v3/oauth/request", server.authenticate(checkConsumerKey, server.oauthRequest))
+	mux.HandleFunc("/v3/oauth/authorize", server.authenticate(checkConsumerKey, server.oauthAuthorize))
+	mux.HandleFunc("/auth/authorize", server.authenticate(checkBasicAuth, server.authorizePage))
 	mux.HandleFunc("/", catchAll)
 
+	if server.digests != nil {
+		log.Printf("Making digests on the schedule %q in %s", options.DigestSchedule(), server.digestDir)
+		go server.digests.schedule(context.Background(), server.digestSchedule)
+	}
+
 	fmt.Printf("Listening on http://localhost:%d\n", options.Port())
 
 	err = http.ListenAndServe(fmt.Sprintf(":%d", options.Port()), mux)
@@ -112,11 +112,34 @@ type testServerOptions struct {
 	backendBearerToken string
 }
 
-func (o testServerOptions) Port() int                  { return o.port }
-func (testServerOptions) Verbose() bool                { return false }
-func (o testServerOptions) BackendName() string        { return o.backendName }
-func (o testServerOptions) BackendEndpoint() string    { return o.backendEndpoint }
-func (o testServerOptions) BackendBearerToken() string { return o.backendBearerToken }
+func (o testServerOptions) Port() int                    { return o.port }
+func (testServerOptions) Verbose() bool                  { return false }
+func (o testServerOptions) BackendName() string          { return o.backendName }
+func (o testServerOptions) BackendEndpoint() string      { return o.backendEndpoint }
+func (o testServerOptions) BackendBearerToken() string   { return o.backendBearerToken }
+func (testServerOptions) BackendClientID() string        { return "" }
+func (testServerOptions) BackendClientSecret() string    { return "" }
+func (testServerOptions) BackendUsername() string        { return "" }
+func (testServerOptions) BackendPassword() string        { return "" }
+func (testServerOptions) DataDir() string                { return "" }
+func (testServerOptions) UsersFile() string              { return "" }
+func (testServerOptions) ImageMaxWidth() int             { return 0 }
+func (testServerOptions) GetTimeout() time.Duration      { return 0 }
+func (testServerOptions) SendTimeout() time.Duration     { return 0 }
+func (testServerOptions) ArticleTimeout() time.Duration  { return 0 }
+func (testServerOptions) BackendRetries() int            { return 0 }
+func (testServerOptions) BackendFailureThreshold() int   { return 0 }
+func (testServerOptions) GetCacheTTL() time.Duration     { return 0 }
+func (testServerOptions) ArticleCacheTTL() time.Duration { return 0 }
+func (testServerOptions) PrefetchWorkers() int           { return 0 }
+func (testServerOptions) DigestSchedule() string         { return "" }
+func (testServerOptions) DigestDir() string              { return "" }
+func (testServerOptions) DigestFormat() string           { return "" }
+func (testServerOptions) AllowedConsumerKeys() []string  { return nil }
+func (testServerOptions) AllowedAccessTokens() []string  { return nil }
+func (testServerOptions) AllowedNetworks() []string      { return nil }
+func (testServerOptions) BasicAuthUsername() string      { return "" }
+func (testServerOptions) BasicAuthPassword() string      { return "" }
 
 type readeckEnv struct {
 	network            *containers.DockerNetwork
@@ -241,7 +264,7 @@ func newReadeckEnv(ctx context.Context, t *testing.T) (*readeckEnv, error) {
 	}
 
 	// Get the auth token.
-	token, err := readeck.GetAuthToken(e.readeckBaseUrl, "test app", readeckUser, readeckPassword)
+	token, err := readeck.GetAuthToken(ctx, e.readeckBaseUrl, "test app", readeckUser, readeckPassword)
 	if err != nil {
 		errCleanup()
 		return nil, err
@@ -0,0 +1,49 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"proxyserver/httpclient"
+	"time"
+)
+
+// backendContext limits how long a request can wait on the backend. It's derived
+// from the request's context, so the backend calls are also cancelled if the
+// device hangs up.
+func backendContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return timeoutContext(r.Context(), timeout)
+}
+
+// timeoutContext limits the parent context to the timeout, unless it's 0.
+func timeoutContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// backendErrorStatus returns the status to respond with when the backend fails.
+func backendErrorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, httpclient.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
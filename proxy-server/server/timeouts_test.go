@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"proxyserver/httpclient"
+	"proxyserver/pocketapi"
+)
+
+// hangingBackend never answers, until the request is given up on.
+type hangingBackend struct {
+	fakeBackend
+}
+
+func (b *hangingBackend) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	<-ctx.Done()
+	return pocketapi.GetResponse{}, ctx.Err()
+}
+
+type timeoutOptions struct {
+	fakeOptions
+}
+
+func (timeoutOptions) GetTimeout() time.Duration { return 10 * time.Millisecond }
+
+func TestServer_GetTimeout(t *testing.T) {
+	s := newTestServer(t, &hangingBackend{})
+	s.options = timeoutOptions{}
+
+	rec := httptest.NewRecorder()
+	s.getArticles(rec, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Unexpected status, want %d got %d", http.StatusGatewayTimeout, rec.Code)
+	}
+}
+
+func TestServer_GetCancelled(t *testing.T) {
+	s := newTestServer(t, &hangingBackend{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rec := httptest.NewRecorder()
+		s.getArticles(rec, httptest.NewRequestWithContext(ctx, http.MethodPost, "/v3/get", strings.NewReader(`{}`)))
+	}()
+	// The device hanging up should stop the backend call.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wanted the request to finish once it was cancelled")
+	}
+}
+
+func TestBackendErrorStatus(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want int
+	}{
+		{err: errors.New("bad item"), want: http.StatusBadRequest},
+		{err: fmt.Errorf("request failed: %w", context.DeadlineExceeded), want: http.StatusGatewayTimeout},
+		{err: fmt.Errorf("request failed: %w", httpclient.ErrCircuitOpen), want: http.StatusServiceUnavailable},
+	}
+	for _, tc := range testCases {
+		if got := backendErrorStatus(tc.err); got != tc.want {
+			t.Errorf("Unexpected status for %v, want %d got %d", tc.err, tc.want, got)
+		}
+	}
+}
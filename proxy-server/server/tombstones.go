@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"proxyserver/internal/atomicfile"
+	"sync"
+	"time"
+)
+
+// How long deletions are remembered. Devices which haven't synced for longer
+// than this will keep deleted items around.
+const tombstoneRetention = 30 * 24 * time.Hour
+
+// tombstoneStore remembers which items were deleted and when, so incremental
+// syncs can tell devices to remove them. It's persisted to disk if a path is given.
+type tombstoneStore struct {
+	mu sync.Mutex
+	// Item ID to Unix deletion time.
+	deleted map[string]int64
+	path    string
+}
+
+func newTombstoneStore(path string) (*tombstoneStore, error) {
+	store := &tombstoneStore{deleted: make(map[string]int64), path: path}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.deleted); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// add records the items as deleted at the given time, and forgets any old deletions.
+func (store *tombstoneStore) add(itemIDs []string, deleteTime time.Time) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, id := range itemIDs {
+		store.deleted[id] = deleteTime.Unix()
+	}
+	cutoff := time.Now().Add(-tombstoneRetention).Unix()
+	for id, deleted := range store.deleted {
+		if deleted < cutoff {
+			delete(store.deleted, id)
+		}
+	}
+	return store.save()
+}
+
+// since returns the IDs of the items deleted at or after the given time.
+func (store *tombstoneStore) since(since time.Time) []string {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var ids []string
+	for id, deleted := range store.deleted {
+		if deleted >= since.Unix() {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// save writes the store to disk. Must be called with mu held.
+func (store *tombstoneStore) save() error {
+	if store.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(store.deleted)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(store.path, data)
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"proxyserver/pocketapi"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBackend returns a fixed list of items and article, and records which items were deleted.
+type fakeBackend struct {
+	items          map[string]pocketapi.GetResponseItem
+	article        pocketapi.ArticleTextResponse
+	deletedOutside []string
+	deleted        []string
+}
+
+func (b *fakeBackend) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	return pocketapi.GetResponse{Status: 1, List: maps.Clone(b.items), Total: len(b.items)}, nil
+}
+
+func (b *fakeBackend) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	return b.article, nil
+}
+
+func (b *fakeBackend) Add(ctx context.Context, url string, title string, time time.Time) (string, error) {
+	return "", nil
+}
+func (b *fakeBackend) Archive(ctx context.Context, itemID string, time time.Time) error   { return nil }
+func (b *fakeBackend) Unarchive(ctx context.Context, itemID string, time time.Time) error { return nil }
+func (b *fakeBackend) Favorite(ctx context.Context, itemID string, time time.Time) error  { return nil }
+func (b *fakeBackend) Unfavorite(ctx context.Context, itemID string, time time.Time) error {
+	return nil
+}
+func (b *fakeBackend) Delete(ctx context.Context, itemID string, time time.Time) error {
+	b.deleted = append(b.deleted, itemID)
+	return nil
+}
+
+func (b *fakeBackend) DeletedSince(ctx context.Context, since time.Time) ([]string, error) {
+	deleted := b.deletedOutside
+	b.deletedOutside = nil
+	return deleted, nil
+}
+
+// fakeOptions is used by tests which construct the server directly.
+type fakeOptions struct{}
+
+func (fakeOptions) Port() int                      { return 0 }
+func (fakeOptions) Verbose() bool                  { return false }
+func (fakeOptions) BackendName() string            { return "fake" }
+func (fakeOptions) BackendEndpoint() string        { return "" }
+func (fakeOptions) BackendBearerToken() string     { return "" }
+func (fakeOptions) BackendClientID() string        { return "" }
+func (fakeOptions) BackendClientSecret() string    { return "" }
+func (fakeOptions) BackendUsername() string        { return "" }
+func (fakeOptions) BackendPassword() string        { return "" }
+func (fakeOptions) DataDir() string                { return "" }
+func (fakeOptions) UsersFile() string              { return "" }
+func (fakeOptions) ImageMaxWidth() int             { return 0 }
+func (fakeOptions) GetTimeout() time.Duration      { return 0 }
+func (fakeOptions) SendTimeout() time.Duration     { return 0 }
+func (fakeOptions) ArticleTimeout() time.Duration  { return 0 }
+func (fakeOptions) BackendRetries() int            { return 0 }
+func (fakeOptions) BackendFailureThreshold() int   { return 0 }
+func (fakeOptions) GetCacheTTL() time.Duration     { return 0 }
+func (fakeOptions) ArticleCacheTTL() time.Duration { return 0 }
+func (fakeOptions) PrefetchWorkers() int           { return 0 }
+func (fakeOptions) DigestSchedule() string         { return "" }
+func (fakeOptions) DigestDir() string              { return "" }
+func (fakeOptions) DigestFormat() string           { return "" }
+func (fakeOptions) AllowedConsumerKeys() []string  { return nil }
+func (fakeOptions) AllowedAccessTokens() []string  { return nil }
+func (fakeOptions) AllowedNetworks() []string      { return nil }
+func (fakeOptions) BasicAuthUsername() string      { return "" }
+func (fakeOptions) BasicAuthPassword() string      { return "" }
+
+func newTestServer(t *testing.T, backend Backend) *server {
+	tombstones, err := newTombstoneStore(filepath.Join(t.TempDir(), "tombstones.json"))
+	if err != nil {
+		t.Fatalf("Unable to create tombstone store: %v", err)
+	}
+	oauth, err := newOAuthStore(filepath.Join(t.TempDir(), "oauth.json"))
+	if err != nil {
+		t.Fatalf("Unable to create OAuth store: %v", err)
+	}
+	return &server{
+		options:        fakeOptions{},
+		defaultAccount: &account{backend: backend, tombstones: tombstones},
+		tenants:        make(map[string]*account),
+		oauth:          oauth,
+		accounts:       make(map[string]*account),
+	}
+}
+
+func TestTombstoneStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "tombstones.json")
+	store, err := newTombstoneStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	if err := store.add([]string{"old"}, now.Add(-tombstoneRetention-time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.add([]string{"1", "2"}, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.add([]string{"3"}, now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reloaded, err := newTombstoneStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reloading: %v", err)
+	}
+	got := reloaded.since(time.Time{})
+	slices.Sort(got)
+	if want := []string{"1", "2", "3"}; !slices.Equal(want, got) {
+		t.Errorf("Unexpected deleted IDs, want %v got %v", want, got)
+	}
+	if got := reloaded.since(now.Add(-time.Minute)); !slices.Equal([]string{"3"}, got) {
+		t.Errorf("Unexpected recently deleted IDs, want [3] got %v", got)
+	}
+}
+
+func TestServer_GetSince(t *testing.T) {
+	backend := &fakeBackend{
+		items:          map[string]pocketapi.GetResponseItem{"1": {ItemID: "1", Status: "0"}},
+		deletedOutside: []string{"2"},
+	}
+	s := newTestServer(t, backend)
+
+	send := httptest.NewRecorder()
+	s.modifyArticles(send, httptest.NewRequest(http.MethodPost, "/v3/send", strings.NewReader(`{"actions": [{"action": "delete", "item_id": "3"}]}`)))
+	if send.Code != http.StatusOK {
+		t.Fatalf("Unexpected status deleting: %d", send.Code)
+	}
+
+	get := func(body string) pocketapi.GetResponse {
+		rec := httptest.NewRecorder()
+		s.getArticles(rec, httptest.NewRequest(http.MethodPost, "/v3/get", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", rec.Code)
+		}
+		var res pocketapi.GetResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatalf("Unable to decode response: %v", err)
+		}
+		return res
+	}
+
+	before := time.Now().Unix()
+	res := get(`{}`)
+	if int64(res.Since) < before {
+		t.Errorf("Expected since to be the server time, got %d", res.Since)
+	}
+	if len(res.List) != 1 {
+		t.Errorf("Wanted only the backend's items for a full sync, got %v", res.List)
+	}
+
+	res = get(`{"since": 1}`)
+	for _, id := range []string{"2", "3"} {
+		if item, exists := res.List[id]; !exists || item.Status != "2" {
+			t.Errorf("Wanted item %s to be deleted, got %+v", id, item)
+		}
+	}
+	if res.List["1"].Status != "0" {
+		t.Errorf("Unexpected status for item 1: %s", res.List["1"].Status)
+	}
+
+	// Later pages don't repeat the deletions.
+	res = get(`{"since": 1, "offset": 30}`)
+	if _, exists := res.List["3"]; exists {
+		t.Error("Wanted deletions to only be on the first page")
+	}
+}
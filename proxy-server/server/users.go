@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// userConfig is one user in the users file. Any backend settings left out are
+// taken from the command line flags.
+type userConfig struct {
+	Name string `json:"name"`
+	// The Pocket access tokens this user's devices send.
+	AccessTokens        []string `json:"access_tokens"`
+	Backend             string   `json:"backend,omitempty"`
+	BackendEndpoint     string   `json:"backend_endpoint,omitempty"`
+	BackendBearerToken  string   `json:"backend_bearer_token,omitempty"`
+	BackendClientID     string   `json:"backend_client_id,omitempty"`
+	BackendClientSecret string   `json:"backend_client_secret,omitempty"`
+	BackendUsername     string   `json:"backend_username,omitempty"`
+	BackendPassword     string   `json:"backend_password,omitempty"`
+}
+
+type usersFile struct {
+	Users []userConfig `json:"users"`
+}
+
+func loadUsers(path string) ([]userConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file usersFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse users file %s: %w", path, err)
+	}
+
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, user := range file.Users {
+		if user.Name == "" {
+			return nil, errors.New("every user in the users file needs a name")
+		}
+		// The name is used as the user's state directory.
+		if !isSafeName(user.Name) {
+			return nil, fmt.Errorf("user name %q can't contain slashes or be . or ..", user.Name)
+		}
+		if names[user.Name] {
+			return nil, fmt.Errorf("user %s is in the users file twice", user.Name)
+		}
+		names[user.Name] = true
+		if len(user.AccessTokens) == 0 {
+			return nil, fmt.Errorf("user %s needs at least one access token", user.Name)
+		}
+		for _, token := range user.AccessTokens {
+			if token == "" || tokens[token] {
+				return nil, fmt.Errorf("user %s has an empty or duplicate access token", user.Name)
+			}
+			tokens[token] = true
+		}
+	}
+	return file.Users, nil
+}
+
+// isSafeName returns whether the name is a single path element, so it can't
+// point outside the users directory or into another user's.
+func isSafeName(name string) bool {
+	return name != "." && filepath.IsLocal(name) && !strings.ContainsAny(name, `/\`)
+}
+
+// tenantOptions overrides the server's options with a user's settings.
+type tenantOptions struct {
+	Options
+	user userConfig
+}
+
+func orDefault(value string, defaultValue string) string {
+	if value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (o tenantOptions) BackendName() string {
+	return orDefault(o.user.Backend, o.Options.BackendName())
+}
+
+func (o tenantOptions) BackendEndpoint() string {
+	return orDefault(o.user.BackendEndpoint, o.Options.BackendEndpoint())
+}
+
+func (o tenantOptions) BackendBearerToken() string {
+	return orDefault(o.user.BackendBearerToken, o.Options.BackendBearerToken())
+}
+
+func (o tenantOptions) BackendClientID() string {
+	return orDefault(o.user.BackendClientID, o.Options.BackendClientID())
+}
+
+func (o tenantOptions) BackendClientSecret() string {
+	return orDefault(o.user.BackendClientSecret, o.Options.BackendClientSecret())
+}
+
+func (o tenantOptions) BackendUsername() string {
+	return orDefault(o.user.BackendUsername, o.Options.BackendUsername())
+}
+
+func (o tenantOptions) BackendPassword() string {
+	return orDefault(o.user.BackendPassword, o.Options.BackendPassword())
+}
+
+// DataDir gives each user their own directory, so backends like local don't share state.
+func (o tenantOptions) DataDir() string {
+	if o.Options.DataDir() == "" {
+		return ""
+	}
+	return filepath.Join(o.Options.DataDir(), "users", o.user.Name)
+}
+
+// newTenants creates an account for every user in the users file, keyed by access token.
+func newTenants(options Options, users []userConfig) (map[string]*account, error) {
+	tenants := make(map[string]*account)
+	for _, user := range users {
+		acc, err := newAccount(tenantOptions{Options: options, user: user})
+		if err != nil {
+			return nil, fmt.Errorf("unable to set up user %s: %w", user.Name, err)
+		}
+		for _, token := range user.AccessTokens {
+			tenants[token] = acc
+		}
+	}
+	return tenants, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"proxyserver/pocketapi"
+	"strings"
+	"testing"
+)
+
+// usersTestOptions runs the local backend for everyone in a users file.
+type usersTestOptions struct {
+	fakeOptions
+	dataDir   string
+	usersFile string
+}
+
+func (usersTestOptions) BackendName() string { return "local" }
+func (o usersTestOptions) DataDir() string   { return o.dataDir }
+func (o usersTestOptions) UsersFile() string { return o.usersFile }
+
+func writeUsersFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadUsers_Invalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+	}{
+		{name: "Not JSON", contents: `{users`},
+		{name: "No Name", contents: `{"users": [{"access_tokens": ["a"]}]}`},
+		{name: "No Tokens", contents: `{"users": [{"name": "alice"}]}`},
+		{name: "Duplicate Name", contents: `{"users": [{"name": "alice", "access_tokens": ["a"]}, {"name": "alice", "access_tokens": ["b"]}]}`},
+		{name: "Parent Directory", contents: `{"users": [{"name": "../alice", "access_tokens": ["a"]}]}`},
+		{name: "Nested Directory", contents: `{"users": [{"name": "alice/bob", "access_tokens": ["a"]}]}`},
+		{name: "Dot", contents: `{"users": [{"name": ".", "access_tokens": ["a"]}]}`},
+		{name: "Duplicate Token", contents: `{"users": [{"name": "alice", "access_tokens": ["a"]}, {"name": "bob", "access_tokens": ["a"]}]}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := loadUsers(writeUsersFile(t, tc.contents)); err == nil {
+				t.Error("Wanted error, got nil instead")
+			}
+		})
+	}
+}
+
+func TestServer_Tenants(t *testing.T) {
+	dataDir := t.TempDir()
+	usersFile := writeUsersFile(t, `{"users": [
+		{"name": "alice", "access_tokens": ["alice-kobo", "alice-phone"]},
+		{"name": "bob", "access_tokens": ["bob-kobo"]}
+	]}`)
+	s, err := NewServer(usersTestOptions{dataDir: dataDir, usersFile: usersFile})
+	if err != nil {
+		t.Fatalf("Unexpected error creating server: %v", err)
+	}
+
+	serve := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return rec
+	}
+	countItems := func(accessToken string) int {
+		rec := serve(s.getArticles, `{"access_token": "`+accessToken+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Unexpected status getting articles for %s: %d", accessToken, rec.Code)
+		}
+		var res pocketapi.GetResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatalf("Unable to decode response: %v", err)
+		}
+		return len(res.List)
+	}
+
+	// The URL can't be fetched, but the local backend still saves it.
+	rec := serve(s.modifyArticles, `{"access_token": "alice-kobo", "actions": [{"action": "add", "url": "http://127.0.0.1:1/article"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status adding: %d", rec.Code)
+	}
+
+	if got := countItems("alice-phone"); got != 1 {
+		t.Errorf("Wanted alice's devices to share items, got %d items", got)
+	}
+	if got := countItems("bob-kobo"); got != 0 {
+		t.Errorf("Wanted bob's items to be separate, got %d items", got)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "users", "alice", "local.db")); err != nil {
+		t.Errorf("Expected alice's database in their own directory: %v", err)
+	}
+
+	for _, token := range []string{"", "someone-else"} {
+		rec := serve(s.getArticles, `{"access_token": "`+token+`"}`)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("X-Error-Code") != "107" {
+			t.Errorf("Unexpected response for access token %q: [%d] %s", token, rec.Code, rec.Header().Get("X-Error"))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// How long before the token's actual expiry to consider it expired, to account for clock skew
+// and in-flight requests.
+const tokenExpiryMargin = 30 * time.Second
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+// token returns a valid access token, logging in or refreshing the existing token if needed.
+func (conn *WallabagConn) token(ctx context.Context) (string, error) {
+	conn.tokenMu.Lock()
+	defer conn.tokenMu.Unlock()
+
+	if conn.accessToken != "" && time.Now().Before(conn.tokenExpiry) {
+		return conn.accessToken, nil
+	}
+
+	var res tokenResponse
+	var err error
+	if conn.refreshToken != "" {
+		res, err = conn.requestToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {conn.refreshToken},
+		})
+	}
+	if conn.refreshToken == "" || err != nil {
+		// Either this is the first login, or the refresh token expired too.
+		res, err = conn.requestToken(ctx, url.Values{
+			"grant_type": {"password"},
+			"username":   {conn.username},
+			"password":   {conn.password},
+		})
+	}
+	if err != nil {
+		conn.accessToken = ""
+		conn.refreshToken = ""
+		return "", err
+	}
+
+	conn.accessToken = res.AccessToken
+	conn.refreshToken = res.RefreshToken
+	conn.tokenExpiry = time.Now().Add(time.Duration(res.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return conn.accessToken, nil
+}
+
+func (conn *WallabagConn) invalidateToken() {
+	conn.tokenMu.Lock()
+	defer conn.tokenMu.Unlock()
+	conn.accessToken = ""
+}
+
+func (conn *WallabagConn) requestToken(ctx context.Context, params url.Values) (tokenResponse, error) {
+	params.Set("client_id", conn.clientID)
+	params.Set("client_secret", conn.clientSecret)
+
+	tokenUrl := fmt.Sprintf("%s/oauth/v2/token", conn.endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUrl, strings.NewReader(params.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := conn.client.Do(req)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer res.Body.Close()
+	if err := checkResponseCode(res); err != nil {
+		return tokenResponse{}, err
+	}
+
+	var resBody tokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return tokenResponse{}, err
+	}
+	if resBody.AccessToken == "" {
+		return tokenResponse{}, errors.New("unexpected empty token in Wallabag auth response")
+	}
+	return resBody, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"proxyserver/httpclient"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The timestamp format used by the Wallabag API, e.g. "2025-06-30T15:08:09+0200".
+const timeLayout = "2006-01-02T15:04:05-0700"
+
+type WallabagConn struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+	client       *httpclient.Client
+
+	// OAuth2 state, guarded by tokenMu since handlers can call the API concurrently.
+	tokenMu      sync.Mutex
+	accessToken  string
+	refreshToken string
+	tokenExpiry  time.Time
+}
+
+func NewWallabagConn(endpoint, clientID, clientSecret, username, password string) *WallabagConn {
+	return &WallabagConn{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		username:     username,
+		password:     password,
+		client:       httpclient.New(httpclient.DefaultOptions()),
+	}
+}
+
+// SetHTTPClient replaces the client used to call the Wallabag API.
+func (conn *WallabagConn) SetHTTPClient(client *httpclient.Client) {
+	conn.client = client
+}
+
+func (conn *WallabagConn) createRequest(ctx context.Context, method, action string, body io.Reader) (*http.Request, error) {
+	token, err := conn.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to authenticate with Wallabag: %w", err)
+	}
+
+	apiUrl := fmt.Sprintf("%s/api/%s", conn.endpoint, action)
+	bagReq, err := http.NewRequestWithContext(ctx, method, apiUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	bagReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	bagReq.Header.Set("Accept", "application/json")
+	return bagReq, nil
+}
+
+// do sends a request created by createRequest and checks the response code.
+func (conn *WallabagConn) do(bagReq *http.Request) (*http.Response, error) {
+	bagRes, err := conn.client.Do(bagReq)
+	if err != nil {
+		return nil, err
+	}
+	if bagRes.StatusCode == http.StatusUnauthorized {
+		// The token was revoked or expired early, make sure the next call gets a new one.
+		conn.invalidateToken()
+	}
+	if err := checkResponseCode(bagRes); err != nil {
+		bagRes.Body.Close()
+		return nil, err
+	}
+	return bagRes, nil
+}
+
+// wallabagTime parses the timestamps returned by the API, which don't quite match RFC 3339.
+type wallabagTime struct {
+	time.Time
+}
+
+func (t *wallabagTime) UnmarshalJSON(data []byte) error {
+	var val string
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	if val == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(timeLayout, val)
+	if err != nil {
+		// Fall back to RFC 3339, which newer versions might use.
+		if parsed, err = time.Parse(time.RFC3339, val); err != nil {
+			return err
+		}
+	}
+	t.Time = parsed
+	return nil
+}
+
+type errorBody struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func checkResponseCode(bagRes *http.Response) error {
+	if bagRes.StatusCode >= 200 && bagRes.StatusCode <= 299 {
+		return nil
+	}
+	var body errorBody
+	if err := json.NewDecoder(bagRes.Body).Decode(&body); err != nil || body.Error == "" {
+		return httpclient.ResponseError(bagRes, fmt.Errorf("error calling Wallabag API: [%d] %s", bagRes.StatusCode, bagRes.Status))
+	}
+	return httpclient.ResponseError(bagRes, fmt.Errorf("error calling Wallabag API: [%d] %s, More details: %s %s", bagRes.StatusCode, bagRes.Status, body.Error, body.ErrorDescription))
+}
@@ -0,0 +1,294 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"proxyserver/pocketapi"
+	"strconv"
+	"strings"
+)
+
+// The page size Wallabag uses when none is given.
+const defaultPageSize = 30
+
+func buildGetQuerystring(req pocketapi.GetRequest) string {
+	query := url.Values{}
+	// The list doesn't need the (potentially huge) article content.
+	query.Set("detail", "metadata")
+
+	// Wallabag paginates by page number rather than offset, so this only
+	// lines up exactly when the offset is a multiple of the count (which is
+	// how the Kobo paginates).
+	perPage := defaultPageSize
+	if req.Count != nil && *req.Count > 0 {
+		perPage = *req.Count
+		query.Set("perPage", strconv.Itoa(perPage))
+	}
+	if req.Offset != nil && *req.Offset > 0 {
+		query.Set("page", strconv.Itoa(*req.Offset/perPage+1))
+	}
+	if req.Since != nil {
+		query.Set("since", strconv.FormatInt(*req.Since, 10))
+	}
+
+	switch strings.ToLower(req.State) {
+	case "unread":
+		query.Set("archive", "0")
+	case "archive":
+		query.Set("archive", "1")
+	case "all":
+		fallthrough
+	default:
+		// Leave it unset.
+	}
+
+	switch strings.ToLower(req.Favorite) {
+	case "0":
+		query.Set("starred", "0")
+	case "1":
+		query.Set("starred", "1")
+	default:
+		// Leave it unset.
+	}
+
+	// Wallabag can't list untagged entries or search them through this API, so those are ignored.
+	if req.Tag != "" && req.Tag != pocketapi.UntaggedTag {
+		query.Set("tags", req.Tag)
+	}
+	if req.Domain != "" {
+		query.Set("domain_name", req.Domain)
+	}
+
+	switch strings.ToLower(req.Sort) {
+	case "oldest":
+		query.Set("sort", "created")
+		query.Set("order", "asc")
+	case "newest":
+		fallthrough
+	default:
+		// Wallabag can't sort by title or site, so fall back to the newest first.
+		query.Set("sort", "created")
+		query.Set("order", "desc")
+	}
+
+	return query.Encode()
+}
+
+type tag struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Slug  string `json:"slug"`
+}
+
+type entry struct {
+	ID             int           `json:"id"`
+	URL            string        `json:"url"`
+	GivenURL       string        `json:"given_url"`
+	Title          string        `json:"title"`
+	Content        string        `json:"content"`
+	IsArchived     int           `json:"is_archived"`
+	IsStarred      int           `json:"is_starred"`
+	CreatedAt      wallabagTime  `json:"created_at"`
+	UpdatedAt      wallabagTime  `json:"updated_at"`
+	StarredAt      *wallabagTime `json:"starred_at"`
+	PublishedAt    *wallabagTime `json:"published_at"`
+	PublishedBy    []string      `json:"published_by"`
+	MimeType       string        `json:"mimetype"`
+	Language       string        `json:"language"`
+	ReadingTime    int           `json:"reading_time"`
+	DomainName     string        `json:"domain_name"`
+	PreviewPicture string        `json:"preview_picture"`
+	Tags           []tag         `json:"tags"`
+}
+
+type entriesResponse struct {
+	Page     int `json:"page"`
+	Limit    int `json:"limit"`
+	Pages    int `json:"pages"`
+	Total    int `json:"total"`
+	Embedded struct {
+		Items []entry `json:"items"`
+	} `json:"_embedded"`
+}
+
+func (e entry) itemID() string {
+	return strconv.Itoa(e.ID)
+}
+
+// Wallabag estimates reading time at 200 words per minute, so use that to
+// approximate the word count, which it doesn't return.
+func (e entry) wordCount() int {
+	return e.ReadingTime * 200
+}
+
+func (e entry) toPocketItem() pocketapi.GetResponseItem {
+	id := e.itemID()
+
+	timeFavorited := "0"
+	if e.IsStarred == 1 && e.StarredAt != nil {
+		timeFavorited = strconv.FormatInt(e.StarredAt.Unix(), 10)
+	}
+
+	var domainMeta *pocketapi.DomainMetadata
+	if e.DomainName != "" {
+		domainMeta = &pocketapi.DomainMetadata{Name: e.DomainName}
+	}
+
+	var authors map[string]pocketapi.Author
+	if len(e.PublishedBy) > 0 {
+		authors = make(map[string]pocketapi.Author)
+		for _, a := range e.PublishedBy {
+			authorID := pocketapi.Digest(a)
+			authors[authorID] = pocketapi.Author{
+				AuthorID: authorID,
+				Name:     a,
+				ItemID:   id,
+			}
+		}
+	}
+
+	hasImage := "0"
+	var image *pocketapi.Image
+	if e.PreviewPicture != "" {
+		hasImage = "1"
+		image = &pocketapi.Image{
+			ItemID:  id,
+			ImageID: pocketapi.Digest(e.PreviewPicture),
+			Src:     e.PreviewPicture,
+		}
+	}
+
+	status := "0"
+	if e.IsArchived == 1 {
+		status = "1"
+	}
+
+	var tags []string
+	for _, t := range e.Tags {
+		tags = append(tags, t.Label)
+	}
+
+	givenURL := e.GivenURL
+	if givenURL == "" {
+		givenURL = e.URL
+	}
+
+	return pocketapi.GetResponseItem{
+		ItemID:         id,
+		Favorite:       pocketapi.OneIfTrue(e.IsStarred == 1),
+		Status:         status,
+		TimeAdded:      strconv.FormatInt(e.CreatedAt.Unix(), 10),
+		TimeUpdated:    strconv.FormatInt(e.UpdatedAt.Unix(), 10),
+		TimeFavorited:  timeFavorited,
+		Tags:           pocketapi.NewTags(id, tags),
+		ResolvedID:     id,
+		GivenURL:       givenURL,
+		GivenTitle:     e.Title,
+		ResolvedTitle:  e.Title,
+		ResolvedURL:    e.URL,
+		IsArticle:      pocketapi.OneIfTrue(strings.HasPrefix(e.MimeType, "text/html") || e.MimeType == ""),
+		IsIndex:        "0",
+		HasVideo:       "0",
+		WordCount:      strconv.Itoa(e.wordCount()),
+		Lang:           e.Language,
+		TimeToRead:     e.ReadingTime,
+		DomainMetadata: domainMeta,
+		Authors:        authors,
+		HasImage:       hasImage,
+		Image:          image,
+		TopImageURL:    e.PreviewPicture,
+	}
+}
+
+func (conn *WallabagConn) Get(ctx context.Context, req pocketapi.GetRequest) (pocketapi.GetResponse, error) {
+	bagReq, err := conn.createRequest(ctx, http.MethodGet, "entries.json", nil)
+	if err != nil {
+		return pocketapi.GetResponse{}, err
+	}
+	bagReq.URL.RawQuery = buildGetQuerystring(req)
+
+	bagRes, err := conn.do(bagReq)
+	if err != nil {
+		return pocketapi.GetResponse{}, err
+	}
+	defer bagRes.Body.Close()
+
+	var entries entriesResponse
+	if err := json.NewDecoder(bagRes.Body).Decode(&entries); err != nil {
+		return pocketapi.GetResponse{}, err
+	}
+
+	var pocketRes pocketapi.GetResponse
+	pocketRes.Status = 1
+	pocketRes.Total = entries.Total
+	pocketRes.List = map[string]pocketapi.GetResponseItem{}
+	for _, e := range entries.Embedded.Items {
+		pocketRes.List[e.itemID()] = e.toPocketItem()
+	}
+	return pocketRes, nil
+}
+
+func (conn *WallabagConn) getEntry(ctx context.Context, itemID string) (entry, error) {
+	bagReq, err := conn.createRequest(ctx, http.MethodGet, fmt.Sprintf("entries/%s.json", itemID), nil)
+	if err != nil {
+		return entry{}, err
+	}
+
+	bagRes, err := conn.do(bagReq)
+	if err != nil {
+		return entry{}, err
+	}
+	defer bagRes.Body.Close()
+
+	var e entry
+	if err := json.NewDecoder(bagRes.Body).Decode(&e); err != nil {
+		return entry{}, err
+	}
+	return e, nil
+}
+
+// findEntryID looks up the ID of the entry saved with the given URL.
+func (conn *WallabagConn) findEntryID(ctx context.Context, articleUrl string) (string, error) {
+	bagReq, err := conn.createRequest(ctx, http.MethodGet, "entries/exists.json", nil)
+	if err != nil {
+		return "", err
+	}
+	bagReq.URL.RawQuery = url.Values{"url": {articleUrl}, "return_id": {"1"}}.Encode()
+
+	bagRes, err := conn.do(bagReq)
+	if err != nil {
+		return "", err
+	}
+	defer bagRes.Body.Close()
+
+	// This is either an ID or null when return_id is set, but older
+	// versions of Wallabag ignore return_id and return a boolean.
+	var body struct {
+		Exists json.RawMessage `json:"exists"`
+	}
+	if err := json.NewDecoder(bagRes.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	var id int
+	if err := json.Unmarshal(body.Exists, &id); err != nil || id == 0 {
+		return "", fmt.Errorf("unable to find an entry for URL %s in Wallabag", articleUrl)
+	}
+	return strconv.Itoa(id), nil
+}
@@ -0,0 +1,367 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"proxyserver/pocketapi"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func numPointer[T int | int64](value T) *T {
+	return &value
+}
+
+// newTestServer starts a fake Wallabag instance which hands out the token "token123"
+// and passes all authenticated API calls to apiHandler.
+func newTestServer(t *testing.T, apiHandler http.HandlerFunc) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/v2/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Unable to parse token request: %v", err)
+		}
+		if r.Form.Get("client_id") != "id" || r.Form.Get("client_secret") != "secret" {
+			t.Errorf("Unexpected client credentials: %v", r.Form)
+		}
+		json.NewEncoder(w).Encode(tokenResponse{
+			AccessToken:  "token123",
+			RefreshToken: "refresh123",
+			ExpiresIn:    3600,
+			TokenType:    "bearer",
+		})
+	})
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		wantToken := "Bearer token123"
+		if r.Header.Get("Authorization") != wantToken {
+			t.Errorf("Unexpected authorization header: want %s got %s", wantToken, r.Header.Get("Authorization"))
+		}
+		apiHandler(w, r)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestConn(server *httptest.Server) *WallabagConn {
+	return NewWallabagConn(server.URL, "id", "secret", "user", "password")
+}
+
+func TestWallabag_GetRequest(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request pocketapi.GetRequest
+		want    url.Values
+	}{
+		{
+			name:    "Defaults",
+			request: pocketapi.GetRequest{},
+			want: url.Values{
+				"detail": {"metadata"},
+				"sort":   {"created"},
+				"order":  {"desc"},
+			},
+		},
+		{
+			name: "Count & Offset",
+			request: pocketapi.GetRequest{
+				Count:  numPointer(30),
+				Offset: numPointer(60),
+			},
+			want: url.Values{
+				"detail":  {"metadata"},
+				"sort":    {"created"},
+				"order":   {"desc"},
+				"perPage": {"30"},
+				"page":    {"3"},
+			},
+		},
+		{
+			name: "Since",
+			request: pocketapi.GetRequest{
+				Since: numPointer(int64(1751296089)),
+			},
+			want: url.Values{
+				"detail": {"metadata"},
+				"sort":   {"created"},
+				"order":  {"desc"},
+				"since":  {"1751296089"},
+			},
+		},
+		{
+			name: "Archived Favorites Oldest",
+			request: pocketapi.GetRequest{
+				State:    "archive",
+				Favorite: "1",
+				Sort:     "oldest",
+			},
+			want: url.Values{
+				"detail":  {"metadata"},
+				"sort":    {"created"},
+				"order":   {"asc"},
+				"archive": {"1"},
+				"starred": {"1"},
+			},
+		},
+		{
+			name: "Unread Not Favorite",
+			request: pocketapi.GetRequest{
+				State:    "unread",
+				Favorite: "0",
+			},
+			want: url.Values{
+				"detail":  {"metadata"},
+				"sort":    {"created"},
+				"order":   {"desc"},
+				"archive": {"0"},
+				"starred": {"0"},
+			},
+		},
+		{
+			name: "Tag & Domain",
+			request: pocketapi.GetRequest{
+				Tag:    "news",
+				Domain: "example.com",
+				Search: "ignored",
+			},
+			want: url.Values{
+				"detail":      {"metadata"},
+				"sort":        {"created"},
+				"order":       {"desc"},
+				"tags":        {"news"},
+				"domain_name": {"example.com"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("Unexpected HTTP method, want GET got %s", r.Method)
+				}
+				if r.URL.Path != "/api/entries.json" {
+					t.Errorf("Unexpected path, want /api/entries.json got %s", r.URL.Path)
+				}
+				if diff := cmp.Diff(tc.want, r.URL.Query()); diff != "" {
+					t.Errorf("GET query mismatch (-want +got):\n%s", diff)
+				}
+				w.Write([]byte(`{"_embedded": {"items": []}}`))
+			})
+			defer server.Close()
+
+			if _, err := newTestConn(server).Get(context.Background(), tc.request); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWallabag_GetResponse(t *testing.T) {
+	testCases := []struct {
+		name         string
+		jsonResponse string
+		responseCode int
+		want         pocketapi.GetResponse
+		wantError    bool
+	}{
+		{
+			name:         "Empty",
+			responseCode: http.StatusOK,
+			jsonResponse: `{"page": 1, "limit": 30, "pages": 1, "total": 0, "_embedded": {"items": []}}`,
+			want: pocketapi.GetResponse{
+				Status: 1,
+				List:   map[string]pocketapi.GetResponseItem{},
+			},
+		},
+		{
+			name:         "Error",
+			responseCode: http.StatusInternalServerError,
+			wantError:    true,
+		},
+		{
+			name:         "Two Items",
+			responseCode: http.StatusOK,
+			jsonResponse: `{
+				"page": 1, "limit": 30, "pages": 1, "total": 2,
+				"_embedded": {"items": [{
+					"id": 12,
+					"url": "https://some-news-website.org/something-great-happened/",
+					"given_url": "https://some-news-website.org/something-great-happened/?utm=1",
+					"title": "Something Great & Awesome Happened",
+					"is_archived": 0,
+					"is_starred": 1,
+					"created_at": "2025-06-30T17:08:09+0200",
+					"updated_at": "2025-06-30T17:08:12+0200",
+					"starred_at": "2025-06-30T17:08:12+0200",
+					"published_by": ["John Doe"],
+					"mimetype": "text/html",
+					"language": "en",
+					"reading_time": 10,
+					"domain_name": "some-news-website.org",
+					"preview_picture": "https://some-news-website.org/image.jpeg",
+					"tags": []
+				}, {
+					"id": 13,
+					"url": "https://some-news-website.org/something-else-happened/",
+					"title": "Something Else Happened",
+					"is_archived": 1,
+					"is_starred": 0,
+					"created_at": "2025-06-30T17:08:09+0200",
+					"updated_at": "2025-06-30T17:08:12+0200",
+					"starred_at": null,
+					"published_by": null,
+					"mimetype": "text/html",
+					"language": "en",
+					"reading_time": 1,
+					"domain_name": "",
+					"preview_picture": null
+				}]}
+			}`,
+			want: pocketapi.GetResponse{
+				Status: 1,
+				Total:  2,
+				List: map[string]pocketapi.GetResponseItem{
+					"12": {
+						ItemID:         "12",
+						Favorite:       "1",
+						Status:         "0",
+						TimeAdded:      "1751296089",
+						TimeUpdated:    "1751296092",
+						TimeFavorited:  "1751296092",
+						TopImageURL:    "https://some-news-website.org/image.jpeg",
+						ResolvedID:     "12",
+						GivenURL:       "https://some-news-website.org/something-great-happened/?utm=1",
+						GivenTitle:     "Something Great & Awesome Happened",
+						ResolvedTitle:  "Something Great & Awesome Happened",
+						ResolvedURL:    "https://some-news-website.org/something-great-happened/",
+						IsArticle:      "1",
+						IsIndex:        "0",
+						HasVideo:       "0",
+						HasImage:       "1",
+						WordCount:      "2000",
+						Lang:           "en",
+						TimeToRead:     10,
+						DomainMetadata: &pocketapi.DomainMetadata{Name: "some-news-website.org"},
+						Authors: map[string]pocketapi.Author{
+							pocketapi.Digest("John Doe"): {AuthorID: pocketapi.Digest("John Doe"), Name: "John Doe", ItemID: "12"},
+						},
+						Image: &pocketapi.Image{
+							ItemID:  "12",
+							ImageID: pocketapi.Digest("https://some-news-website.org/image.jpeg"),
+							Src:     "https://some-news-website.org/image.jpeg",
+						},
+					},
+					"13": {
+						ItemID:        "13",
+						Favorite:      "0",
+						Status:        "1",
+						TimeAdded:     "1751296089",
+						TimeUpdated:   "1751296092",
+						TimeFavorited: "0",
+						ResolvedID:    "13",
+						GivenURL:      "https://some-news-website.org/something-else-happened/",
+						GivenTitle:    "Something Else Happened",
+						ResolvedTitle: "Something Else Happened",
+						ResolvedURL:   "https://some-news-website.org/something-else-happened/",
+						IsArticle:     "1",
+						IsIndex:       "0",
+						HasVideo:      "0",
+						HasImage:      "0",
+						WordCount:     "200",
+						Lang:          "en",
+						TimeToRead:    1,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.responseCode)
+				w.Write([]byte(tc.jsonResponse))
+			})
+			defer server.Close()
+
+			res, err := newTestConn(server).Get(context.Background(), pocketapi.GetRequest{})
+
+			if tc.wantError && err == nil {
+				t.Error("Wanted error, got nil instead")
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("Wanted nil error, got %v instead", err)
+			}
+			if diff := cmp.Diff(tc.want, res); diff != "" {
+				t.Errorf("GET response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWallabag_TokenReuseAndRefresh(t *testing.T) {
+	var passwordLogins, refreshes atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/v2/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("grant_type") {
+		case "password":
+			passwordLogins.Add(1)
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh123" {
+				t.Errorf("Unexpected refresh token: %s", r.Form.Get("refresh_token"))
+			}
+			refreshes.Add(1)
+		}
+		// Expire immediately, so the next call has to refresh.
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "token123", RefreshToken: "refresh123", ExpiresIn: 0})
+	})
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"_embedded": {"items": []}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn := newTestConn(server)
+	for range 3 {
+		if _, err := conn.Get(context.Background(), pocketapi.GetRequest{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if passwordLogins.Load() != 1 {
+		t.Errorf("Unexpected number of password logins, want 1 got %d", passwordLogins.Load())
+	}
+	if refreshes.Load() != 2 {
+		t.Errorf("Unexpected number of token refreshes, want 2 got %d", refreshes.Load())
+	}
+}
+
+func TestWallabag_LoginFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "Invalid username and password combination"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestConn(server).Get(context.Background(), pocketapi.GetRequest{})
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Wanted invalid_grant error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"proxyserver/httpclient"
+	"time"
+)
+
+var pointerOne int = 1
+var pointerZero int = 0
+
+type updateRequest struct {
+	Archive *int `json:"archive,omitempty"`
+	Starred *int `json:"starred,omitempty"`
+}
+
+func sendUpdate(ctx context.Context, conn *WallabagConn, itemID string, params updateRequest) error {
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(params); err != nil {
+		return err
+	}
+
+	bagReq, err := conn.createRequest(ctx, http.MethodPatch, fmt.Sprintf("entries/%s.json", itemID), &buffer)
+	if err != nil {
+		return err
+	}
+	bagReq.Header.Set("Content-Type", "application/json")
+
+	// Updates set fields to fixed values, so they're safe to retry.
+	bagRes, err := conn.do(httpclient.Idempotent(bagReq))
+	if err != nil {
+		return err
+	}
+	return bagRes.Body.Close()
+}
+
+type insertRequest struct {
+	Url   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+func (conn *WallabagConn) Add(ctx context.Context, url string, title string, time time.Time) (string, error) {
+	body := insertRequest{Url: url, Title: title}
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(body); err != nil {
+		return "", err
+	}
+
+	bagReq, err := conn.createRequest(ctx, http.MethodPost, "entries.json", &buffer)
+	if err != nil {
+		return "", err
+	}
+	bagReq.Header.Set("Content-Type", "application/json")
+
+	bagRes, err := conn.do(bagReq)
+	if err != nil {
+		return "", err
+	}
+	defer bagRes.Body.Close()
+
+	var created entry
+	if err := json.NewDecoder(bagRes.Body).Decode(&created); err != nil || created.ID == 0 {
+		// The entry was still saved.
+		return "", nil
+	}
+	return created.itemID(), nil
+}
+
+func (conn *WallabagConn) Archive(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Archive: &pointerOne})
+}
+
+func (conn *WallabagConn) Unarchive(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Archive: &pointerZero})
+}
+
+func (conn *WallabagConn) Delete(ctx context.Context, itemID string, time time.Time) error {
+	bagReq, err := conn.createRequest(ctx, http.MethodDelete, fmt.Sprintf("entries/%s.json", itemID), nil)
+	if err != nil {
+		return err
+	}
+
+	bagRes, err := conn.do(bagReq)
+	if err != nil {
+		return err
+	}
+	return bagRes.Body.Close()
+}
+
+func (conn *WallabagConn) Favorite(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Starred: &pointerOne})
+}
+
+func (conn *WallabagConn) Unfavorite(ctx context.Context, itemID string, time time.Time) error {
+	return sendUpdate(ctx, conn, itemID, updateRequest{Starred: &pointerZero})
+}
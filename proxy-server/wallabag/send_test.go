@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func TestWallabag_SendUpdate(t *testing.T) {
+	const itemID = "12"
+
+	testCases := []struct {
+		name       string
+		update     func(*WallabagConn) error
+		statusCode int
+		wantError  bool
+		wantMethod string
+		wantBody   *updateRequest
+	}{
+		{
+			name:       "Archive",
+			statusCode: http.StatusOK,
+			update: func(conn *WallabagConn) error {
+				return conn.Archive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Archive: intPointer(1)},
+		},
+		{
+			name:       "Unarchive",
+			statusCode: http.StatusOK,
+			update: func(conn *WallabagConn) error {
+				return conn.Unarchive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Archive: intPointer(0)},
+		},
+		{
+			name:       "Favorite",
+			statusCode: http.StatusOK,
+			update: func(conn *WallabagConn) error {
+				return conn.Favorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Starred: intPointer(1)},
+		},
+		{
+			name:       "Unfavorite",
+			statusCode: http.StatusOK,
+			update: func(conn *WallabagConn) error {
+				return conn.Unfavorite(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Starred: intPointer(0)},
+		},
+		{
+			name:       "Delete",
+			statusCode: http.StatusOK,
+			update: func(conn *WallabagConn) error {
+				return conn.Delete(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodDelete,
+		},
+		{
+			name:       "Error",
+			statusCode: http.StatusNotFound,
+			update: func(conn *WallabagConn) error {
+				return conn.Archive(context.Background(), itemID, time.Time{})
+			},
+			wantMethod: http.MethodPatch,
+			wantBody:   &updateRequest{Archive: intPointer(1)},
+			wantError:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tc.wantMethod {
+					t.Errorf("Unexpected HTTP method, want %s got %s", tc.wantMethod, r.Method)
+				}
+				if r.URL.Path != "/api/entries/12.json" {
+					t.Errorf("Unexpected path, want /api/entries/12.json got %s", r.URL.Path)
+				}
+				if tc.wantBody != nil {
+					var got updateRequest
+					if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+						t.Errorf("Unable to decode request body: %v", err)
+					}
+					if diff := cmp.Diff(*tc.wantBody, got); diff != "" {
+						t.Errorf("Request body mismatch (-want +got):\n%s", diff)
+					}
+				}
+				w.WriteHeader(tc.statusCode)
+			})
+			defer server.Close()
+
+			err := tc.update(newTestConn(server))
+			if tc.wantError && err == nil {
+				t.Error("Wanted error, got nil instead")
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("Wanted nil error, got %v instead", err)
+			}
+		})
+	}
+}
+
+func TestWallabag_Add(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/entries.json" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var got insertRequest
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Unable to decode request body: %v", err)
+		}
+		want := insertRequest{Url: "https://test.com/article", Title: "Title"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Request body mismatch (-want +got):\n%s", diff)
+		}
+		w.Write([]byte(`{"id": 12}`))
+	})
+	defer server.Close()
+
+	itemID, err := newTestConn(server).Add(context.Background(), "https://test.com/article", "Title", time.Time{})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if itemID != "12" {
+		t.Errorf("Wanted item ID 12, got %q", itemID)
+	}
+}
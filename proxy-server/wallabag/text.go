@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"fmt"
+	"proxyserver/pocketapi"
+	"strings"
+	"time"
+)
+
+func copyFromEntry(e entry, article *pocketapi.ArticleTextResponse) {
+	pocketItem := e.toPocketItem()
+
+	article.ItemID = pocketItem.ItemID
+	article.ResolvedID = pocketItem.ItemID
+	article.GivenURL = pocketItem.GivenURL
+	article.NormalURL = e.URL
+	article.ResolvedNormalURL = e.URL
+	article.ResolvedURL = e.URL
+	article.DateResolved = e.CreatedAt.Format(time.RFC3339)
+	article.TimeToRead = &e.ReadingTime
+
+	article.HasVideo = "0"
+	article.Host = e.DomainName
+	article.Title = e.Title
+	if e.PublishedAt != nil {
+		article.DatePublished = e.PublishedAt.Format(time.RFC3339)
+	}
+	article.ResponseCode = "200"
+	article.MimeType = e.MimeType
+	article.TopImageURL = e.PreviewPicture
+
+	article.HasImage = pocketItem.HasImage
+	article.Authors = pocketItem.Authors
+	wordCount := e.wordCount()
+	article.WordCount = &wordCount
+	one := 1
+	article.IsArticle = &one
+	zero := 0
+	article.IsIndex = &zero
+	article.IsVideo = &zero
+	article.Lang = e.Language
+}
+
+func (conn *WallabagConn) ArticleText(ctx context.Context, url string) (pocketapi.ArticleTextResponse, error) {
+	id, err := conn.findEntryID(ctx, url)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+
+	e, err := conn.getEntry(ctx, id)
+	if err != nil {
+		return pocketapi.ArticleTextResponse{}, err
+	}
+	article := pocketapi.ArticleTextResponse{}
+	copyFromEntry(e, &article)
+
+	article.Encoding = "utf-8"
+	if err := pocketapi.ParseArticleText(strings.NewReader(e.Content), &article); err != nil {
+		return pocketapi.ArticleTextResponse{}, fmt.Errorf("error parsing article HTML: %v", err)
+	}
+
+	return article, nil
+}
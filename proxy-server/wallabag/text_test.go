@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallabag
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestWallabag_ArticleText(t *testing.T) {
+	const articleUrl = "https://some-news-website.org/something-great-happened/"
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/entries/exists.json":
+			if got := r.URL.Query().Get("url"); got != articleUrl {
+				t.Errorf("Unexpected URL lookup, want %s got %s", articleUrl, got)
+			}
+			w.Write([]byte(`{"exists": 12}`))
+		case "/api/entries/12.json":
+			w.Write([]byte(`{
+				"id": 12,
+				"url": "https://some-news-website.org/something-great-happened/",
+				"title": "Something Great & Awesome Happened",
+				"content": "<p>Hello</p><img src=\"https://some-news-website.org/img.png\" width=\"20\" height=\"10\">",
+				"created_at": "2025-06-30T17:08:09+0200",
+				"updated_at": "2025-06-30T17:08:12+0200",
+				"mimetype": "text/html",
+				"language": "en",
+				"reading_time": 3,
+				"domain_name": "some-news-website.org"
+			}`))
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+
+	article, err := newTestConn(server).ArticleText(context.Background(), articleUrl)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if article.ItemID != "12" || article.Title != "Something Great & Awesome Happened" {
+		t.Errorf("Unexpected item ID or title: %s %s", article.ItemID, article.Title)
+	}
+	wantArticle := "<div><p>Hello</p><!--IMG_1--></div>"
+	if article.Article != wantArticle {
+		t.Errorf("Unexpected article HTML, want %s got %s", wantArticle, article.Article)
+	}
+	img, exists := article.Images["1"]
+	if !exists || img.Src != "https://some-news-website.org/img.png" || img.Width != "20" || img.Height != "10" {
+		t.Errorf("Unexpected image: %+v", article.Images)
+	}
+	if *article.WordCount != 600 {
+		t.Errorf("Unexpected word count, want 600 got %d", *article.WordCount)
+	}
+}
+
+func TestWallabag_ArticleTextNotFound(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"exists": null}`))
+	})
+	defer server.Close()
+
+	if _, err := newTestConn(server).ArticleText(context.Background(), "https://test.com/missing"); err == nil {
+		t.Error("Wanted error, got nil instead")
+	}
+}